@@ -0,0 +1,20 @@
+// Command propre-vet runs the analyzers of propre with go vet:
+//
+//	go install github.com/cyb3rd4d/propre/analysis/cmd/propre-vet@latest
+//	go vet -vettool=$(which propre-vet) ./...
+//
+// The analyzers are:
+//   - errorfield, see [github.com/cyb3rd4d/propre/analysis/errorfield],
+//   - layers, see [github.com/cyb3rd4d/propre/analysis/layers].
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	"github.com/cyb3rd4d/propre/analysis/errorfield"
+	"github.com/cyb3rd4d/propre/analysis/layers"
+)
+
+func main() {
+	unitchecker.Main(errorfield.Analyzer, layers.Analyzer)
+}
@@ -0,0 +1,323 @@
+// Package errorfield defines an analyzer which checks that the use cases check
+// the error carried by their input before reading its data, and that the
+// presenters handle the error carried by their output.
+//
+// An input or an output carries an error when it has an Error or Err field of
+// type error, like the Input types built by the request decoders, or when it
+// has an error state like the Result monad of samber/mo: an Error() error, an
+// Err() error, an IsError() bool, an IsErr() bool or an IsOk() bool method.
+//
+// The use cases are the methods with the signature of
+// propre.UseCaseHandler.Handle, the presenters are the methods with the
+// signature of propre.Presenter.Present.
+package errorfield
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const doc = `check that use cases and presenters handle the errors carried by their input and output
+
+A use case which reads the data of its input before checking its error works
+on the zero values left by a failed request decoding. A presenter which never
+reads the error of its output presents a failed use case as a success.`
+
+// Analyzer reports the use cases reading the data of their input before
+// checking its error, and the presenters ignoring the error of their output.
+var Analyzer = &analysis.Analyzer{
+	Name:     "errorfield",
+	Doc:      doc,
+	URL:      "https://pkg.go.dev/github.com/cyb3rd4d/propre/analysis/errorfield",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	errorType      = types.Universe.Lookup("error").Type()
+	errorFields    = []string{"Error", "Err"}
+	errorMethods   = []string{"Error", "Err"}
+	errorPredicate = []string{"IsError", "IsErr", "IsOk"}
+)
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		decl := n.(*ast.FuncDecl)
+		if decl.Recv == nil || decl.Body == nil {
+			return
+		}
+
+		fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+		if !ok {
+			return
+		}
+
+		signature := fn.Type().(*types.Signature)
+		switch {
+		case fn.Name() == "Handle" && isUseCaseSignature(signature):
+			checkUseCase(pass, decl, signature.Params().At(1))
+		case fn.Name() == "Present" && isPresenterSignature(signature):
+			checkPresenter(pass, decl, signature.Params().At(2))
+		}
+	})
+
+	return nil, nil
+}
+
+// isUseCaseSignature tells if the signature is the one of a use case,
+// func(context.Context, Input) Output, with an input carrying an error.
+func isUseCaseSignature(signature *types.Signature) bool {
+	return signature.Params().Len() == 2 &&
+		signature.Results().Len() == 1 &&
+		isContext(signature.Params().At(0).Type()) &&
+		carriesError(signature.Params().At(1).Type())
+}
+
+// isPresenterSignature tells if the signature is the one of a presenter,
+// func(context.Context, Writer, Output), with an output carrying an error.
+func isPresenterSignature(signature *types.Signature) bool {
+	return signature.Params().Len() == 3 &&
+		signature.Results().Len() == 0 &&
+		isContext(signature.Params().At(0).Type()) &&
+		carriesError(signature.Params().At(2).Type())
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// carriesError tells if the type has an error field or an error state.
+func carriesError(t types.Type) bool {
+	if _, ok := t.(*types.TypeParam); ok {
+		return false
+	}
+
+	for _, name := range errorFields {
+		if field, ok := lookup(t, name).(*types.Var); ok && field.IsField() && types.Identical(field.Type(), errorType) {
+			return true
+		}
+	}
+
+	for _, name := range errorMethods {
+		if method, ok := lookup(t, name).(*types.Func); ok && returns(method, errorType) {
+			return true
+		}
+	}
+
+	for _, name := range errorPredicate {
+		if method, ok := lookup(t, name).(*types.Func); ok && returns(method, types.Typ[types.Bool]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lookup(t types.Type, name string) types.Object {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name)
+	return obj
+}
+
+// returns tells if the method takes no argument and returns a single value of the type.
+func returns(method *types.Func, t types.Type) bool {
+	signature := method.Type().(*types.Signature)
+	return signature.Params().Len() == 0 &&
+		signature.Results().Len() == 1 &&
+		types.Identical(signature.Results().At(0).Type(), t)
+}
+
+// use is a use of the input or the output in the body of a method.
+type use int
+
+const (
+	// useData reads the data.
+	useData use = iota
+	// useError reads the error or the error state.
+	useError
+	// useWhole uses the value as a whole, like passing it to a function,
+	// which might check the error.
+	useWhole
+)
+
+// firstUses returns the position of the first use of the variable of each kind,
+// in the order of the source.
+func firstUses(pass *analysis.Pass, body *ast.BlockStmt, v *types.Var) map[use]ast.Node {
+	uses := make(map[use]ast.Node)
+	record := func(kind use, n ast.Node) {
+		if _, ok := uses[kind]; !ok {
+			uses[kind] = n
+		}
+	}
+
+	var stack []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+
+		stack = append(stack, n)
+		if lit, ok := n.(*ast.CompositeLit); ok && propagatesError(pass, lit, v) {
+			record(useError, lit)
+			return true
+		}
+
+		ident, ok := n.(*ast.Ident)
+		if !ok || pass.TypesInfo.Uses[ident] != v {
+			return true
+		}
+
+		selector, ok := stack[len(stack)-2].(*ast.SelectorExpr)
+		if !ok || selector.X != ident {
+			record(useWhole, ident)
+			return true
+		}
+
+		if isErrorSelection(pass, selector) {
+			record(useError, selector)
+		} else {
+			record(useData, selector)
+		}
+
+		return true
+	})
+
+	return uses
+}
+
+// propagatesError tells if the composite literal copies the error of the
+// variable to an error field, like Output{Title: input.Title, Error: input.Error}:
+// the data read in the literal go along with the error, whatever the order of
+// the fields. The keyed fields are resolved by name, the other ones by position.
+func propagatesError(pass *analysis.Pass, lit *ast.CompositeLit, v *types.Var) bool {
+	t := pass.TypesInfo.TypeOf(lit)
+	if t == nil {
+		return false
+	}
+
+	structType, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+
+	for i, elt := range lit.Elts {
+		var field *types.Var
+		value := elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+
+			field, _ = pass.TypesInfo.Uses[key].(*types.Var)
+			value = kv.Value
+		} else if i < structType.NumFields() {
+			field = structType.Field(i)
+		}
+
+		if field != nil && types.Identical(field.Type(), errorType) && readsErrorOf(pass, value, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readsErrorOf tells if the expression reads the error of the variable, like
+// input.Error or input.Error().
+func readsErrorOf(pass *analysis.Pass, expr ast.Expr, v *types.Var) bool {
+	expr = ast.Unparen(expr)
+	if call, ok := expr.(*ast.CallExpr); ok {
+		expr = ast.Unparen(call.Fun)
+	}
+
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+
+	ident, ok := ast.Unparen(selector.X).(*ast.Ident)
+	return ok && pass.TypesInfo.Uses[ident] == v && isErrorSelection(pass, selector)
+}
+
+// isErrorSelection tells if the selector reads the error or the error state:
+// an error field, or a method returning an error or a boolean, or taking a
+// function with an error argument like the Match method of a Result.
+func isErrorSelection(pass *analysis.Pass, selector *ast.SelectorExpr) bool {
+	selection, ok := pass.TypesInfo.Selections[selector]
+	if !ok {
+		return false
+	}
+
+	switch selection.Kind() {
+	case types.FieldVal:
+		return types.Identical(selection.Obj().Type(), errorType)
+	case types.MethodVal:
+		signature := selection.Obj().Type().(*types.Signature)
+		for i := range signature.Results().Len() {
+			result := signature.Results().At(i).Type()
+			if types.Identical(result, errorType) || types.Identical(result, types.Typ[types.Bool]) {
+				return true
+			}
+		}
+
+		for i := range signature.Params().Len() {
+			if callback, ok := signature.Params().At(i).Type().Underlying().(*types.Signature); ok {
+				for j := range callback.Params().Len() {
+					if types.Identical(callback.Params().At(j).Type(), errorType) {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func checkUseCase(pass *analysis.Pass, decl *ast.FuncDecl, input *types.Var) {
+	if input.Name() == "" || input.Name() == "_" {
+		return
+	}
+
+	uses := firstUses(pass, decl.Body, input)
+	read, ok := uses[useData]
+	if !ok {
+		return
+	}
+
+	for _, kind := range []use{useError, useWhole} {
+		if checked, ok := uses[kind]; ok && checked.Pos() < read.Pos() {
+			return
+		}
+	}
+
+	pass.ReportRangef(read, "the use case reads %s before checking the error of %s", types.ExprString(read.(ast.Expr)), input.Name())
+}
+
+func checkPresenter(pass *analysis.Pass, decl *ast.FuncDecl, output *types.Var) {
+	if output.Name() != "" && output.Name() != "_" {
+		uses := firstUses(pass, decl.Body, output)
+		if _, ok := uses[useError]; ok {
+			return
+		}
+
+		if _, ok := uses[useWhole]; ok {
+			return
+		}
+	}
+
+	pass.ReportRangef(decl.Name, "the presenter never handles the error of its output")
+}
@@ -0,0 +1,13 @@
+package errorfield_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/cyb3rd4d/propre/analysis/errorfield"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), errorfield.Analyzer, "a")
+}
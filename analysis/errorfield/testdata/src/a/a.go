@@ -0,0 +1,178 @@
+package a
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+type Input struct {
+	Data struct {
+		Title string
+	}
+	Error error
+}
+
+type Output struct {
+	Data struct {
+		Title string
+	}
+	Error error
+}
+
+type CheckedUseCase struct{}
+
+func (u CheckedUseCase) Handle(ctx context.Context, input Input) Output {
+	var output Output
+	if input.Error != nil {
+		output.Error = input.Error
+		return output
+	}
+
+	output.Data.Title = input.Data.Title
+	return output
+}
+
+type UncheckedUseCase struct{}
+
+func (u UncheckedUseCase) Handle(ctx context.Context, input Input) Output {
+	var output Output
+	output.Data.Title = input.Data.Title // want `the use case reads input.Data before checking the error of input`
+	return output
+}
+
+type LateCheckUseCase struct{}
+
+func (u LateCheckUseCase) Handle(ctx context.Context, input Input) Output {
+	var output Output
+	title := input.Data.Title // want `the use case reads input.Data before checking the error of input`
+	if input.Error != nil {
+		output.Error = input.Error
+		return output
+	}
+
+	output.Data.Title = title
+	return output
+}
+
+type KeyedLiteralUseCase struct{}
+
+func (u KeyedLiteralUseCase) Handle(ctx context.Context, input Input) Output {
+	return Output{Data: input.Data, Error: input.Error}
+}
+
+type PositionalLiteralUseCase struct{}
+
+func (u PositionalLiteralUseCase) Handle(ctx context.Context, input Input) Output {
+	return Output{input.Data, input.Error}
+}
+
+type DroppingLiteralUseCase struct{}
+
+func (u DroppingLiteralUseCase) Handle(ctx context.Context, input Input) Output {
+	return Output{Data: input.Data, Error: errors.New("failure")} // want `the use case reads input.Data before checking the error of input`
+}
+
+type DelegatingUseCase struct {
+	next CheckedUseCase
+}
+
+func (u DelegatingUseCase) Handle(ctx context.Context, input Input) Output {
+	output := u.next.Handle(ctx, input)
+	output.Data.Title += input.Data.Title
+	return output
+}
+
+type IgnoringUseCase struct{}
+
+func (u IgnoringUseCase) Handle(ctx context.Context, _ Input) Output {
+	return Output{}
+}
+
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+func (r Result[T]) Error() error {
+	return r.err
+}
+
+func (r Result[T]) MustGet() T {
+	return r.value
+}
+
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+type ResultUseCase struct{}
+
+func (u ResultUseCase) Handle(ctx context.Context, input Result[string]) Result[int] {
+	if !input.IsOk() {
+		return Result[int]{err: input.Error()}
+	}
+
+	return Result[int]{value: len(input.MustGet())}
+}
+
+type GetResultUseCase struct{}
+
+func (u GetResultUseCase) Handle(ctx context.Context, input Result[string]) Result[int] {
+	value, err := input.Get()
+	return Result[int]{value: len(value), err: err}
+}
+
+type UncheckedResultUseCase struct{}
+
+func (u UncheckedResultUseCase) Handle(ctx context.Context, input Result[string]) Result[int] {
+	return Result[int]{value: len(input.MustGet())} // want `the use case reads input.MustGet before checking the error of input`
+}
+
+type NotAUseCase struct{}
+
+func (u NotAUseCase) Handle(ctx context.Context, input string) string {
+	return input
+}
+
+type CheckedPresenter struct{}
+
+func (p CheckedPresenter) Present(ctx context.Context, w io.Writer, output Output) {
+	if errors.Is(output.Error, io.EOF) {
+		return
+	}
+
+	io.WriteString(w, output.Data.Title)
+}
+
+type UncheckedPresenter struct{}
+
+func (p UncheckedPresenter) Present(ctx context.Context, w io.Writer, output Output) { // want `the presenter never handles the error of its output`
+	io.WriteString(w, output.Data.Title)
+}
+
+type IgnoringPresenter struct{}
+
+func (p IgnoringPresenter) Present(ctx context.Context, w io.Writer, _ Output) { // want `the presenter never handles the error of its output`
+	io.WriteString(w, "done")
+}
+
+type DelegatingPresenter struct {
+	next CheckedPresenter
+}
+
+func (p DelegatingPresenter) Present(ctx context.Context, w io.Writer, output Output) {
+	p.next.Present(ctx, w, output)
+}
+
+type ResultPresenter struct{}
+
+func (p ResultPresenter) Present(ctx context.Context, w io.Writer, output Result[int]) {
+	if output.IsOk() {
+		io.WriteString(w, "ok")
+	}
+}
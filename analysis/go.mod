@@ -0,0 +1,13 @@
+module github.com/cyb3rd4d/propre/analysis
+
+go 1.22.0
+
+require (
+	golang.org/x/tools v0.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.22.0 // indirect
+	golang.org/x/sync v0.10.0 // indirect
+)
@@ -0,0 +1,183 @@
+package layers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidConfig is returned when the configuration of the layers is invalid.
+var ErrInvalidConfig = errors.New("invalid layers configuration")
+
+// Layer is a layer of the architecture, like the domain or the use cases.
+type Layer struct {
+	// Name is the name of the layer, used in the Allow lists and in the reports.
+	Name string `yaml:"name"`
+	// Packages are the patterns of the packages of the layer.
+	Packages []string `yaml:"packages"`
+	// Allow are the names of the layers the packages of the layer can import.
+	// A package can always import the packages of its own layer.
+	Allow []string `yaml:"allow"`
+	// Forbidden are the patterns of the packages the layer must not import,
+	// like net/http for the domain.
+	Forbidden []string `yaml:"forbidden"`
+}
+
+// Config maps the packages to the layers and sets the rules of their dependencies.
+//
+// The patterns are import paths, which match the packages below them when they
+// end with "/...". A pattern starting with "./" is relative to the module holding
+// the configuration file. A package belongs to the first layer matching it, and the
+// packages without layer are not checked, except for the global forbidden imports.
+type Config struct {
+	Layers []Layer `yaml:"layers"`
+	// Forbidden are the patterns of the packages no package must import.
+	Forbidden []string `yaml:"forbidden"`
+	// Tests enables the checks of the test files.
+	Tests bool `yaml:"tests"`
+}
+
+// LoadConfig reads the configuration from the layers, forbidden and tests keys
+// of a YAML file, so the configuration can share the propre.yaml file of the
+// propre command:
+//
+//	layers:
+//	  - name: domain
+//	    packages: [./internal/domain/...]
+//	    forbidden: [net/http, database/sql]
+//	  - name: usecase
+//	    packages: [./internal/usecase/...]
+//	    allow: [domain]
+//	  - name: adapter
+//	    packages: [./internal/api/...]
+//	    allow: [usecase, domain]
+//	  - name: infrastructure
+//	    packages: [./internal/postgres/...]
+//	    allow: [domain]
+//	forbidden: [github.com/pkg/errors]
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%w caused by %w", ErrInvalidConfig, err)
+	}
+
+	module, err := modulePath(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%w caused by %w", ErrInvalidConfig, err)
+	}
+
+	config.resolve(module)
+	return &config, config.validate()
+}
+
+// resolve turns the relative patterns into import paths of the module.
+func (c *Config) resolve(module string) {
+	resolve := func(patterns []string) {
+		for i, pattern := range patterns {
+			if rest, ok := strings.CutPrefix(pattern, "./"); ok && module != "" {
+				patterns[i] = strings.TrimSuffix(module+"/"+rest, "/")
+			}
+		}
+	}
+
+	for _, layer := range c.Layers {
+		resolve(layer.Packages)
+		resolve(layer.Forbidden)
+	}
+
+	resolve(c.Forbidden)
+}
+
+func (c *Config) validate() error {
+	names := make(map[string]bool, len(c.Layers))
+	for _, layer := range c.Layers {
+		if layer.Name == "" {
+			return fmt.Errorf("%w: a layer has no name", ErrInvalidConfig)
+		}
+
+		if names[layer.Name] {
+			return fmt.Errorf("%w: the layer %s is declared twice", ErrInvalidConfig, layer.Name)
+		}
+
+		names[layer.Name] = true
+	}
+
+	for _, layer := range c.Layers {
+		for _, allowed := range layer.Allow {
+			if !names[allowed] {
+				return fmt.Errorf("%w: the layer %s allows the unknown layer %s", ErrInvalidConfig, layer.Name, allowed)
+			}
+		}
+	}
+
+	return nil
+}
+
+// layerOf returns the layer of the package, nil if it has none.
+func (c *Config) layerOf(pkg string) *Layer {
+	for i := range c.Layers {
+		if matchesAny(c.Layers[i].Packages, pkg) {
+			return &c.Layers[i]
+		}
+	}
+
+	return nil
+}
+
+func matchesAny(patterns []string, pkg string) bool {
+	for _, pattern := range patterns {
+		if matches(pattern, pkg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches tells if the package matches the pattern, "a/..." matching a and
+// the packages below it.
+func matches(pattern, pkg string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+	}
+
+	return pkg == pattern
+}
+
+// modulePath returns the path of the module of the directory, read from the
+// closest go.mod file, empty if there is none.
+func modulePath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return strings.Trim(strings.TrimSpace(module), `"`), nil
+				}
+			}
+
+			return "", nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
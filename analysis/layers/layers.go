@@ -0,0 +1,146 @@
+// Package layers defines an analyzer which enforces the dependency rules
+// between the layers of a clean architecture, set in a configuration file,
+// see [Config].
+//
+// The configuration file is given with the -layers.config flag, otherwise the
+// closest propre.yaml file above the analyzed package is used. The packages are
+// not checked without configuration.
+package layers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const doc = `check the imports against the dependency rules of the architecture layers
+
+The rules map the packages to layers, like domain, use case, adapter and
+infrastructure, and list the layers each layer can import and the packages it
+must not import, like net/http in the domain.`
+
+// configFile is the name of the configuration file searched above the packages.
+const configFile = "propre.yaml"
+
+// Analyzer reports the imports breaking the rules of the layers.
+var Analyzer = &analysis.Analyzer{
+	Name: "layers",
+	Doc:  doc,
+	URL:  "https://pkg.go.dev/github.com/cyb3rd4d/propre/analysis/layers",
+	Run:  run,
+}
+
+var (
+	configPath string
+
+	// configs caches the configurations by path, the analyzer runs once per package.
+	configs sync.Map
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&configPath, "config", "", "configuration file of the layers (default: the closest "+configFile+")")
+}
+
+type cachedConfig struct {
+	config *Config
+	err    error
+}
+
+func loadConfig(path string) (*Config, error) {
+	cached, ok := configs.Load(path)
+	if !ok {
+		config, err := LoadConfig(path)
+		cached, _ = configs.LoadOrStore(path, cachedConfig{config: config, err: err})
+	}
+
+	return cached.(cachedConfig).config, cached.(cachedConfig).err
+}
+
+// findConfig returns the closest configuration file above the directory.
+func findConfig(dir string) string {
+	for {
+		path := filepath.Join(dir, configFile)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	path := configPath
+	if path == "" && len(pass.Files) > 0 {
+		path = findConfig(filepath.Dir(pass.Fset.File(pass.Files[0].Pos()).Name()))
+	}
+
+	if path == "" {
+		return nil, nil
+	}
+
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := strings.TrimSuffix(pass.Pkg.Path(), "_test")
+	layer := config.layerOf(pkg)
+
+	for _, file := range pass.Files {
+		filename := pass.Fset.File(file.Pos()).Name()
+		if !config.Tests && strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+
+		for _, spec := range file.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+
+			if message := config.check(pkg, layer, imported); message != "" {
+				pass.Reportf(spec.Pos(), "%s", message)
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// check returns the violation of the rules by the import, empty if there is none.
+func (c *Config) check(pkg string, layer *Layer, imported string) string {
+	if matchesAny(c.Forbidden, imported) {
+		return fmt.Sprintf("%s must not import %s", pkg, imported)
+	}
+
+	if layer == nil {
+		return ""
+	}
+
+	if matchesAny(layer.Forbidden, imported) {
+		return fmt.Sprintf("%s of the %s layer must not import %s", pkg, layer.Name, imported)
+	}
+
+	importedLayer := c.layerOf(imported)
+	if importedLayer == nil || importedLayer.Name == layer.Name {
+		return ""
+	}
+
+	for _, allowed := range layer.Allow {
+		if allowed == importedLayer.Name {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("%s of the %s layer must not import %s of the %s layer", pkg, layer.Name, imported, importedLayer.Name)
+}
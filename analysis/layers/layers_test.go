@@ -0,0 +1,83 @@
+package layers_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/cyb3rd4d/propre/analysis/layers"
+)
+
+func TestAnalyzer(t *testing.T) {
+	testdata := analysistest.TestData()
+	if err := layers.Analyzer.Flags.Set("config", filepath.Join(testdata, "layers.yaml")); err != nil {
+		t.Fatal(err)
+	}
+
+	analysistest.Run(t, testdata, layers.Analyzer, "app/...")
+}
+
+func TestLoadConfig(t *testing.T) {
+	type testCase struct {
+		config      string
+		expected    *layers.Config
+		expectedErr error
+	}
+
+	testCases := map[string]testCase{
+		"the relative patterns are resolved in the module": {
+			config: "layers:\n  - name: domain\n    packages: [./internal/domain/..., ./]\n    forbidden: [net/http]\n" +
+				"  - name: usecase\n    packages: [./internal/usecase]\n    allow: [domain]\nforbidden: [./internal/legacy/...]\n",
+			expected: &layers.Config{
+				Layers: []layers.Layer{
+					{Name: "domain", Packages: []string{"example.com/app/internal/domain/...", "example.com/app"}, Forbidden: []string{"net/http"}},
+					{Name: "usecase", Packages: []string{"example.com/app/internal/usecase"}, Allow: []string{"domain"}},
+				},
+				Forbidden: []string{"example.com/app/internal/legacy/..."},
+			},
+		},
+		"a layer has no name": {
+			config:      "layers:\n  - packages: [./internal/domain]\n",
+			expectedErr: layers.ErrInvalidConfig,
+		},
+		"a layer is declared twice": {
+			config:      "layers:\n  - name: domain\n  - name: domain\n",
+			expectedErr: layers.ErrInvalidConfig,
+		},
+		"a layer allows an unknown layer": {
+			config:      "layers:\n  - name: usecase\n    allow: [domain]\n",
+			expectedErr: layers.ErrInvalidConfig,
+		},
+		"the file is not YAML": {
+			config:      "layers: [",
+			expectedErr: layers.ErrInvalidConfig,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			path := filepath.Join(dir, "propre.yaml")
+			if err := os.WriteFile(path, []byte(tc.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := layers.LoadConfig(path)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+
+			if tc.expected != nil && !reflect.DeepEqual(config, tc.expected) {
+				t.Fatalf("expected the config %+v, got %+v", tc.expected, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"net/http"
+
+	"app/domain"
+	"app/usecase"
+)
+
+const Route = http.MethodPost + " /todos"
+
+var _ = domain.Todo{}
+var _ = usecase.Name
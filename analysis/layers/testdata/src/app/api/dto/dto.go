@@ -0,0 +1,5 @@
+package dto
+
+type Todo struct {
+	Title string `json:"title"`
+}
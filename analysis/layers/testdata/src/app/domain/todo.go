@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"net/http" // want `app/domain of the domain layer must not import net/http`
+
+	"app/usecase/port" // want `app/domain of the domain layer must not import app/usecase/port of the usecase layer`
+)
+
+type Todo struct {
+	Title string
+}
+
+var _ = http.StatusOK
+var _ port.TodoRepository
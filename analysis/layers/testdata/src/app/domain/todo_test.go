@@ -0,0 +1,10 @@
+package domain
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTodo(t *testing.T) {
+	_ = http.StatusOK
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"app/domain"
+	"app/usecase" // want `app/postgres of the infrastructure layer must not import app/usecase of the usecase layer`
+)
+
+var _ *sql.DB
+var _ = domain.Todo{}
+var _ = usecase.Name
+
+const Driver = "postgres"
@@ -0,0 +1,9 @@
+package tools
+
+import (
+	"app/postgres"
+	"legacy" // want `app/tools must not import legacy`
+)
+
+var _ = legacy.Version
+var _ = postgres.Driver
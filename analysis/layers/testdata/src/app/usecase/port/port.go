@@ -0,0 +1,5 @@
+package port
+
+type TodoRepository interface {
+	Save(title string) error
+}
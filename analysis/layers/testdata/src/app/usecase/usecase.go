@@ -0,0 +1,13 @@
+package usecase
+
+import (
+	"app/api/dto" // want `app/usecase of the usecase layer must not import app/api/dto of the adapter layer`
+	"app/domain"
+	"app/usecase/port"
+)
+
+const Name = "create todo"
+
+var _ = domain.Todo{}
+var _ = dto.Todo{}
+var _ port.TodoRepository
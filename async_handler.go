@@ -0,0 +1,266 @@
+package propre
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JobOutput is the output given to the presenter of an [AsyncHandler] when a job
+// resource is fetched. Error is set if the job could not be created or fetched,
+// like [ErrJobNotFound].
+type JobOutput[Output any] struct {
+	Job   Job[Output]
+	Error error
+}
+
+// AsyncHandler runs use cases in the background for the requests which cannot
+// wait for them. Its ServeHTTP method decodes the request, stores a new job and
+// returns immediately a 202 Accepted response with a Location header pointing to
+// the job resource, then the use case is run in the background.
+//
+// The job resource is served by the handler returned by JobHandler: a GET request
+// presents the job, including the output of the use case once completed, and a
+// DELETE request cancels it.
+//
+// The jobs are canceled through the context given to the use case. Only the jobs
+// started by the same AsyncHandler instance can be canceled.
+type AsyncHandler[Input, Output any] struct {
+	requestDecoder RequestDecoder[Input]
+	inputError     func(Input) error
+	useCaseHandler UseCaseHandler[Input, Output]
+	presenter      Presenter[JobOutput[Output], http.ResponseWriter]
+	store          JobStore[Output]
+	jobLocation    func(id string) string
+	jobID          func(req *http.Request) string
+	ttl            time.Duration
+	timeout        time.Duration
+	errorHandler   func(ctx context.Context, jobID string, err error)
+	accepted       *HTTPResponse[HTTPEmpty]
+
+	mu      sync.Mutex
+	running map[string]*runningJob
+}
+
+type runningJob struct {
+	cancel   context.CancelFunc
+	canceled bool
+}
+
+// AsyncHandlerOpts is the alias for the [AsyncHandler] builder options.
+type AsyncHandlerOpts[Input, Output any] func(h *AsyncHandler[Input, Output])
+
+// WithJobTTL is an [AsyncHandler] option to set how long a job is kept once it is
+// completed or canceled. The default is one hour, zero means forever.
+func WithJobTTL[Input, Output any](ttl time.Duration) AsyncHandlerOpts[Input, Output] {
+	return func(h *AsyncHandler[Input, Output]) {
+		h.ttl = ttl
+	}
+}
+
+// WithJobTimeout is an [AsyncHandler] option to set a timeout to the context
+// given to the use case. There is no timeout by default.
+func WithJobTimeout[Input, Output any](timeout time.Duration) AsyncHandlerOpts[Input, Output] {
+	return func(h *AsyncHandler[Input, Output]) {
+		h.timeout = timeout
+	}
+}
+
+// WithJobIDExtractor is an [AsyncHandler] option to define how the job ID is
+// read from the requests to the job resource. By default it is the path value
+// "id", as defined by a pattern like "/jobs/{id}" of an [http.ServeMux].
+func WithJobIDExtractor[Input, Output any](extractor func(req *http.Request) string) AsyncHandlerOpts[Input, Output] {
+	return func(h *AsyncHandler[Input, Output]) {
+		h.jobID = extractor
+	}
+}
+
+// WithJobInputError is an [AsyncHandler] option to reject the invalid requests
+// before creating a job. The given function returns the error carried by the
+// decoded input, if any, which is then given to the presenter synchronously, so
+// it can send a 400 Bad Request response. By default every request creates a job.
+func WithJobInputError[Input, Output any](inputError func(Input) error) AsyncHandlerOpts[Input, Output] {
+	return func(h *AsyncHandler[Input, Output]) {
+		h.inputError = inputError
+	}
+}
+
+// WithJobErrorHandler is an [AsyncHandler] option to be notified of the errors
+// of the store when a job is updated in the background.
+func WithJobErrorHandler[Input, Output any](handler func(ctx context.Context, jobID string, err error)) AsyncHandlerOpts[Input, Output] {
+	return func(h *AsyncHandler[Input, Output]) {
+		h.errorHandler = handler
+	}
+}
+
+// NewAsyncHandler builds an AsyncHandler with the given dependencies. The
+// jobLocation function returns the URL of the job resource for a job ID.
+// [AsyncHandlerOpts] can be passed to customize the expiry, the timeout, the
+// extraction of the job ID, the validation of the input and the error handler.
+func NewAsyncHandler[Input, Output any](
+	requestDecoder RequestDecoder[Input],
+	useCaseHandler UseCaseHandler[Input, Output],
+	presenter Presenter[JobOutput[Output], http.ResponseWriter],
+	store JobStore[Output],
+	jobLocation func(id string) string,
+	opts ...AsyncHandlerOpts[Input, Output],
+) *AsyncHandler[Input, Output] {
+	handler := &AsyncHandler[Input, Output]{
+		requestDecoder: requestDecoder,
+		useCaseHandler: useCaseHandler,
+		presenter:      presenter,
+		store:          store,
+		jobLocation:    jobLocation,
+		jobID: func(req *http.Request) string {
+			return req.PathValue("id")
+		},
+		ttl:      time.Hour,
+		accepted: NewHTTPResponse[HTTPEmpty](),
+		running:  make(map[string]*runningJob),
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP allows AsyncHandler to be used by any HTTP "ServeMux".
+// If the input carries an error, see [WithJobInputError], or if the job cannot
+// be stored, the error is given to the presenter.
+func (handler *AsyncHandler[Input, Output]) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	input := handler.requestDecoder.Decode(req)
+	if handler.inputError != nil {
+		if err := handler.inputError(input); err != nil {
+			handler.presenter.Present(req.Context(), rw, JobOutput[Output]{Error: err})
+			return
+		}
+	}
+
+	now := time.Now()
+	job := Job[Output]{
+		ID:        newJobID(),
+		Status:    JobRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	err := handler.store.Save(req.Context(), job)
+	if err != nil {
+		handler.presenter.Present(req.Context(), rw, JobOutput[Output]{Job: job, Error: err})
+		return
+	}
+
+	// The job outlives the request, but keeps the values of its context.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+	if handler.timeout > 0 {
+		ctx, cancel = withTimeout(ctx, cancel, handler.timeout)
+	}
+
+	handler.mu.Lock()
+	handler.running[job.ID] = &runningJob{cancel: cancel}
+	handler.mu.Unlock()
+
+	go handler.run(ctx, job, input)
+
+	handler.accepted.Send(req.Context(), rw, Accepted(handler.jobLocation(job.ID)))
+}
+
+// JobHandler returns the [http.Handler] serving the job resources.
+func (handler *AsyncHandler[Input, Output]) JobHandler() http.Handler {
+	return http.HandlerFunc(handler.serveJob)
+}
+
+// Cancel cancels a running job. It returns false if the job is not running.
+func (handler *AsyncHandler[Input, Output]) Cancel(ctx context.Context, id string) (bool, error) {
+	handler.mu.Lock()
+	running, ok := handler.running[id]
+	if ok {
+		running.canceled = true
+		running.cancel()
+	}
+	handler.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	job, err := handler.store.Get(ctx, id)
+	if err != nil {
+		return true, err
+	}
+
+	return true, handler.finish(ctx, job, JobCanceled)
+}
+
+func (handler *AsyncHandler[Input, Output]) serveJob(rw http.ResponseWriter, req *http.Request) {
+	id := handler.jobID(req)
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodDelete:
+		_, err := handler.Cancel(req.Context(), id)
+		if err != nil {
+			handler.presenter.Present(req.Context(), rw, JobOutput[Output]{Error: err})
+			return
+		}
+	default:
+		rw.Header().Set("allow", "GET, HEAD, DELETE")
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	job, err := handler.store.Get(req.Context(), id)
+	handler.presenter.Present(req.Context(), rw, JobOutput[Output]{Job: job, Error: err})
+}
+
+func (handler *AsyncHandler[Input, Output]) run(ctx context.Context, job Job[Output], input Input) {
+	output := handler.useCaseHandler.Handle(ctx, input)
+
+	handler.mu.Lock()
+	running := handler.running[job.ID]
+	delete(handler.running, job.ID)
+	handler.mu.Unlock()
+
+	running.cancel()
+	if running.canceled {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	job.Output = output
+	err := handler.finish(ctx, job, JobCompleted)
+	if err != nil && handler.errorHandler != nil {
+		handler.errorHandler(ctx, job.ID, err)
+	}
+}
+
+func (handler *AsyncHandler[Input, Output]) finish(ctx context.Context, job Job[Output], status JobStatus) error {
+	now := time.Now()
+	job.Status = status
+	job.UpdatedAt = now
+	if handler.ttl > 0 {
+		job.ExpiresAt = now.Add(handler.ttl)
+	}
+
+	return handler.store.Save(ctx, job)
+}
+
+func withTimeout(ctx context.Context, cancel context.CancelFunc, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancelTimeout()
+		cancel()
+	}
+}
+
+func newJobID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,206 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type asyncInput struct {
+	Data  string
+	Error error
+}
+
+type asyncOutput struct {
+	Data  string
+	Error error
+}
+
+type asyncDecoder struct{}
+
+var errInvalidAsyncData = errors.New("invalid data")
+
+func (d asyncDecoder) Decode(req *http.Request) asyncInput {
+	data := req.URL.Query().Get("data")
+	if data == "invalid" {
+		return asyncInput{Error: errInvalidAsyncData}
+	}
+
+	return asyncInput{Data: data}
+}
+
+type blockingUseCase struct {
+	release chan struct{}
+	done    chan struct{}
+}
+
+func newBlockingUseCase() *blockingUseCase {
+	return &blockingUseCase{release: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (u *blockingUseCase) Handle(ctx context.Context, input asyncInput) asyncOutput {
+	defer close(u.done)
+
+	select {
+	case <-u.release:
+		return asyncOutput{Data: strings.ToUpper(input.Data)}
+	case <-ctx.Done():
+		return asyncOutput{Error: ctx.Err()}
+	}
+}
+
+type jobPresenterSpy struct {
+	outputs chan propre.JobOutput[asyncOutput]
+}
+
+func (p *jobPresenterSpy) Present(ctx context.Context, rw http.ResponseWriter, output propre.JobOutput[asyncOutput]) {
+	rw.WriteHeader(http.StatusOK)
+	p.outputs <- output
+}
+
+type failingJobStore struct{}
+
+func (s failingJobStore) Save(ctx context.Context, job propre.Job[asyncOutput]) error {
+	return errors.New("store unavailable")
+}
+
+func (s failingJobStore) Get(ctx context.Context, id string) (propre.Job[asyncOutput], error) {
+	return propre.Job[asyncOutput]{}, propre.ErrJobNotFound
+}
+
+func newAsyncTestServer(useCase *blockingUseCase, store propre.JobStore[asyncOutput]) (*http.ServeMux, *jobPresenterSpy) {
+	presenter := &jobPresenterSpy{outputs: make(chan propre.JobOutput[asyncOutput], 1)}
+	handler := propre.NewAsyncHandler(asyncDecoder{}, useCase, presenter, store, func(id string) string {
+		return "/jobs/" + id
+	}, propre.WithJobInputError[asyncInput, asyncOutput](func(input asyncInput) error {
+		return input.Error
+	}))
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /tasks", handler)
+	mux.Handle("/jobs/{id}", handler.JobHandler())
+
+	return mux, presenter
+}
+
+func submitJob(t *testing.T, mux *http.ServeMux) string {
+	t.Helper()
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/tasks?data=some+data", nil))
+
+	if rw.Code != http.StatusAccepted {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusAccepted, rw.Code)
+	}
+
+	location := rw.Header().Get("location")
+	if !strings.HasPrefix(location, "/jobs/") {
+		t.Fatalf("unexpected location header %q", location)
+	}
+
+	return location
+}
+
+func fetchJob(mux *http.ServeMux, presenter *jobPresenterSpy, method, location string) propre.JobOutput[asyncOutput] {
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, location, nil))
+	return <-presenter.outputs
+}
+
+func TestAsyncHandlerRunsTheUseCaseInTheBackground(t *testing.T) {
+	useCase := newBlockingUseCase()
+	mux, presenter := newAsyncTestServer(useCase, propre.NewInMemoryJobStore[asyncOutput]())
+
+	location := submitJob(t, mux)
+
+	output := fetchJob(mux, presenter, http.MethodGet, location)
+	if output.Error != nil || output.Job.Status != propre.JobRunning {
+		t.Fatalf("unexpected job output %+v", output)
+	}
+
+	close(useCase.release)
+	<-useCase.done
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		output = fetchJob(mux, presenter, http.MethodGet, location)
+		if output.Job.Status != propre.JobRunning {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if output.Job.Status != propre.JobCompleted || output.Job.Output.Data != "SOME DATA" {
+		t.Fatalf("unexpected job output %+v", output)
+	}
+
+	if output.Job.ExpiresAt.IsZero() {
+		t.Fatal("a completed job should expire")
+	}
+}
+
+func TestAsyncHandlerCancelsJobs(t *testing.T) {
+	useCase := newBlockingUseCase()
+	mux, presenter := newAsyncTestServer(useCase, propre.NewInMemoryJobStore[asyncOutput]())
+
+	location := submitJob(t, mux)
+
+	output := fetchJob(mux, presenter, http.MethodDelete, location)
+	if output.Error != nil || output.Job.Status != propre.JobCanceled {
+		t.Fatalf("unexpected job output %+v", output)
+	}
+
+	<-useCase.done
+
+	output = fetchJob(mux, presenter, http.MethodGet, location)
+	if output.Job.Status != propre.JobCanceled {
+		t.Fatalf("the canceled job should not be completed: %+v", output)
+	}
+}
+
+func TestAsyncHandlerPresentsUnknownJobs(t *testing.T) {
+	mux, presenter := newAsyncTestServer(newBlockingUseCase(), propre.NewInMemoryJobStore[asyncOutput]())
+
+	output := fetchJob(mux, presenter, http.MethodGet, "/jobs/unknown")
+	if !errors.Is(output.Error, propre.ErrJobNotFound) {
+		t.Fatalf("unexpected error: %v", output.Error)
+	}
+}
+
+func TestAsyncHandlerPresentsStoreErrors(t *testing.T) {
+	useCase := newBlockingUseCase()
+	mux, presenter := newAsyncTestServer(useCase, failingJobStore{})
+
+	output := fetchJob(mux, presenter, http.MethodPost, "/tasks")
+	if output.Error == nil {
+		t.Fatal("expected a store error")
+	}
+
+	select {
+	case <-useCase.done:
+		t.Fatal("the use case should not run if the job cannot be stored")
+	default:
+	}
+}
+
+func TestAsyncHandlerPresentsInputErrorsWithoutCreatingAJob(t *testing.T) {
+	useCase := newBlockingUseCase()
+	store := propre.NewInMemoryJobStore[asyncOutput]()
+	mux, presenter := newAsyncTestServer(useCase, store)
+
+	output := fetchJob(mux, presenter, http.MethodPost, "/tasks?data=invalid")
+	if !errors.Is(output.Error, errInvalidAsyncData) || output.Job.ID != "" {
+		t.Fatalf("unexpected job output %+v", output)
+	}
+
+	select {
+	case <-useCase.done:
+		t.Fatal("the use case should not run for an invalid input")
+	default:
+	}
+}
@@ -0,0 +1,234 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrNoCredentials is returned by an [Authenticator] if the request does not
+	// contain credentials for its scheme.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned by an [Authenticator] if the credentials
+	// of the request are invalid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Principal is the authenticated client of a request, stored in the request
+// context by [AuthenticationHandler].
+type Principal struct {
+	// ID identifies the principal, like a user ID or the subject of a token.
+	ID string
+	// Tenant is the tenant the principal belongs to, if any.
+	Tenant string
+	// Roles are the roles granted to the principal.
+	Roles []string
+	// Scheme is the authentication scheme used, like "Bearer" or "Basic".
+	Scheme string
+	// Attributes holds any other data about the principal, like the claims of a token.
+	Attributes map[string]any
+}
+
+// HasRole reports whether the principal has the given role.
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type principalContextKey struct{}
+
+// ContextWithPrincipal returns a copy of the context holding the given principal.
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in the context, if any.
+// Use cases can call it with the context given to their Handle method.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// Authenticator authenticates a request with a single scheme.
+type Authenticator interface {
+	// Authenticate returns the principal of the request. It returns an error
+	// wrapping [ErrNoCredentials] if the request has no credentials for this
+	// scheme, or [ErrInvalidCredentials] if they are invalid.
+	Authenticate(req *http.Request) (Principal, error)
+	// Challenge returns the WWW-Authenticate challenge sent when the authentication
+	// fails with the given error, or an empty string if the scheme has none.
+	Challenge(err error) string
+}
+
+// AuthenticatorRegistry holds the authenticators of an [AuthenticationHandler].
+// They are tried in their order of registration until one finds credentials
+// in the request.
+type AuthenticatorRegistry struct {
+	authenticators []Authenticator
+}
+
+// NewAuthenticatorRegistry builds an [AuthenticatorRegistry] with the given authenticators.
+func NewAuthenticatorRegistry(authenticators ...Authenticator) *AuthenticatorRegistry {
+	return &AuthenticatorRegistry{authenticators: authenticators}
+}
+
+// Register appends an authenticator to the registry.
+func (registry *AuthenticatorRegistry) Register(authenticator Authenticator) {
+	registry.authenticators = append(registry.authenticators, authenticator)
+}
+
+// Authenticate returns the principal found by the first authenticator for which
+// the request has credentials. It returns an error wrapping [ErrNoCredentials]
+// if none of them found credentials.
+func (registry *AuthenticatorRegistry) Authenticate(req *http.Request) (Principal, error) {
+	for i, authenticator := range registry.authenticators {
+		principal, err := authenticator.Authenticate(req)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		if err != nil {
+			return principal, &authenticationError{authenticator: i, err: err}
+		}
+
+		return principal, nil
+	}
+
+	return Principal{}, ErrNoCredentials
+}
+
+// Challenges returns the WWW-Authenticate challenges of the authenticators for
+// an error returned by Authenticate. The authenticator which returned the error
+// receives it, the other ones receive [ErrNoCredentials].
+func (registry *AuthenticatorRegistry) Challenges(err error) []string {
+	failed := -1
+	var authErr *authenticationError
+	if errors.As(err, &authErr) {
+		failed = authErr.authenticator
+	}
+
+	challenges := make([]string, 0, len(registry.authenticators))
+	for i, authenticator := range registry.authenticators {
+		challengeErr := ErrNoCredentials
+		if i == failed {
+			challengeErr = authErr.err
+		}
+
+		if challenge := authenticator.Challenge(challengeErr); challenge != "" {
+			challenges = append(challenges, challenge)
+		}
+	}
+
+	return challenges
+}
+
+// authenticationError records which authenticator of a registry failed.
+type authenticationError struct {
+	authenticator int
+	err           error
+}
+
+func (e *authenticationError) Error() string {
+	return e.err.Error()
+}
+
+func (e *authenticationError) Unwrap() error {
+	return e.err
+}
+
+// AuthenticationHandler is the authentication stage in front of a wrapped
+// [http.Handler], like an [HTTPHandler]. It authenticates the requests with an
+// [AuthenticatorRegistry] and stores the principal in the request context, so
+// the request decoders and the use cases can read it with [PrincipalFromContext].
+//
+// The failures are presented with an error wrapping [ErrNoCredentials] or
+// [ErrInvalidCredentials], and the WWW-Authenticate header is set with the
+// challenges of the authenticators.
+type AuthenticationHandler struct {
+	next           http.Handler
+	registry       *AuthenticatorRegistry
+	anonymous      bool
+	errorPresenter Presenter[error, http.ResponseWriter]
+}
+
+// AuthenticationHandlerOpts is the alias for the [AuthenticationHandler] builder options.
+type AuthenticationHandlerOpts func(h *AuthenticationHandler)
+
+// WithAnonymousAccess is an [AuthenticationHandler] option to pass the requests
+// without credentials to the wrapped handler, without principal in their context.
+// The requests with invalid credentials are still rejected.
+func WithAnonymousAccess() AuthenticationHandlerOpts {
+	return func(h *AuthenticationHandler) {
+		h.anonymous = true
+	}
+}
+
+// WithAuthenticationErrorPresenter is an [AuthenticationHandler] option to customize
+// the responses to the rejected requests. The default presenter sends a plain text
+// response with the status code 401 for the authentication failures and 500 otherwise.
+func WithAuthenticationErrorPresenter(presenter Presenter[error, http.ResponseWriter]) AuthenticationHandlerOpts {
+	return func(h *AuthenticationHandler) {
+		h.errorPresenter = presenter
+	}
+}
+
+// NewAuthenticationHandler builds an AuthenticationHandler wrapping the given handler.
+// [AuthenticationHandlerOpts] can be passed to allow anonymous access and to customize
+// the error responses.
+func NewAuthenticationHandler(
+	next http.Handler,
+	registry *AuthenticatorRegistry,
+	opts ...AuthenticationHandlerOpts,
+) *AuthenticationHandler {
+	handler := &AuthenticationHandler{
+		next:           next,
+		registry:       registry,
+		errorPresenter: authenticationErrorPresenter{},
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP allows AuthenticationHandler to be used by any HTTP "ServeMux".
+func (handler *AuthenticationHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	principal, err := handler.registry.Authenticate(req)
+	if err == nil {
+		handler.next.ServeHTTP(rw, req.WithContext(ContextWithPrincipal(req.Context(), principal)))
+		return
+	}
+
+	if errors.Is(err, ErrNoCredentials) && handler.anonymous {
+		handler.next.ServeHTTP(rw, req)
+		return
+	}
+
+	if errors.Is(err, ErrNoCredentials) || errors.Is(err, ErrInvalidCredentials) {
+		for _, challenge := range handler.registry.Challenges(err) {
+			rw.Header().Add("WWW-Authenticate", challenge)
+		}
+	}
+
+	handler.errorPresenter.Present(req.Context(), rw, err)
+}
+
+type authenticationErrorPresenter struct{}
+
+func (p authenticationErrorPresenter) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrNoCredentials) || errors.Is(err, ErrInvalidCredentials) {
+		status = http.StatusUnauthorized
+	}
+
+	http.Error(rw, http.StatusText(status), status)
+}
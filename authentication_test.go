@@ -0,0 +1,170 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func verifyToken(ctx context.Context, token string) (propre.Principal, error) {
+	if token != "valid-token" {
+		return propre.Principal{}, fmt.Errorf("%w: unknown token", propre.ErrInvalidCredentials)
+	}
+
+	return propre.Principal{ID: "user-1", Tenant: "acme", Roles: []string{"admin"}}, nil
+}
+
+func verifyBasicCredentials(ctx context.Context, username, password string) (propre.Principal, error) {
+	if username != "john" || password != "secret" {
+		return propre.Principal{}, propre.ErrInvalidCredentials
+	}
+
+	return propre.Principal{ID: username}, nil
+}
+
+type authenticationErrorPresenterSpy struct {
+	err error
+}
+
+func (p *authenticationErrorPresenterSpy) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	p.err = err
+	rw.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func principalHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		principal, ok := propre.PrincipalFromContext(req.Context())
+		if !ok {
+			rw.Write([]byte("anonymous"))
+			return
+		}
+
+		rw.Write([]byte(principal.Scheme + ":" + principal.ID))
+	})
+}
+
+func TestAuthenticationHandler(t *testing.T) {
+	type testCase struct {
+		authorization      string
+		opts               []propre.AuthenticationHandlerOpts
+		expectedStatusCode int
+		expectedBody       string
+		expectedChallenges []string
+	}
+
+	testCases := map[string]testCase{
+		"the principal is stored in the context": {
+			authorization:      "Bearer valid-token",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "Bearer:user-1",
+		},
+		"the second authenticator is tried when the first finds no credentials": {
+			authorization:      "Basic am9objpzZWNyZXQ=",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "Basic:john",
+		},
+		"a request without credentials is rejected with the challenges": {
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "Unauthorized\n",
+			expectedChallenges: []string{`Bearer realm="api"`, `Basic realm="api", charset="UTF-8"`},
+		},
+		"only the failing authenticator receives the error in its challenge": {
+			authorization:      "Bearer invalid-token",
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "Unauthorized\n",
+			expectedChallenges: []string{
+				`Bearer realm="api", error="invalid_token"`,
+				`Basic realm="api", charset="UTF-8"`,
+			},
+		},
+		"a request without credentials is passed with the anonymous access": {
+			opts:               []propre.AuthenticationHandlerOpts{propre.WithAnonymousAccess()},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "anonymous",
+		},
+		"invalid credentials are rejected with the anonymous access": {
+			authorization:      "Basic am9objp3cm9uZw==",
+			opts:               []propre.AuthenticationHandlerOpts{propre.WithAnonymousAccess()},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedBody:       "Unauthorized\n",
+			expectedChallenges: []string{`Bearer realm="api"`, `Basic realm="api", charset="UTF-8"`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			registry := propre.NewAuthenticatorRegistry(propre.NewBearerAuthenticator("api", verifyToken))
+			registry.Register(propre.NewBasicAuthenticator("api", verifyBasicCredentials))
+			handler := propre.NewAuthenticationHandler(principalHandler(), registry, tc.opts...)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			if rw.Code != tc.expectedStatusCode {
+				t.Fatalf("wrong status code, expected %d, got %d", tc.expectedStatusCode, rw.Code)
+			}
+
+			if rw.Body.String() != tc.expectedBody {
+				t.Fatalf("wrong body, expected %q, got %q", tc.expectedBody, rw.Body.String())
+			}
+
+			challenges := rw.Header().Values("WWW-Authenticate")
+			if fmt.Sprint(challenges) != fmt.Sprint(tc.expectedChallenges) {
+				t.Fatalf("wrong challenges, expected %q, got %q", tc.expectedChallenges, challenges)
+			}
+		})
+	}
+}
+
+func TestAuthenticationHandlerPresentsTheVerifierErrors(t *testing.T) {
+	verifierErr := errors.New("token service unavailable")
+	registry := propre.NewAuthenticatorRegistry(propre.NewBearerAuthenticator("api", func(ctx context.Context, token string) (propre.Principal, error) {
+		return propre.Principal{}, verifierErr
+	}))
+
+	presenter := &authenticationErrorPresenterSpy{}
+	handler := propre.NewAuthenticationHandler(principalHandler(), registry, propre.WithAuthenticationErrorPresenter(presenter))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	if !errors.Is(presenter.err, verifierErr) {
+		t.Fatalf("the verifier error should be presented, got %v", presenter.err)
+	}
+
+	if rw.Code != http.StatusServiceUnavailable {
+		t.Fatalf("wrong status code %d", rw.Code)
+	}
+
+	if rw.Header().Get("WWW-Authenticate") != "" {
+		t.Fatalf("no challenge should be sent for a server error, got %q", rw.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := propre.PrincipalFromContext(context.Background()); ok {
+		t.Fatal("an empty context should not hold a principal")
+	}
+
+	ctx := propre.ContextWithPrincipal(context.Background(), propre.Principal{ID: "user-1", Roles: []string{"reader"}})
+	principal, ok := propre.PrincipalFromContext(ctx)
+	if !ok || principal.ID != "user-1" {
+		t.Fatalf("unexpected principal %+v", principal)
+	}
+
+	if !principal.HasRole("reader") || principal.HasRole("admin") {
+		t.Fatalf("unexpected roles %v", principal.Roles)
+	}
+}
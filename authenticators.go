@@ -0,0 +1,178 @@
+package propre
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BearerTokenVerifier returns the principal identified by a bearer token.
+// It returns an error wrapping [ErrInvalidCredentials] if the token is invalid.
+type BearerTokenVerifier func(ctx context.Context, token string) (Principal, error)
+
+// BearerAuthenticator is an [Authenticator] reading a token from the
+// "Authorization: Bearer" header (RFC 6750), like a JWT or an opaque token.
+type BearerAuthenticator struct {
+	realm  string
+	verify BearerTokenVerifier
+}
+
+// NewBearerAuthenticator builds a [BearerAuthenticator] with the realm sent in
+// its challenge and the function verifying the tokens.
+func NewBearerAuthenticator(realm string, verify BearerTokenVerifier) *BearerAuthenticator {
+	return &BearerAuthenticator{realm: realm, verify: verify}
+}
+
+// Authenticate implements [Authenticator].
+func (a *BearerAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	scheme, token, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return Principal{}, fmt.Errorf("%w: empty bearer token", ErrInvalidCredentials)
+	}
+
+	principal, err := a.verify(req.Context(), token)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal.Scheme = "Bearer"
+	return principal, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *BearerAuthenticator) Challenge(err error) string {
+	if errors.Is(err, ErrInvalidCredentials) {
+		return fmt.Sprintf(`Bearer realm=%q, error="invalid_token"`, a.realm)
+	}
+
+	return fmt.Sprintf("Bearer realm=%q", a.realm)
+}
+
+// APIKeyVerifier returns the principal identified by an API key.
+// It returns an error wrapping [ErrInvalidCredentials] if the key is invalid.
+type APIKeyVerifier func(ctx context.Context, key string) (Principal, error)
+
+// APIKeyAuthenticator is an [Authenticator] reading an API key from a header,
+// like "X-API-Key". API keys have no standard challenge.
+type APIKeyAuthenticator struct {
+	header string
+	verify APIKeyVerifier
+}
+
+// NewAPIKeyAuthenticator builds an [APIKeyAuthenticator] reading the key from
+// the given header.
+func NewAPIKeyAuthenticator(header string, verify APIKeyVerifier) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{header: header, verify: verify}
+}
+
+// Authenticate implements [Authenticator].
+func (a *APIKeyAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	key := req.Header.Get(a.header)
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	principal, err := a.verify(req.Context(), key)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal.Scheme = "APIKey"
+	return principal, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *APIKeyAuthenticator) Challenge(err error) string {
+	return ""
+}
+
+// BasicCredentialsVerifier returns the principal identified by a username and a password.
+// It returns an error wrapping [ErrInvalidCredentials] if they are invalid.
+type BasicCredentialsVerifier func(ctx context.Context, username, password string) (Principal, error)
+
+// BasicAuthenticator is an [Authenticator] for the HTTP Basic scheme (RFC 7617).
+type BasicAuthenticator struct {
+	realm  string
+	verify BasicCredentialsVerifier
+}
+
+// NewBasicAuthenticator builds a [BasicAuthenticator] with the realm sent in its
+// challenge and the function verifying the credentials.
+func NewBasicAuthenticator(realm string, verify BasicCredentialsVerifier) *BasicAuthenticator {
+	return &BasicAuthenticator{realm: realm, verify: verify}
+}
+
+// Authenticate implements [Authenticator].
+func (a *BasicAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	scheme, _, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Basic") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	username, password, ok := req.BasicAuth()
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: malformed basic credentials", ErrInvalidCredentials)
+	}
+
+	principal, err := a.verify(req.Context(), username, password)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal.Scheme = "Basic"
+	return principal, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *BasicAuthenticator) Challenge(err error) string {
+	return fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, a.realm)
+}
+
+// ClientCertificateVerifier returns the principal identified by a client certificate.
+// It returns an error wrapping [ErrInvalidCredentials] if the certificate is not accepted.
+type ClientCertificateVerifier func(ctx context.Context, certificate *x509.Certificate) (Principal, error)
+
+// ClientCertificateAuthenticator is an [Authenticator] for the mutual TLS
+// authentication. The certificate chain must be verified by the TLS server,
+// with a ClientAuth policy like [crypto/tls.VerifyClientCertIfGiven], the
+// authenticator only maps the verified leaf certificate to a principal.
+type ClientCertificateAuthenticator struct {
+	verify ClientCertificateVerifier
+}
+
+// NewClientCertificateAuthenticator builds a [ClientCertificateAuthenticator].
+func NewClientCertificateAuthenticator(verify ClientCertificateVerifier) *ClientCertificateAuthenticator {
+	return &ClientCertificateAuthenticator{verify: verify}
+}
+
+// Authenticate implements [Authenticator].
+func (a *ClientCertificateAuthenticator) Authenticate(req *http.Request) (Principal, error) {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return Principal{}, ErrNoCredentials
+	}
+
+	if len(req.TLS.VerifiedChains) == 0 {
+		return Principal{}, fmt.Errorf("%w: unverified client certificate", ErrInvalidCredentials)
+	}
+
+	principal, err := a.verify(req.Context(), req.TLS.VerifiedChains[0][0])
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal.Scheme = "ClientCertificate"
+	return principal, nil
+}
+
+// Challenge implements [Authenticator].
+func (a *ClientCertificateAuthenticator) Challenge(err error) string {
+	return ""
+}
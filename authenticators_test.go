@@ -0,0 +1,168 @@
+package propre_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	type testCase struct {
+		key               string
+		expectedPrincipal propre.Principal
+		expectedErr       error
+	}
+
+	testCases := map[string]testCase{
+		"a valid key is authenticated": {
+			key:               "key-1",
+			expectedPrincipal: propre.Principal{ID: "service-1", Scheme: "APIKey"},
+		},
+		"an invalid key is rejected": {
+			key:         "unknown",
+			expectedErr: propre.ErrInvalidCredentials,
+		},
+		"a request without key has no credentials": {
+			expectedErr: propre.ErrNoCredentials,
+		},
+	}
+
+	authenticator := propre.NewAPIKeyAuthenticator("X-API-Key", func(ctx context.Context, key string) (propre.Principal, error) {
+		if key != "key-1" {
+			return propre.Principal{}, propre.ErrInvalidCredentials
+		}
+
+		return propre.Principal{ID: "service-1"}, nil
+	})
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.key != "" {
+				req.Header.Set("X-API-Key", tc.key)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("unexpected error, expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if principal.ID != tc.expectedPrincipal.ID || principal.Scheme != tc.expectedPrincipal.Scheme {
+				t.Fatalf("unexpected principal, expected %+v, got %+v", tc.expectedPrincipal, principal)
+			}
+
+			if authenticator.Challenge(err) != "" {
+				t.Fatal("API keys have no challenge")
+			}
+		})
+	}
+}
+
+func TestBearerAndBasicAuthenticatorsRejectMalformedCredentials(t *testing.T) {
+	type testCase struct {
+		authenticator propre.Authenticator
+		authorization string
+		expectedErr   error
+	}
+
+	testCases := map[string]testCase{
+		"empty bearer token": {
+			authenticator: propre.NewBearerAuthenticator("api", verifyToken),
+			authorization: "Bearer ",
+			expectedErr:   propre.ErrInvalidCredentials,
+		},
+		"bearer scheme is case insensitive": {
+			authenticator: propre.NewBearerAuthenticator("api", verifyToken),
+			authorization: "bearer valid-token",
+		},
+		"another scheme for bearer": {
+			authenticator: propre.NewBearerAuthenticator("api", verifyToken),
+			authorization: "Basic am9objpzZWNyZXQ=",
+			expectedErr:   propre.ErrNoCredentials,
+		},
+		"malformed basic credentials": {
+			authenticator: propre.NewBasicAuthenticator("api", verifyBasicCredentials),
+			authorization: "Basic not-base64",
+			expectedErr:   propre.ErrInvalidCredentials,
+		},
+		"another scheme for basic": {
+			authenticator: propre.NewBasicAuthenticator("api", verifyBasicCredentials),
+			authorization: "Bearer valid-token",
+			expectedErr:   propre.ErrNoCredentials,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", tc.authorization)
+
+			_, err := tc.authenticator.Authenticate(req)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("unexpected error, expected %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestClientCertificateAuthenticator(t *testing.T) {
+	certificate := &x509.Certificate{Subject: pkix.Name{CommonName: "service-1"}}
+
+	type testCase struct {
+		tls         *tls.ConnectionState
+		expectedID  string
+		expectedErr error
+	}
+
+	testCases := map[string]testCase{
+		"a verified certificate is authenticated": {
+			tls: &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{certificate},
+				VerifiedChains:   [][]*x509.Certificate{{certificate}},
+			},
+			expectedID: "service-1",
+		},
+		"an unverified certificate is rejected": {
+			tls:         &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}},
+			expectedErr: propre.ErrInvalidCredentials,
+		},
+		"a connection without certificate has no credentials": {
+			tls:         &tls.ConnectionState{},
+			expectedErr: propre.ErrNoCredentials,
+		},
+		"a plain text connection has no credentials": {
+			expectedErr: propre.ErrNoCredentials,
+		},
+	}
+
+	authenticator := propre.NewClientCertificateAuthenticator(func(ctx context.Context, certificate *x509.Certificate) (propre.Principal, error) {
+		return propre.Principal{ID: certificate.Subject.CommonName}, nil
+	})
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.TLS = tc.tls
+
+			principal, err := authenticator.Authenticate(req)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("unexpected error, expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if principal.ID != tc.expectedID {
+				t.Fatalf("unexpected principal %+v", principal)
+			}
+
+			if tc.expectedID != "" && principal.Scheme != "ClientCertificate" {
+				t.Fatalf("unexpected scheme %q", principal.Scheme)
+			}
+		})
+	}
+}
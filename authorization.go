@@ -0,0 +1,313 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrAccessDenied is returned by a [Policy] denying the access to a use case.
+	// An [AuthorizedUseCase] called without principal in the context returns an
+	// error wrapping both ErrAccessDenied and [ErrNoCredentials].
+	ErrAccessDenied = errors.New("access denied")
+
+	// ErrPolicyEvaluation wraps the errors of a [Policy] which could not be
+	// evaluated, like a failure to load the owner of a resource.
+	ErrPolicyEvaluation = errors.New("policy evaluation error")
+)
+
+// Policy is an authorization rule evaluated by an [AuthorizedUseCase] before its
+// use case handler.
+type Policy[Input any] interface {
+	// Authorize returns nil if the principal is allowed to run the use case with
+	// the given input, or an error wrapping [ErrAccessDenied] otherwise.
+	Authorize(ctx context.Context, principal Principal, input Input) error
+	// Describe returns a human readable description of the policy, used by [PolicyReport].
+	Describe() string
+}
+
+// PolicyProvider can be implemented by a use case handler to declare the policies
+// protecting it, so they apply to every route using this handler type.
+type PolicyProvider[Input any] interface {
+	Policies() []Policy[Input]
+}
+
+type rolePolicy[Input any] struct {
+	roles []string
+}
+
+// RequireRoles is a [Policy] allowing the principals with at least one of the given roles.
+func RequireRoles[Input any](roles ...string) Policy[Input] {
+	return rolePolicy[Input]{roles: roles}
+}
+
+func (p rolePolicy[Input]) Authorize(ctx context.Context, principal Principal, input Input) error {
+	for _, role := range p.roles {
+		if principal.HasRole(role) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: one of the roles %s is required", ErrAccessDenied, strings.Join(p.roles, ", "))
+}
+
+func (p rolePolicy[Input]) Describe() string {
+	return "role in (" + strings.Join(p.roles, ", ") + ")"
+}
+
+type conditionPolicy[Input any] struct {
+	description string
+	condition   func(ctx context.Context, principal Principal, input Input) bool
+}
+
+// RequireCondition is a [Policy] allowing the requests for which the condition,
+// a predicate over the principal and the input, is true. The description is
+// used in the errors and in the [PolicyReport].
+func RequireCondition[Input any](
+	description string,
+	condition func(ctx context.Context, principal Principal, input Input) bool,
+) Policy[Input] {
+	return conditionPolicy[Input]{description: description, condition: condition}
+}
+
+func (p conditionPolicy[Input]) Authorize(ctx context.Context, principal Principal, input Input) error {
+	if p.condition(ctx, principal, input) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrAccessDenied, p.description)
+}
+
+func (p conditionPolicy[Input]) Describe() string {
+	return p.description
+}
+
+type ownershipPolicy[Input any] struct {
+	owner func(ctx context.Context, input Input) (string, error)
+}
+
+// RequireOwnership is a [Policy] allowing the principal owning the resource targeted
+// by the input. The owner function returns the ID of the owner, compared to the
+// ID of the principal. Its errors are wrapped with [ErrPolicyEvaluation].
+func RequireOwnership[Input any](owner func(ctx context.Context, input Input) (string, error)) Policy[Input] {
+	return ownershipPolicy[Input]{owner: owner}
+}
+
+func (p ownershipPolicy[Input]) Authorize(ctx context.Context, principal Principal, input Input) error {
+	owner, err := p.owner(ctx, input)
+	if err != nil {
+		return fmt.Errorf("%w caused by %w", ErrPolicyEvaluation, err)
+	}
+
+	if owner == "" || owner != principal.ID {
+		return fmt.Errorf("%w: the principal does not own the resource", ErrAccessDenied)
+	}
+
+	return nil
+}
+
+func (p ownershipPolicy[Input]) Describe() string {
+	return "resource owner"
+}
+
+type anyOfPolicy[Input any] struct {
+	policies []Policy[Input]
+}
+
+// AnyOf is a [Policy] allowing the requests allowed by at least one of the given
+// policies, like "the owner or an admin".
+func AnyOf[Input any](policies ...Policy[Input]) Policy[Input] {
+	return anyOfPolicy[Input]{policies: policies}
+}
+
+func (p anyOfPolicy[Input]) Authorize(ctx context.Context, principal Principal, input Input) error {
+	errs := make([]error, 0, len(p.policies))
+	for _, policy := range p.policies {
+		err := policy.Authorize(ctx, principal, input)
+		if err == nil {
+			return nil
+		}
+
+		if !errors.Is(err, ErrAccessDenied) {
+			return err
+		}
+
+		errs = append(errs, err)
+	}
+
+	return errors.Join(append([]error{ErrAccessDenied}, errs...)...)
+}
+
+func (p anyOfPolicy[Input]) Describe() string {
+	descriptions := make([]string, len(p.policies))
+	for i, policy := range p.policies {
+		descriptions[i] = policy.Describe()
+	}
+
+	return "any of (" + strings.Join(descriptions, " | ") + ")"
+}
+
+// AuthorizedUseCase is a [UseCaseHandler] evaluating policies before the wrapped
+// use case handler. Used in an [HTTPHandler] in place of the use case handler,
+// the policies are evaluated between the request decoder and the use case.
+//
+// The principal is read from the context, see [AuthenticationHandler]. All the
+// policies must allow the request, otherwise the error is converted to an output
+// by the errorOutput function and the use case is not called, so the presenter
+// can send a 403 response for [ErrAccessDenied].
+//
+// With [WithAuthorizationInputError], an input carrying an error of the request
+// decoder is passed to the use case handler without evaluating the policies, so
+// the presenter sends the 400 response of the decoding error.
+type AuthorizedUseCase[Input, Output any] struct {
+	authorizationRules[Input]
+	useCaseHandler UseCaseHandler[Input, Output]
+	errorOutput    func(err error) Output
+}
+
+// authorizationRules holds the settings of an [AuthorizedUseCase] depending on
+// its input only, so the options infer their type parameter.
+type authorizationRules[Input any] struct {
+	policies   []Policy[Input]
+	inputError func(Input) error
+}
+
+// AuthorizedUseCaseOpts is the alias for the [AuthorizedUseCase] builder options.
+type AuthorizedUseCaseOpts[Input any] func(r *authorizationRules[Input])
+
+// WithAuthorizationPolicies is an [AuthorizedUseCase] option to add policies,
+// evaluated in the order of the options.
+func WithAuthorizationPolicies[Input any](policies ...Policy[Input]) AuthorizedUseCaseOpts[Input] {
+	return func(r *authorizationRules[Input]) {
+		r.policies = append(r.policies, policies...)
+	}
+}
+
+// WithAuthorizationInputError is an [AuthorizedUseCase] option to skip the policies
+// for the invalid requests. The given function returns the error carried by the
+// decoded input, if any, then the input is passed as is to the use case handler.
+// By default the policies are evaluated for every input.
+func WithAuthorizationInputError[Input any](inputError func(Input) error) AuthorizedUseCaseOpts[Input] {
+	return func(r *authorizationRules[Input]) {
+		r.inputError = inputError
+	}
+}
+
+// NewAuthorizedUseCase builds an AuthorizedUseCase. If the use case handler
+// implements [PolicyProvider], its policies are evaluated first, then the ones
+// given with [WithAuthorizationPolicies].
+func NewAuthorizedUseCase[Input, Output any](
+	useCaseHandler UseCaseHandler[Input, Output],
+	errorOutput func(err error) Output,
+	opts ...AuthorizedUseCaseOpts[Input],
+) *AuthorizedUseCase[Input, Output] {
+	u := &AuthorizedUseCase[Input, Output]{
+		useCaseHandler: useCaseHandler,
+		errorOutput:    errorOutput,
+	}
+
+	if provider, ok := useCaseHandler.(PolicyProvider[Input]); ok {
+		u.policies = append(u.policies, provider.Policies()...)
+	}
+
+	for _, opt := range opts {
+		opt(&u.authorizationRules)
+	}
+
+	return u
+}
+
+// Handle implements [UseCaseHandler].
+func (u *AuthorizedUseCase[Input, Output]) Handle(ctx context.Context, input Input) Output {
+	if u.inputError != nil && u.inputError(input) != nil {
+		return u.useCaseHandler.Handle(ctx, input)
+	}
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return u.errorOutput(fmt.Errorf("%w caused by %w", ErrAccessDenied, ErrNoCredentials))
+	}
+
+	for _, policy := range u.policies {
+		if err := policy.Authorize(ctx, principal, input); err != nil {
+			return u.errorOutput(err)
+		}
+	}
+
+	return u.useCaseHandler.Handle(ctx, input)
+}
+
+// DescribePolicies returns the descriptions of the policies, see [PolicyReport].
+func (u *AuthorizedUseCase[Input, Output]) DescribePolicies() []string {
+	descriptions := make([]string, len(u.policies))
+	for i, policy := range u.policies {
+		descriptions[i] = policy.Describe()
+	}
+
+	return descriptions
+}
+
+// PolicyDescriber is implemented by [AuthorizedUseCase] to list its policies.
+type PolicyDescriber interface {
+	DescribePolicies() []string
+}
+
+// PolicyReportEntry lists the policies protecting an endpoint.
+type PolicyReportEntry struct {
+	Endpoint string   `json:"endpoint"`
+	Policies []string `json:"policies"`
+}
+
+// PolicyReport lists what protects each endpoint, to audit the permissions.
+// Register the endpoints along with their [AuthorizedUseCase] when building the routes.
+type PolicyReport struct {
+	entries map[string][]string
+}
+
+// NewPolicyReport builds an empty [PolicyReport].
+func NewPolicyReport() *PolicyReport {
+	return &PolicyReport{entries: make(map[string][]string)}
+}
+
+// Register adds the policies of an endpoint to the report. A nil describer
+// registers an unprotected endpoint.
+func (r *PolicyReport) Register(endpoint string, describer PolicyDescriber) {
+	var policies []string
+	if describer != nil {
+		policies = describer.DescribePolicies()
+	}
+
+	r.entries[endpoint] = policies
+}
+
+// Entries returns the endpoints sorted by name with their policies.
+func (r *PolicyReport) Entries() []PolicyReportEntry {
+	entries := make([]PolicyReportEntry, 0, len(r.entries))
+	for endpoint, policies := range r.entries {
+		entries = append(entries, PolicyReportEntry{Endpoint: endpoint, Policies: policies})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Endpoint < entries[j].Endpoint
+	})
+
+	return entries
+}
+
+// String formats the report with an endpoint per line.
+func (r *PolicyReport) String() string {
+	var b strings.Builder
+	for _, entry := range r.Entries() {
+		policies := "unprotected"
+		if len(entry.Policies) > 0 {
+			policies = strings.Join(entry.Policies, " and ")
+		}
+
+		fmt.Fprintf(&b, "%s: %s\n", entry.Endpoint, policies)
+	}
+
+	return b.String()
+}
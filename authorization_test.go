@@ -0,0 +1,204 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type updateTodoInput struct {
+	TodoID string
+	Title  string
+	Error  error
+}
+
+type updateTodoOutput struct {
+	Title string
+	Error error
+}
+
+type updateTodoUseCase struct {
+	called bool
+}
+
+func (u *updateTodoUseCase) Handle(ctx context.Context, input updateTodoInput) updateTodoOutput {
+	u.called = true
+	return updateTodoOutput{Title: input.Title, Error: input.Error}
+}
+
+type protectedUpdateTodoUseCase struct {
+	updateTodoUseCase
+}
+
+func (u *protectedUpdateTodoUseCase) Policies() []propre.Policy[updateTodoInput] {
+	return []propre.Policy[updateTodoInput]{propre.RequireRoles[updateTodoInput]("editor")}
+}
+
+func updateTodoInputError(input updateTodoInput) error {
+	return input.Error
+}
+
+func updateTodoErrorOutput(err error) updateTodoOutput {
+	return updateTodoOutput{Error: err}
+}
+
+func todoOwner(ctx context.Context, input updateTodoInput) (string, error) {
+	switch input.TodoID {
+	case "todo-1":
+		return "user-1", nil
+	case "todo-2":
+		return "user-2", nil
+	}
+
+	return "", errors.New("todo not found")
+}
+
+func TestAuthorizedUseCase(t *testing.T) {
+	type testCase struct {
+		principal      *propre.Principal
+		input          updateTodoInput
+		policies       []propre.Policy[updateTodoInput]
+		expectedErr    error
+		expectedTitle  string
+		expectedCalled bool
+	}
+
+	ownerOrAdmin := propre.AnyOf(
+		propre.RequireOwnership(todoOwner),
+		propre.RequireRoles[updateTodoInput]("admin"),
+	)
+
+	testCases := map[string]testCase{
+		"a principal with the role is allowed": {
+			principal:     &propre.Principal{ID: "user-1", Roles: []string{"editor"}},
+			input:         updateTodoInput{Title: "title"},
+			policies:      []propre.Policy[updateTodoInput]{propre.RequireRoles[updateTodoInput]("admin", "editor")},
+			expectedTitle: "title",
+		},
+		"a principal without the role is denied": {
+			principal:   &propre.Principal{ID: "user-1", Roles: []string{"reader"}},
+			policies:    []propre.Policy[updateTodoInput]{propre.RequireRoles[updateTodoInput]("admin", "editor")},
+			expectedErr: propre.ErrAccessDenied,
+		},
+		"a request without principal is denied": {
+			policies:    []propre.Policy[updateTodoInput]{propre.RequireRoles[updateTodoInput]("admin")},
+			expectedErr: propre.ErrNoCredentials,
+		},
+		"the owner is allowed": {
+			principal:     &propre.Principal{ID: "user-1"},
+			input:         updateTodoInput{TodoID: "todo-1", Title: "title"},
+			policies:      []propre.Policy[updateTodoInput]{ownerOrAdmin},
+			expectedTitle: "title",
+		},
+		"an admin is allowed on the resources of another principal": {
+			principal:     &propre.Principal{ID: "user-1", Roles: []string{"admin"}},
+			input:         updateTodoInput{TodoID: "todo-2", Title: "title"},
+			policies:      []propre.Policy[updateTodoInput]{ownerOrAdmin},
+			expectedTitle: "title",
+		},
+		"another principal is denied": {
+			principal:   &propre.Principal{ID: "user-1"},
+			input:       updateTodoInput{TodoID: "todo-2"},
+			policies:    []propre.Policy[updateTodoInput]{ownerOrAdmin},
+			expectedErr: propre.ErrAccessDenied,
+		},
+		"the owner loading errors are not denials": {
+			principal:   &propre.Principal{ID: "user-1"},
+			input:       updateTodoInput{TodoID: "unknown"},
+			policies:    []propre.Policy[updateTodoInput]{ownerOrAdmin},
+			expectedErr: propre.ErrPolicyEvaluation,
+		},
+		"a condition over the principal and the input is evaluated": {
+			principal: &propre.Principal{ID: "user-1", Tenant: "acme"},
+			input:     updateTodoInput{Title: "title"},
+			policies: []propre.Policy[updateTodoInput]{
+				propre.RequireCondition("tenant is globex", func(ctx context.Context, principal propre.Principal, input updateTodoInput) bool {
+					return principal.Tenant == "globex"
+				}),
+			},
+			expectedErr: propre.ErrAccessDenied,
+		},
+		"an input error is passed to the use case without evaluating the policies": {
+			input:          updateTodoInput{Error: propre.ErrRequestPayloadExtraction},
+			policies:       []propre.Policy[updateTodoInput]{propre.RequireRoles[updateTodoInput]("admin")},
+			expectedErr:    propre.ErrRequestPayloadExtraction,
+			expectedCalled: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = propre.ContextWithPrincipal(ctx, *tc.principal)
+			}
+
+			useCase := &updateTodoUseCase{}
+			authorized := propre.NewAuthorizedUseCase(useCase, updateTodoErrorOutput,
+				propre.WithAuthorizationInputError(updateTodoInputError),
+				propre.WithAuthorizationPolicies(tc.policies...),
+			)
+
+			output := authorized.Handle(ctx, tc.input)
+			if !errors.Is(output.Error, tc.expectedErr) {
+				t.Fatalf("unexpected error, expected %v, got %v", tc.expectedErr, output.Error)
+			}
+
+			if output.Title != tc.expectedTitle {
+				t.Fatalf("unexpected title, expected %q, got %q", tc.expectedTitle, output.Title)
+			}
+
+			if useCase.called != (tc.expectedErr == nil || tc.expectedCalled) {
+				t.Fatalf("the use case should be called only if the request is allowed")
+			}
+		})
+	}
+}
+
+func TestAuthorizedUseCaseEvaluatesThePoliciesOfTheInvalidInputsByDefault(t *testing.T) {
+	useCase := &updateTodoUseCase{}
+	authorized := propre.NewAuthorizedUseCase(useCase, updateTodoErrorOutput,
+		propre.WithAuthorizationPolicies(propre.RequireRoles[updateTodoInput]("admin")),
+	)
+
+	ctx := propre.ContextWithPrincipal(context.Background(), propre.Principal{ID: "user-1"})
+	output := authorized.Handle(ctx, updateTodoInput{Error: propre.ErrRequestPayloadExtraction})
+	if !errors.Is(output.Error, propre.ErrAccessDenied) || useCase.called {
+		t.Fatalf("the policies should be evaluated without input error function, got %v", output.Error)
+	}
+}
+
+func TestAuthorizedUseCaseEvaluatesThePoliciesOfTheUseCaseType(t *testing.T) {
+	useCase := &protectedUpdateTodoUseCase{}
+	authorized := propre.NewAuthorizedUseCase[updateTodoInput, updateTodoOutput](useCase, updateTodoErrorOutput)
+
+	ctx := propre.ContextWithPrincipal(context.Background(), propre.Principal{ID: "user-1"})
+	output := authorized.Handle(ctx, updateTodoInput{})
+	if !errors.Is(output.Error, propre.ErrAccessDenied) || useCase.called {
+		t.Fatalf("the policies of the use case type should be evaluated, got %v", output.Error)
+	}
+}
+
+func TestPolicyReport(t *testing.T) {
+	report := propre.NewPolicyReport()
+	report.Register("PATCH /todos/{id}", propre.NewAuthorizedUseCase(
+		&protectedUpdateTodoUseCase{},
+		updateTodoErrorOutput,
+		propre.WithAuthorizationPolicies(propre.AnyOf(propre.RequireOwnership(todoOwner), propre.RequireRoles[updateTodoInput]("admin"))),
+	))
+	report.Register("GET /todos", nil)
+
+	expected := "GET /todos: unprotected\n" +
+		"PATCH /todos/{id}: role in (editor) and any of (resource owner | role in (admin))\n"
+
+	if report.String() != expected {
+		t.Fatalf("unexpected report, expected:\n%s\ngot:\n%s", expected, report.String())
+	}
+
+	entries := report.Entries()
+	if len(entries) != 2 || entries[1].Endpoint != "PATCH /todos/{id}" || len(entries[1].Policies) != 2 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
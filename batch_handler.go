@@ -0,0 +1,316 @@
+package propre
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrBatchDecoding is set in [BatchOutput] if the request body is not a JSON
+	// array of sub-requests, if it is too big or if it contains too many of them.
+	ErrBatchDecoding = errors.New("batch decoding error")
+
+	// ErrBatchAborted is set in [BatchOutput] if an item failed with [WithBatchFailFast].
+	ErrBatchAborted = errors.New("batch aborted")
+
+	// ErrBatchRolledBack is set in [BatchOutput] if an item failed with [WithBatchAtomic].
+	ErrBatchRolledBack = errors.New("batch rolled back")
+)
+
+// BatchItem is the result of a sub-request of a batch.
+type BatchItem[Output any] struct {
+	// Index is the position of the sub-request in the batch.
+	Index int
+	// Output is the output of the use case, it is the zero value if the item is skipped.
+	Output Output
+	// Skipped is true if the sub-request has not been handled because the batch
+	// has been aborted.
+	Skipped bool
+}
+
+// BatchOutput is the output given to the presenter of a [BatchHandler].
+// Error is set if the batch could not be decoded or if it has been aborted,
+// the items are in the order of the sub-requests.
+type BatchOutput[Output any] struct {
+	Items []BatchItem[Output]
+	Error error
+}
+
+// BatchHandler is an [http.Handler] fanning a batch of sub-requests out to an
+// existing request decoder and use case handler. The request body must be a JSON
+// array, each element is passed to the request decoder as the body of a clone of
+// the batch request, so the decoders written for [HTTPHandler] can be reused as is.
+//
+// The sub-requests are handled concurrently, then the presenter receives all the
+// results at once to build the response, typically a [MultiStatusView].
+type BatchHandler[Input, Output any] struct {
+	requestDecoder RequestDecoder[Input]
+	useCaseHandler UseCaseHandler[Input, Output]
+	presenter      Presenter[BatchOutput[Output], http.ResponseWriter]
+	concurrency    int
+	maxItems       int
+	maxBodySize    int64
+	failed         func(Output) bool
+	txManager      TxManager
+}
+
+// BatchHandlerOpts is the alias for the [BatchHandler] builder options.
+type BatchHandlerOpts[Input, Output any] func(h *BatchHandler[Input, Output])
+
+// WithBatchConcurrency is a [BatchHandler] option to set the maximum number of
+// sub-requests handled concurrently. The default is 1.
+func WithBatchConcurrency[Input, Output any](concurrency int) BatchHandlerOpts[Input, Output] {
+	return func(h *BatchHandler[Input, Output]) {
+		h.concurrency = max(concurrency, 1)
+	}
+}
+
+// WithBatchMaxItems is a [BatchHandler] option to limit the number of sub-requests
+// of a batch. A bigger batch is rejected with [ErrBatchDecoding] as soon as the
+// item exceeding the limit is read. The default is 100, a zero or negative limit
+// disables it.
+func WithBatchMaxItems[Input, Output any](maxItems int) BatchHandlerOpts[Input, Output] {
+	return func(h *BatchHandler[Input, Output]) {
+		h.maxItems = maxItems
+	}
+}
+
+// WithBatchMaxBodySize is a [BatchHandler] option to limit the size in bytes of
+// the request body. A bigger body is rejected with [ErrBatchDecoding], wrapping
+// an [http.MaxBytesError]. The default is 1 MiB.
+func WithBatchMaxBodySize[Input, Output any](size int64) BatchHandlerOpts[Input, Output] {
+	return func(h *BatchHandler[Input, Output]) {
+		h.maxBodySize = size
+	}
+}
+
+// WithBatchFailFast is a [BatchHandler] option to abort the batch as soon as
+// an item fails. The context of the sub-requests in flight is canceled, the ones
+// not started yet are skipped, and [ErrBatchAborted] is set in the [BatchOutput].
+// The given function tells whether an output is a failure.
+//
+// The items already handled are not undone: the batch is not atomic, the
+// presenter must report the completed items along with the error. Use
+// [WithBatchAtomic] for all-or-nothing semantics.
+//
+// By default the semantics are partial: every sub-request is handled whatever
+// the outputs of the other ones.
+func WithBatchFailFast[Input, Output any](failed func(Output) bool) BatchHandlerOpts[Input, Output] {
+	return func(h *BatchHandler[Input, Output]) {
+		h.failed = failed
+		h.txManager = nil
+	}
+}
+
+// WithBatchAtomic is a [BatchHandler] option to handle the batch all or nothing.
+// The sub-requests are handled one by one, the concurrency is ignored, in a
+// single unit of work begun with the given manager. The unit of work is stored
+// in the context of the sub-requests, so the repositories and the
+// [TransactionalUseCase] join it.
+//
+// As soon as an item fails, according to the given function, the unit of work
+// is rolled back, the remaining items are skipped and [ErrBatchRolledBack] is
+// set in the [BatchOutput]: the outputs of the items handled before are kept,
+// but their changes are undone. Otherwise the unit of work is committed, and
+// the functions registered with [AfterCommit] are called. The errors of the
+// unit of work itself are set in the BatchOutput, see [ErrUnitOfWorkBegin],
+// [ErrUnitOfWorkCommit] and [ErrUnitOfWorkRollback].
+func WithBatchAtomic[Input, Output any](txManager TxManager, failed func(Output) bool) BatchHandlerOpts[Input, Output] {
+	return func(h *BatchHandler[Input, Output]) {
+		h.failed = failed
+		h.txManager = txManager
+	}
+}
+
+// NewBatchHandler builds a BatchHandler with the given dependencies.
+// [BatchHandlerOpts] can be passed to customize the concurrency, the size of a
+// batch and its semantics.
+func NewBatchHandler[Input, Output any](
+	requestDecoder RequestDecoder[Input],
+	useCaseHandler UseCaseHandler[Input, Output],
+	presenter Presenter[BatchOutput[Output], http.ResponseWriter],
+	opts ...BatchHandlerOpts[Input, Output],
+) *BatchHandler[Input, Output] {
+	handler := &BatchHandler[Input, Output]{
+		requestDecoder: requestDecoder,
+		useCaseHandler: useCaseHandler,
+		presenter:      presenter,
+		concurrency:    1,
+		maxItems:       100,
+		maxBodySize:    1 << 20,
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP allows BatchHandler to be used by any HTTP "ServeMux".
+func (handler *BatchHandler[Input, Output]) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	subRequests, err := handler.decode(rw, req)
+	if err != nil {
+		handler.presenter.Present(req.Context(), rw, BatchOutput[Output]{
+			Error: fmt.Errorf("%w caused by %w", ErrBatchDecoding, err),
+		})
+
+		return
+	}
+
+	if handler.txManager != nil {
+		handler.presenter.Present(req.Context(), rw, handler.handleAtomic(req, subRequests))
+		return
+	}
+
+	handler.presenter.Present(req.Context(), rw, handler.handle(req, subRequests))
+}
+
+// decode reads the sub-requests one by one, so a batch exceeding the limit is
+// rejected without reading the remaining items.
+func (handler *BatchHandler[Input, Output]) decode(rw http.ResponseWriter, req *http.Request) ([]json.RawMessage, error) {
+	decoder := json.NewDecoder(http.MaxBytesReader(rw, req.Body, handler.maxBodySize))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("unexpected %v instead of a JSON array", token)
+	}
+
+	var subRequests []json.RawMessage
+	for decoder.More() {
+		if handler.maxItems > 0 && len(subRequests) == handler.maxItems {
+			return nil, fmt.Errorf("more than %d items", handler.maxItems)
+		}
+
+		var subRequest json.RawMessage
+		if err := decoder.Decode(&subRequest); err != nil {
+			return nil, err
+		}
+
+		subRequests = append(subRequests, subRequest)
+	}
+
+	// the closing bracket
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return subRequests, nil
+}
+
+func (handler *BatchHandler[Input, Output]) handle(req *http.Request, subRequests []json.RawMessage) BatchOutput[Output] {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	output := BatchOutput[Output]{Items: make([]BatchItem[Output], len(subRequests))}
+	var aborted sync.Once
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, handler.concurrency)
+
+	for i, body := range subRequests {
+		output.Items[i] = BatchItem[Output]{Index: i, Skipped: true}
+
+		select {
+		case <-ctx.Done():
+			continue
+		case slots <- struct{}{}:
+		}
+
+		// The batch may have been aborted while waiting for a slot.
+		if ctx.Err() != nil {
+			<-slots
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			itemOutput := handler.handleItem(ctx, req, body)
+			output.Items[i] = BatchItem[Output]{Index: i, Output: itemOutput}
+
+			if handler.failed != nil && handler.failed(itemOutput) {
+				aborted.Do(func() {
+					output.Error = ErrBatchAborted
+					cancel()
+				})
+			}
+		}()
+	}
+
+	wg.Wait()
+	return output
+}
+
+func (handler *BatchHandler[Input, Output]) handleAtomic(req *http.Request, subRequests []json.RawMessage) BatchOutput[Output] {
+	ctx := req.Context()
+	output := BatchOutput[Output]{Items: make([]BatchItem[Output], len(subRequests))}
+	for i := range output.Items {
+		output.Items[i] = BatchItem[Output]{Index: i, Skipped: true}
+	}
+
+	uow, err := handler.txManager.Begin(ctx)
+	if err != nil {
+		output.Error = fmt.Errorf("%w caused by %w", ErrUnitOfWorkBegin, err)
+		return output
+	}
+
+	settled := false
+	defer func() {
+		// a use case panicked, the panic goes on once the unit of work is rolled back
+		if !settled {
+			uow.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	hooks := &afterCommitHooks{}
+	itemCtx := context.WithValue(ContextWithUnitOfWork(ctx, uow), afterCommitContextKey{}, hooks)
+	for i, body := range subRequests {
+		itemOutput := handler.handleItem(itemCtx, req, body)
+		output.Items[i] = BatchItem[Output]{Index: i, Output: itemOutput}
+
+		if handler.failed(itemOutput) {
+			settled = true
+			output.Error = ErrBatchRolledBack
+			if err := uow.Rollback(context.WithoutCancel(ctx)); err != nil {
+				output.Error = errors.Join(ErrBatchRolledBack, fmt.Errorf("%w caused by %w", ErrUnitOfWorkRollback, err))
+			}
+
+			return output
+		}
+	}
+
+	settled = true
+	if err := uow.Commit(ctx); err != nil {
+		uow.Rollback(context.WithoutCancel(ctx))
+		output.Error = fmt.Errorf("%w caused by %w", ErrUnitOfWorkCommit, err)
+		return output
+	}
+
+	for _, hook := range hooks.take() {
+		hook(ctx)
+	}
+
+	return output
+}
+
+func (handler *BatchHandler[Input, Output]) handleItem(ctx context.Context, req *http.Request, body json.RawMessage) Output {
+	subRequest := req.Clone(ctx)
+	subRequest.Body = io.NopCloser(bytes.NewReader(body))
+	subRequest.ContentLength = int64(len(body))
+
+	input := handler.requestDecoder.Decode(subRequest)
+	return handler.useCaseHandler.Handle(ctx, input)
+}
@@ -0,0 +1,68 @@
+package propre_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type CreateTodosBatchPresenter struct {
+	response *propre.HTTPResponse[propre.MultiStatusView]
+}
+
+func (p *CreateTodosBatchPresenter) Present(ctx context.Context, rw http.ResponseWriter, output propre.BatchOutput[CreateTodoOutput]) {
+	if output.Error != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var view propre.MultiStatusView
+	for _, item := range output.Items {
+		if item.Output.Error != nil {
+			view.Items = append(view.Items, propre.MultiStatusItem{
+				Status: http.StatusBadRequest,
+				Body:   map[string]string{"message": "create todo error"},
+			})
+
+			continue
+		}
+
+		view.Items = append(view.Items, propre.MultiStatusItem{
+			Status: http.StatusCreated,
+			Body:   map[string]any{"id": item.Output.Data.ID, "title": item.Output.Data.Title},
+		})
+	}
+
+	p.response.Send(ctx, rw, view)
+}
+
+// In this example the decoder and the interactor of the HTTPHandler example
+// create several todos in a single request. Each element of the JSON array is
+// decoded by CreateTodoRequestDecoder as if it were the body of its own request.
+//
+// The presenter builds a 207 Multi-Status response with one result per todo.
+func ExampleBatchHandler() {
+	handler := propre.NewBatchHandler(
+		&CreateTodoRequestDecoder[CreateTodoInput]{},
+		&CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput]{},
+		&CreateTodosBatchPresenter{response: propre.NewHTTPResponse[propre.MultiStatusView]()},
+		propre.WithBatchConcurrency[CreateTodoInput, CreateTodoOutput](4),
+	)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/todos/batch", strings.NewReader(
+		`[{"title":"First todo"},{"name":"Wrong field"}]`,
+	))
+
+	handler.ServeHTTP(rw, req)
+
+	fmt.Println(rw.Code)
+	fmt.Println(rw.Body.String())
+	// Output:
+	// 207
+	// {"items":[{"status":201,"body":{"id":42,"title":"First todo"}},{"status":400,"body":{"message":"create todo error"}}]}
+}
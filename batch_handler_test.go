@@ -0,0 +1,269 @@
+package propre_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type batchInput struct {
+	Value int
+	Error error
+}
+
+type batchOutput struct {
+	Value int
+	Error error
+}
+
+type batchDecoder struct{}
+
+func (d batchDecoder) Decode(req *http.Request) batchInput {
+	var input batchInput
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		input.Error = err
+		return input
+	}
+
+	input.Error = json.Unmarshal(body, &input.Value)
+	return input
+}
+
+type batchUseCase struct {
+	running    atomic.Int32
+	maxRunning atomic.Int32
+	handled    atomic.Int32
+}
+
+func (u *batchUseCase) Handle(ctx context.Context, input batchInput) batchOutput {
+	u.handled.Add(1)
+	current := u.running.Add(1)
+	defer u.running.Add(-1)
+
+	for {
+		previous := u.maxRunning.Load()
+		if current <= previous || u.maxRunning.CompareAndSwap(previous, current) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+	if input.Error != nil {
+		return batchOutput{Error: input.Error}
+	}
+
+	if input.Value < 0 {
+		return batchOutput{Error: errors.New("negative value")}
+	}
+
+	return batchOutput{Value: input.Value * 2}
+}
+
+type batchPresenterSpy struct {
+	output propre.BatchOutput[batchOutput]
+}
+
+func (p *batchPresenterSpy) Present(ctx context.Context, rw http.ResponseWriter, output propre.BatchOutput[batchOutput]) {
+	p.output = output
+}
+
+func serveBatch(handler http.Handler, body string) {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestBatchHandlerHandlesEverySubRequestWithPartialSemantics(t *testing.T) {
+	useCase := new(batchUseCase)
+	presenter := new(batchPresenterSpy)
+	handler := propre.NewBatchHandler(
+		batchDecoder{},
+		useCase,
+		presenter,
+		propre.WithBatchConcurrency[batchInput, batchOutput](2),
+	)
+
+	serveBatch(handler, `[1, -2, "three", 4, 5]`)
+
+	if presenter.output.Error != nil {
+		t.Fatalf("unexpected batch error: %s", presenter.output.Error)
+	}
+
+	if len(presenter.output.Items) != 5 {
+		t.Fatalf("expected 5 items, got %d", len(presenter.output.Items))
+	}
+
+	expectedValues := []int{2, 0, 0, 8, 10}
+	expectedFailures := []bool{false, true, true, false, false}
+	for i, item := range presenter.output.Items {
+		if item.Index != i || item.Skipped {
+			t.Fatalf("unexpected item %d: %+v", i, item)
+		}
+
+		if item.Output.Value != expectedValues[i] || (item.Output.Error != nil) != expectedFailures[i] {
+			t.Fatalf("unexpected output for item %d: %+v", i, item.Output)
+		}
+	}
+
+	if useCase.maxRunning.Load() > 2 {
+		t.Fatalf("too many concurrent sub-requests: %d", useCase.maxRunning.Load())
+	}
+}
+
+func TestBatchHandlerAbortsWithFailFastSemantics(t *testing.T) {
+	useCase := new(batchUseCase)
+	presenter := new(batchPresenterSpy)
+	handler := propre.NewBatchHandler(
+		batchDecoder{},
+		useCase,
+		presenter,
+		propre.WithBatchFailFast[batchInput](func(output batchOutput) bool {
+			return output.Error != nil
+		}),
+	)
+
+	serveBatch(handler, `[1, -2, 3, 4]`)
+
+	if !errors.Is(presenter.output.Error, propre.ErrBatchAborted) {
+		t.Fatalf("unexpected batch error: %v", presenter.output.Error)
+	}
+
+	expectedSkipped := []bool{false, false, true, true}
+	for i, item := range presenter.output.Items {
+		if item.Skipped != expectedSkipped[i] {
+			t.Fatalf("unexpected item %d: %+v", i, item)
+		}
+	}
+
+	if useCase.handled.Load() != 2 {
+		t.Fatalf("expected 2 handled sub-requests, got %d", useCase.handled.Load())
+	}
+}
+
+type batchAtomicTestCase struct {
+	body            string
+	expectedError   error
+	expectedState   propre.UnitOfWorkState
+	expectedSkipped []bool
+}
+
+func TestBatchHandlerHandlesAllOrNothingWithAtomicSemantics(t *testing.T) {
+	testCases := map[string]batchAtomicTestCase{
+		"every item succeeds": {
+			body:            `[1, 2, 3]`,
+			expectedState:   propre.UnitOfWorkCommitted,
+			expectedSkipped: []bool{false, false, false},
+		},
+		"an item fails": {
+			body:            `[1, -2, 3]`,
+			expectedError:   propre.ErrBatchRolledBack,
+			expectedState:   propre.UnitOfWorkRolledBack,
+			expectedSkipped: []bool{false, false, true},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			txManager := propre.NewInMemoryTxManager()
+			presenter := new(batchPresenterSpy)
+			handler := propre.NewBatchHandler(
+				batchDecoder{},
+				propre.UseCaseHandlerFunc[batchInput, batchOutput](func(ctx context.Context, input batchInput) batchOutput {
+					if _, ok := propre.UnitOfWorkFromContext(ctx); !ok {
+						t.Fatal("the sub-requests should be handled in the unit of work")
+					}
+
+					return new(batchUseCase).Handle(ctx, input)
+				}),
+				presenter,
+				propre.WithBatchConcurrency[batchInput, batchOutput](4),
+				propre.WithBatchAtomic[batchInput](txManager, func(output batchOutput) bool {
+					return output.Error != nil
+				}),
+			)
+
+			serveBatch(handler, testCase.body)
+
+			if !errors.Is(presenter.output.Error, testCase.expectedError) {
+				t.Fatalf("unexpected batch error: %v", presenter.output.Error)
+			}
+
+			unitsOfWork := txManager.UnitsOfWork()
+			if len(unitsOfWork) != 1 || unitsOfWork[0].State() != testCase.expectedState {
+				t.Fatalf("expected a single unit of work in state %v, got %v", testCase.expectedState, unitsOfWork)
+			}
+
+			for i, item := range presenter.output.Items {
+				if item.Skipped != testCase.expectedSkipped[i] {
+					t.Fatalf("unexpected item %d: %+v", i, item)
+				}
+			}
+		})
+	}
+}
+
+type batchDecodingTestCase struct {
+	body string
+}
+
+func TestBatchHandlerRejectsInvalidBatches(t *testing.T) {
+	testCases := map[string]batchDecodingTestCase{
+		"not an array":   {body: `{"value":1}`},
+		"malformed JSON": {body: `[1, 2`},
+		"too many items": {body: `[1, 2, 3, 4]`},
+		"too big body":   {body: `[1, 2, "` + strings.Repeat("a", 64) + `"]`},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			useCase := new(batchUseCase)
+			presenter := new(batchPresenterSpy)
+			handler := propre.NewBatchHandler(
+				batchDecoder{},
+				useCase,
+				presenter,
+				propre.WithBatchMaxItems[batchInput, batchOutput](3),
+				propre.WithBatchMaxBodySize[batchInput, batchOutput](32),
+			)
+
+			serveBatch(handler, testCase.body)
+
+			if !errors.Is(presenter.output.Error, propre.ErrBatchDecoding) {
+				t.Fatalf("unexpected batch error: %v", presenter.output.Error)
+			}
+
+			if useCase.handled.Load() != 0 {
+				t.Fatalf("no sub-request should be handled, got %d", useCase.handled.Load())
+			}
+		})
+	}
+}
+
+func TestMultiStatusView(t *testing.T) {
+	response := propre.NewHTTPResponse[propre.MultiStatusView]()
+	rw := httptest.NewRecorder()
+	response.Send(context.Background(), rw, propre.MultiStatusView{
+		Items: []propre.MultiStatusItem{
+			{Status: http.StatusCreated, Body: map[string]int{"id": 1}},
+			{Status: http.StatusBadRequest},
+		},
+	})
+
+	if rw.Code != http.StatusMultiStatus {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusMultiStatus, rw.Code)
+	}
+
+	expectedBody := `{"items":[{"status":201,"body":{"id":1}},{"status":400}]}`
+	if rw.Body.String() != expectedBody {
+		t.Fatalf("unexpected body, expected %s, got %s", expectedBody, rw.Body.String())
+	}
+}
@@ -0,0 +1,168 @@
+package propre
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// ExitSuccess is the exit code of a command which succeeded.
+	ExitSuccess = 0
+	// ExitFailure is the exit code of a command which failed.
+	ExitFailure = 1
+	// ExitUsage is the exit code of a command called with wrong arguments.
+	ExitUsage = 2
+)
+
+// CommandDecoder is the CLI counterpart of [RequestDecoder]. It checks and
+// extracts the command line arguments required by a use case. The produced
+// input can be either an error or the actual data required by the use case.
+type CommandDecoder[Input any] interface {
+	Decode(args []string) Input
+}
+
+// CLIWriter is the writer given to the presenters of a [CLIHandler].
+// Writing to it writes to the standard output, the standard error is
+// available through the Stderr field for error messages.
+// The presenter maps the output to an exit code with SetExitCode.
+type CLIWriter struct {
+	Stdout   io.Writer
+	Stderr   io.Writer
+	exitCode int
+}
+
+// Write writes to the standard output.
+func (w *CLIWriter) Write(p []byte) (int, error) {
+	return w.Stdout.Write(p)
+}
+
+// SetExitCode sets the exit code returned by the command.
+func (w *CLIWriter) SetExitCode(code int) {
+	w.exitCode = code
+}
+
+// ExitCode returns the exit code set by the presenter, [ExitSuccess] by default.
+func (w *CLIWriter) ExitCode() int {
+	return w.exitCode
+}
+
+// Command is implemented by anything runnable from the command line.
+// It returns the exit code of the process.
+type Command interface {
+	Run(ctx context.Context, args []string, stdout, stderr io.Writer) int
+}
+
+// CLIHandler is the CLI counterpart of [HTTPHandler]. It allows to run the same
+// use cases from the command line. Each command requires:
+//   - a command decoder to transform the command line arguments to a use case input,
+//   - a use case handler,
+//   - a presenter to write the output to the standard output or error and to set the exit code.
+//
+// It implements [Command] to be registered in a [CLIMux].
+type CLIHandler[Input, Output any] struct {
+	commandDecoder CommandDecoder[Input]
+	useCaseHandler UseCaseHandler[Input, Output]
+	presenter      Presenter[Output, *CLIWriter]
+}
+
+// NewCLIHandler builds a CLIHandler with the given dependencies.
+func NewCLIHandler[Input, Output any](
+	commandDecoder CommandDecoder[Input],
+	useCaseHandler UseCaseHandler[Input, Output],
+	presenter Presenter[Output, *CLIWriter],
+) *CLIHandler[Input, Output] {
+	return &CLIHandler[Input, Output]{
+		commandDecoder: commandDecoder,
+		useCaseHandler: useCaseHandler,
+		presenter:      presenter,
+	}
+}
+
+// Run decodes the arguments, passes the input to the use case handler and
+// presents the output. It returns the exit code set by the presenter.
+func (handler *CLIHandler[Input, Output]) Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	input := handler.commandDecoder.Decode(args)
+	output := handler.useCaseHandler.Handle(ctx, input)
+
+	w := &CLIWriter{Stdout: stdout, Stderr: stderr}
+	handler.presenter.Present(ctx, w, output)
+
+	return w.ExitCode()
+}
+
+type cliCommand struct {
+	description string
+	command     Command
+}
+
+// CLIMux is the CLI counterpart of an HTTP "ServeMux". It dispatches the
+// command line to the [Command] registered under the first argument.
+// A CLIMux implements [Command] itself, so subcommands can be nested.
+type CLIMux struct {
+	name     string
+	commands map[string]cliCommand
+}
+
+// NewCLIMux builds a CLIMux, the name is used in the usage message.
+func NewCLIMux(name string) *CLIMux {
+	return &CLIMux{
+		name:     name,
+		commands: make(map[string]cliCommand),
+	}
+}
+
+// Handle registers a command under the given name. It panics if a command
+// is already registered with the same name.
+func (mux *CLIMux) Handle(name, description string, command Command) {
+	if _, exists := mux.commands[name]; exists {
+		panic(fmt.Sprintf("propre: command %q already registered in %q", name, mux.name))
+	}
+
+	mux.commands[name] = cliCommand{description: description, command: command}
+}
+
+// Run runs the command registered under args[0] with the remaining arguments.
+// The usage is written to stderr and [ExitUsage] is returned if no command
+// matches. The "help" command writes the usage to stdout.
+func (mux *CLIMux) Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		mux.usage(stderr)
+		return ExitUsage
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		mux.usage(stdout)
+		return ExitSuccess
+	}
+
+	registered, ok := mux.commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "%s: unknown command %q\n", mux.name, args[0])
+		mux.usage(stderr)
+		return ExitUsage
+	}
+
+	return registered.command.Run(ctx, args[1:], stdout, stderr)
+}
+
+func (mux *CLIMux) usage(w io.Writer) {
+	names := make([]string, 0, len(mux.commands))
+	width := 0
+	for name := range mux.commands {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+
+	sort.Strings(names)
+
+	var usage strings.Builder
+	fmt.Fprintf(&usage, "Usage: %s <command> [arguments]\n\nCommands:\n", mux.name)
+	for _, name := range names {
+		fmt.Fprintf(&usage, "  %-*s  %s\n", width, name, mux.commands[name].description)
+	}
+
+	io.WriteString(w, usage.String())
+}
@@ -0,0 +1,75 @@
+package propre_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type CreateTodoCommandPayload struct {
+	Title string `flag:"title" usage:"title of the todo"`
+}
+
+func (p CreateTodoCommandPayload) Validate() error {
+	if p.Title == "" {
+		return fmt.Errorf("missing title flag")
+	}
+
+	return nil
+}
+
+type CreateTodoCommandDecoder struct {
+	extractor *propre.CommandArgsExtractor[CreateTodoCommandPayload]
+}
+
+func (decoder *CreateTodoCommandDecoder) Decode(args []string) CreateTodoInput {
+	var input CreateTodoInput
+	payload, err := decoder.extractor.Extract(args)
+	if err != nil {
+		input.Error = fmt.Errorf("[CreateTodoCommandDecoder] %w", err)
+		return input
+	}
+
+	input.Data.Title = payload.Title
+	return input
+}
+
+type CreateTodoCLIPresenter struct{}
+
+func (p *CreateTodoCLIPresenter) Present(ctx context.Context, w *propre.CLIWriter, output CreateTodoOutput) {
+	if output.Error != nil {
+		fmt.Fprintln(w.Stderr, "create todo error")
+		w.SetExitCode(propre.ExitFailure)
+		return
+	}
+
+	fmt.Fprintf(w, "todo #%d created: %s\n", output.Data.ID, output.Data.Title)
+}
+
+// In this example the use case of the HTTPHandler example is run from the
+// command line. Only the decoder and the presenter are specific to the CLI,
+// the interactor is the same.
+//
+// The command is registered in a CLIMux under the name "create", the process
+// would typically exit with the code returned by Run.
+func ExampleCLIHandler() {
+	decoder := &CreateTodoCommandDecoder{
+		extractor: propre.NewCommandArgsExtractor[CreateTodoCommandPayload](),
+	}
+
+	useCaseHandler := &CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput]{}
+	presenter := &CreateTodoCLIPresenter{}
+
+	app := propre.NewCLIMux("todo")
+	app.Handle("create", "create a new todo", propre.NewCLIHandler(decoder, useCaseHandler, presenter))
+
+	args := []string{"create", "-title", "New todo title"}
+	exitCode := app.Run(context.Background(), args, os.Stdout, os.Stderr)
+
+	fmt.Println(exitCode)
+	// Output:
+	// todo #42 created: New todo title
+	// 0
+}
@@ -0,0 +1,166 @@
+package propre_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCLIHandlerImplementsCommand(t *testing.T) {
+	handler := propre.NewCLIHandler(
+		new(commandDecoderMock[any]),
+		new(useCaseHandlerMock[any, any]),
+		new(cliPresenterMock[any]),
+	)
+
+	f := func(c propre.Command) {}
+	f(handler)
+	f(propre.NewCLIMux("app"))
+}
+
+func TestCLIHandlerUsesACommandDecoderThenAUseCaseHandlerThenPresentsTheOutput(t *testing.T) {
+	commandDecoder := new(commandDecoderMock[any])
+	defer commandDecoder.AssertExpectations(t)
+
+	useCaseHandler := new(useCaseHandlerMock[any, any])
+	defer useCaseHandler.AssertExpectations(t)
+
+	presenter := new(cliPresenterMock[any])
+	defer presenter.AssertExpectations(t)
+
+	handler := propre.NewCLIHandler(commandDecoder, useCaseHandler, presenter)
+	args := []string{"-some", "args"}
+
+	useCaseInput := "some input"
+	useCaseOutput := "some output"
+
+	commandDecoder.On("Decode", args).Return(useCaseInput)
+
+	ctxArgMatcher := mock.MatchedBy(func(ctx context.Context) bool {
+		return true
+	})
+
+	useCaseHandler.On("Handle", ctxArgMatcher, useCaseInput).Return(useCaseOutput)
+	presenter.On("Present", ctxArgMatcher, mock.AnythingOfType("*propre.CLIWriter"), useCaseOutput).
+		Run(func(args mock.Arguments) {
+			w := args.Get(1).(*propre.CLIWriter)
+			fmt.Fprint(w, "some result")
+			fmt.Fprint(w.Stderr, "some warning")
+			w.SetExitCode(propre.ExitFailure)
+		})
+
+	var stdout, stderr bytes.Buffer
+	exitCode := handler.Run(context.Background(), args, &stdout, &stderr)
+
+	if exitCode != propre.ExitFailure {
+		t.Fatalf("wrong exit code, expected %d, got %d", propre.ExitFailure, exitCode)
+	}
+
+	if stdout.String() != "some result" {
+		t.Fatalf("unexpected stdout %q", stdout.String())
+	}
+
+	if stderr.String() != "some warning" {
+		t.Fatalf("unexpected stderr %q", stderr.String())
+	}
+}
+
+type cliMuxTestCase struct {
+	args             []string
+	expectedExitCode int
+	expectedStdout   string
+	expectedStderr   string
+}
+
+func TestCLIMux(t *testing.T) {
+	todo := propre.NewCLIMux("todo")
+	todo.Handle("add", "add a todo", commandFunc(func(args []string, stdout io.Writer) int {
+		fmt.Fprintf(stdout, "add %s", strings.Join(args, " "))
+		return propre.ExitSuccess
+	}))
+
+	app := propre.NewCLIMux("app")
+	app.Handle("todo", "manage todos", todo)
+
+	testCases := map[string]cliMuxTestCase{
+		"nested subcommand": {
+			args:             []string{"todo", "add", "-title", "milk"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedStdout:   "add -title milk",
+		},
+		"help": {
+			args:             []string{"help"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedStdout:   "Usage: app <command> [arguments]\n\nCommands:\n  todo  manage todos\n",
+		},
+		"no command": {
+			args:             nil,
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "Usage: app <command> [arguments]\n\nCommands:\n  todo  manage todos\n",
+		},
+		"unknown command": {
+			args:             []string{"todo", "remove"},
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "todo: unknown command \"remove\"\nUsage: todo <command> [arguments]\n\nCommands:\n  add  add a todo\n",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := app.Run(context.Background(), testCase.args, &stdout, &stderr)
+
+			if exitCode != testCase.expectedExitCode {
+				t.Fatalf("wrong exit code, expected %d, got %d", testCase.expectedExitCode, exitCode)
+			}
+
+			if stdout.String() != testCase.expectedStdout {
+				t.Fatalf("unexpected stdout, expected %q, got %q", testCase.expectedStdout, stdout.String())
+			}
+
+			if stderr.String() != testCase.expectedStderr {
+				t.Fatalf("unexpected stderr, expected %q, got %q", testCase.expectedStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestCLIMuxPanicsOnDuplicateCommands(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	mux := propre.NewCLIMux("app")
+	mux.Handle("cmd", "", propre.NewCLIMux("cmd"))
+	mux.Handle("cmd", "", propre.NewCLIMux("cmd"))
+}
+
+type commandFunc func(args []string, stdout io.Writer) int
+
+func (f commandFunc) Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	return f(args, stdout)
+}
+
+type commandDecoderMock[Input any] struct {
+	mock.Mock
+}
+
+func (m *commandDecoderMock[Input]) Decode(args []string) Input {
+	return m.Called(args).Get(0).(Input)
+}
+
+type cliPresenterMock[Output any] struct {
+	mock.Mock
+}
+
+func (m *cliPresenterMock[Output]) Present(ctx context.Context, w *propre.CLIWriter, output Output) {
+	m.Called(ctx, w, output)
+}
@@ -0,0 +1,322 @@
+package propre
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrClientRequest is returned by [Endpoint] if the request cannot be built
+	// or sent, or if its payload cannot be encoded.
+	ErrClientRequest = errors.New("client request error")
+
+	// ErrClientResponseDecoding is returned by [Endpoint] if the body of a
+	// successful response cannot be decoded in the view type.
+	ErrClientResponseDecoding = errors.New("client response decoding error")
+
+	// ErrClientResponseStatus is wrapped by [ClientError].
+	ErrClientResponseStatus = errors.New("client response status error")
+)
+
+// PayloadEncoder is the client counterpart of [PayloadDecoder], it encodes the
+// payloads sent by an [Endpoint].
+type PayloadEncoder interface {
+	Encode(payload any) error
+}
+
+// JSONEncoder returns a standard JSON encoder, the counterpart of [JSONDecoder].
+func JSONEncoder(w io.Writer) PayloadEncoder {
+	return json.NewEncoder(w)
+}
+
+// XMLEncoder returns a standard XML encoder, the counterpart of [XMLDecoder].
+func XMLEncoder(w io.Writer) PayloadEncoder {
+	return xml.NewEncoder(w)
+}
+
+// PayloadFormat pairs the encoder and the decoder of a content type, so a
+// client encodes the payloads in the format decoded by the server.
+type PayloadFormat struct {
+	ContentType string
+	Encoder     func(io.Writer) PayloadEncoder
+	Decoder     func(io.Reader) PayloadDecoder
+}
+
+var (
+	// JSONFormat is the [PayloadFormat] of the endpoints using [JSONDecoder].
+	JSONFormat = PayloadFormat{ContentType: "application/json", Encoder: JSONEncoder, Decoder: JSONDecoder}
+
+	// XMLFormat is the [PayloadFormat] of the endpoints using [XMLDecoder].
+	XMLFormat = PayloadFormat{ContentType: "application/xml", Encoder: XMLEncoder, Decoder: XMLDecoder}
+)
+
+// ClientError is returned by [Endpoint] if the response has a status code out
+// of the 2xx range. View is the body decoded in the error view type, the raw
+// body is kept in Body if it cannot be decoded. It wraps [ErrClientResponseStatus].
+type ClientError[ErrorView any] struct {
+	StatusCode int
+	Header     http.Header
+	View       ErrorView
+	Body       []byte
+}
+
+func (e *ClientError[ErrorView]) Error() string {
+	return fmt.Sprintf("%s: %d %s", ErrClientResponseStatus, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *ClientError[ErrorView]) Unwrap() error {
+	return ErrClientResponseStatus
+}
+
+// Client holds the settings shared by the endpoints of a propre service.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	headers    http.Header
+}
+
+// ClientOpts is the alias for the [Client] builder options.
+type ClientOpts func(c *Client)
+
+// WithHTTPClient is a [Client] option to send the requests with the given HTTP
+// client, like the client of an [net/http/httptest.Server]. The default is
+// [http.DefaultClient].
+func WithHTTPClient(httpClient *http.Client) ClientOpts {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithClientHeaders is a [Client] option to set common headers for every request,
+// like an Authorization header.
+func WithClientHeaders(headers http.Header) ClientOpts {
+	return func(c *Client) {
+		c.headers = headers
+	}
+}
+
+// NewClient builds a Client calling the service at the given base URL.
+// [ClientOpts] can be passed to customize the HTTP client and the headers.
+func NewClient(baseURL string, opts ...ClientOpts) *Client {
+	client := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		headers:    http.Header{},
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	return client
+}
+
+// Endpoint is a typed client of a route. It reuses the payload type decoded by the
+// [RequestPayloadExtractor] of the server and the view types sent by its presenter:
+// the payload is encoded with the [PayloadFormat] of the route, then the response
+// body is decoded in View for a 2xx status code, in ErrorView otherwise.
+type Endpoint[Payload, View, ErrorView any] struct {
+	client *Client
+	method string
+	path   string
+	format PayloadFormat
+}
+
+// NewEndpoint builds an Endpoint for a route. The pattern uses the syntax of
+// [http.ServeMux], like "POST /todos/{id}", the host of the pattern is ignored
+// and the wildcards are replaced with the values given by [WithCallPathValue].
+func NewEndpoint[Payload, View, ErrorView any](
+	client *Client,
+	pattern string,
+	format PayloadFormat,
+) *Endpoint[Payload, View, ErrorView] {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = http.MethodGet, pattern
+	}
+
+	path = strings.TrimSpace(path)
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+
+	return &Endpoint[Payload, View, ErrorView]{
+		client: client,
+		method: method,
+		path:   path,
+		format: format,
+	}
+}
+
+type clientCall struct {
+	header     http.Header
+	query      url.Values
+	pathValues map[string]string
+	timeout    time.Duration
+}
+
+// ClientCallOpts is the alias for the options of a call of an [Endpoint].
+type ClientCallOpts func(c *clientCall)
+
+// WithCallHeader is an [Endpoint] call option to set a header of the request.
+func WithCallHeader(key, value string) ClientCallOpts {
+	return func(c *clientCall) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithCallTimeout is an [Endpoint] call option to set the timeout of the call.
+func WithCallTimeout(timeout time.Duration) ClientCallOpts {
+	return func(c *clientCall) {
+		c.timeout = timeout
+	}
+}
+
+// WithCallPathValue is an [Endpoint] call option to set the value of a wildcard of
+// the route pattern.
+func WithCallPathValue(name, value string) ClientCallOpts {
+	return func(c *clientCall) {
+		c.pathValues[name] = value
+	}
+}
+
+// WithCallQuery is an [Endpoint] call option to set the query string of the request.
+func WithCallQuery(query url.Values) ClientCallOpts {
+	return func(c *clientCall) {
+		c.query = query
+	}
+}
+
+// Call sends the payload to the route and returns the decoded view. The payload
+// is not sent for the GET and HEAD routes. It returns a [*ClientError] if the
+// status code is not in the 2xx range.
+func (e *Endpoint[Payload, View, ErrorView]) Call(ctx context.Context, payload Payload, opts ...ClientCallOpts) (View, error) {
+	var view View
+	call := &clientCall{header: http.Header{}, pathValues: make(map[string]string)}
+	for _, opt := range opts {
+		opt(call)
+	}
+
+	if call.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, call.timeout)
+		defer cancel()
+	}
+
+	req, err := e.newRequest(ctx, payload, call)
+	if err != nil {
+		return view, fmt.Errorf("%w caused by %w", ErrClientRequest, err)
+	}
+
+	res, err := e.client.httpClient.Do(req)
+	if err != nil {
+		return view, fmt.Errorf("%w caused by %w", ErrClientRequest, err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return view, fmt.Errorf("%w caused by %w", ErrClientRequest, err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		clientErr := &ClientError[ErrorView]{StatusCode: res.StatusCode, Header: res.Header, Body: body}
+		if len(body) > 0 {
+			e.format.Decoder(bytes.NewReader(body)).Decode(&clientErr.View)
+		}
+
+		return view, clientErr
+	}
+
+	if len(body) == 0 {
+		return view, nil
+	}
+
+	if err := e.format.Decoder(bytes.NewReader(body)).Decode(&view); err != nil {
+		return view, fmt.Errorf("%w caused by %w", ErrClientResponseDecoding, err)
+	}
+
+	return view, nil
+}
+
+func (e *Endpoint[Payload, View, ErrorView]) newRequest(ctx context.Context, payload Payload, call *clientCall) (*http.Request, error) {
+	path, err := expandPath(e.path, call.pathValues)
+	if err != nil {
+		return nil, err
+	}
+
+	target := e.client.baseURL + path
+	if len(call.query) > 0 {
+		target += "?" + call.query.Encode()
+	}
+
+	var body io.Reader
+	withBody := e.method != http.MethodGet && e.method != http.MethodHead
+	if withBody {
+		var buf bytes.Buffer
+		if err := e.format.Encoder(&buf).Encode(payload); err != nil {
+			return nil, err
+		}
+
+		body = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, e.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range e.client.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	req.Header.Set("Accept", e.format.ContentType)
+	if withBody {
+		req.Header.Set("Content-Type", e.format.ContentType)
+	}
+
+	for key, values := range call.header {
+		req.Header[key] = values
+	}
+
+	return req, nil
+}
+
+// expandPath replaces the wildcards of a route pattern with the escaped values.
+func expandPath(pattern string, values map[string]string) (string, error) {
+	segments := strings.Split(strings.ReplaceAll(pattern, "{$}", ""), "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name, remaining := strings.CutSuffix(segment[1:len(segment)-1], "...")
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("missing value of the path wildcard %q", name)
+		}
+
+		if remaining {
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+
+			segments[i] = strings.Join(parts, "/")
+		} else {
+			segments[i] = url.PathEscape(value)
+		}
+	}
+
+	return strings.Join(segments, "/"), nil
+}
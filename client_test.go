@@ -0,0 +1,159 @@
+package propre_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type renameTodoPayload struct {
+	XMLName xml.Name `json:"-" xml:"RenameTodo"`
+	Title   string   `json:"title" xml:"Title"`
+}
+
+type renamedTodoView struct {
+	XMLName xml.Name `json:"-" xml:"Todo"`
+	ID      string   `json:"id" xml:"ID"`
+	Title   string   `json:"title" xml:"Title"`
+	Fields  string   `json:"fields" xml:"Fields"`
+	Tenant  string   `json:"tenant" xml:"Tenant"`
+}
+
+type todoErrorView struct {
+	XMLName xml.Name `json:"-" xml:"Error"`
+	Message string   `json:"message" xml:"Message"`
+}
+
+func newTodoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /todos/{id}", func(rw http.ResponseWriter, req *http.Request) {
+		format := propre.JSONFormat
+		if req.Header.Get("Content-Type") == "application/xml" {
+			format = propre.XMLFormat
+		}
+
+		var payload renameTodoPayload
+		if err := format.Decoder(req.Body).Decode(&payload); err != nil || payload.Title == "" {
+			rw.Header().Set("Content-Type", format.ContentType)
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			format.Encoder(rw).Encode(todoErrorView{Message: "missing title"})
+			return
+		}
+
+		rw.Header().Set("Content-Type", format.ContentType)
+		format.Encoder(rw).Encode(renamedTodoView{
+			ID:     req.PathValue("id"),
+			Title:  payload.Title,
+			Fields: req.URL.Query().Get("fields"),
+			Tenant: req.Header.Get("X-Tenant"),
+		})
+	})
+	mux.HandleFunc("DELETE /todos/{id}", func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /slow", func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestEndpointCall(t *testing.T) {
+	server := newTodoServer(t)
+
+	type testCase struct {
+		format propre.PayloadFormat
+	}
+
+	testCases := map[string]testCase{
+		"json": {format: propre.JSONFormat},
+		"xml":  {format: propre.XMLFormat},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			client := propre.NewClient(server.URL, propre.WithHTTPClient(server.Client()))
+			endpoint := propre.NewEndpoint[renameTodoPayload, renamedTodoView, todoErrorView](client, "PUT /todos/{id}", tc.format)
+
+			view, err := endpoint.Call(
+				context.Background(),
+				renameTodoPayload{Title: "new title"},
+				propre.WithCallPathValue("id", "todo 1"),
+				propre.WithCallQuery(url.Values{"fields": []string{"title"}}),
+				propre.WithCallHeader("X-Tenant", "acme"),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := renamedTodoView{ID: "todo 1", Title: "new title", Fields: "title", Tenant: "acme"}
+			view.XMLName = xml.Name{}
+			if view != expected {
+				t.Fatalf("unexpected view, expected %+v, got %+v", expected, view)
+			}
+
+			_, err = endpoint.Call(context.Background(), renameTodoPayload{}, propre.WithCallPathValue("id", "1"))
+
+			var clientErr *propre.ClientError[todoErrorView]
+			if !errors.As(err, &clientErr) || !errors.Is(err, propre.ErrClientResponseStatus) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if clientErr.StatusCode != http.StatusUnprocessableEntity || clientErr.View.Message != "missing title" {
+				t.Fatalf("unexpected client error %+v", clientErr)
+			}
+		})
+	}
+}
+
+func TestEndpointCallWithoutResponseBody(t *testing.T) {
+	server := newTodoServer(t)
+	client := propre.NewClient(server.URL, propre.WithHTTPClient(server.Client()))
+	endpoint := propre.NewEndpoint[struct{}, struct{}, json.RawMessage](client, "DELETE /todos/{id}", propre.JSONFormat)
+
+	if _, err := endpoint.Call(context.Background(), struct{}{}, propre.WithCallPathValue("id", "1")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err := endpoint.Call(context.Background(), struct{}{})
+	if !errors.Is(err, propre.ErrClientRequest) {
+		t.Fatalf("a missing path value should be reported, got %v", err)
+	}
+}
+
+func TestEndpointCallIgnoresPatternHost(t *testing.T) {
+	server := newTodoServer(t)
+	client := propre.NewClient(server.URL, propre.WithHTTPClient(server.Client()))
+	endpoint := propre.NewEndpoint[struct{}, struct{}, json.RawMessage](client, "DELETE api.example.com/todos/{id}", propre.JSONFormat)
+
+	if _, err := endpoint.Call(context.Background(), struct{}{}, propre.WithCallPathValue("id", "1")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestEndpointCallTimeout(t *testing.T) {
+	server := newTodoServer(t)
+	client := propre.NewClient(server.URL, propre.WithHTTPClient(server.Client()))
+	endpoint := propre.NewEndpoint[struct{}, struct{}, json.RawMessage](client, "GET /slow", propre.JSONFormat)
+
+	_, err := endpoint.Call(context.Background(), struct{}{}, propre.WithCallTimeout(10*time.Millisecond))
+	if !errors.Is(err, propre.ErrClientRequest) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("the call should time out, got %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+// Command propre generates the code of the new endpoints of an application
+// built with propre.
+//
+// The new command generates the input and the output of a use case, its
+// interactor, request decoder, presenter, view models, route registration and
+// their tests:
+//
+//	propre new -method POST -path /todos -field title:string -field done:bool CreateTodo
+//	propre new -spec create_todo.yaml
+//
+// The packages of the generated files are set by the propre.yaml file at the
+// root of the module, or by the file given with the -config flag.
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func main() {
+	os.Exit(newApp().Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func newApp() *propre.CLIMux {
+	app := propre.NewCLIMux("propre")
+	app.Handle("new", "generate the files of a new endpoint", newEndpointCommand())
+
+	return app
+}
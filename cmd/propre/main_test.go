@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func newModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files["go.mod"] = "module example.com/app\n\ngo 1.22.0\n"
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestNewCommand(t *testing.T) {
+	type testCase struct {
+		files            map[string]string
+		args             []string
+		expectedExitCode int
+		expectedFiles    []string
+		expectedStderr   string
+	}
+
+	testCases := map[string]testCase{
+		"the endpoint is generated from the flags": {
+			args:             []string{"-path", "/todos/{id}", "-method", "PUT", "-field", "title:string", "UpdateTodo"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedFiles:    []string{"internal/usecase/update_todo.go", "internal/api/update_todo_route.go"},
+		},
+		"the endpoint is generated from a spec file in the layout of the config file": {
+			files: map[string]string{
+				"propre.yaml": "usecase: internal/todo\nrouter: internal/api/routes\n",
+				"spec.yaml":   "name: CreateTodo\npath: /todos\nfields:\n  - title:string\n",
+			},
+			args:             []string{"-spec", "spec.yaml"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedFiles:    []string{"internal/todo/create_todo.go", "internal/api/create_todo_decoder.go", "internal/api/routes/create_todo_route.go"},
+		},
+		"the path is required": {
+			args:             []string{"CreateTodo"},
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "the name and the -path flag are required",
+		},
+		"the spec is invalid": {
+			args:             []string{"-path", "/todos", "-field", "title", "CreateTodo"},
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "invalid endpoint spec",
+		},
+		"the existing files are not overwritten": {
+			files:            map[string]string{"propre.yaml": "usecase: .\n", "create_todo.go": "package app\n"},
+			args:             []string{"-path", "/todos", "CreateTodo"},
+			expectedExitCode: propre.ExitFailure,
+			expectedStderr:   "file already exists: create_todo.go",
+		},
+		"the unexpected arguments are reported with the usage": {
+			args:             []string{"-path", "/todos", "CreateTodo", "UpdateTodo"},
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "unexpected arguments [\"UpdateTodo\"]\n\nUsage: propre new [flags] <name>",
+		},
+		"the help is written": {
+			args:             []string{"-h"},
+			expectedExitCode: propre.ExitUsage,
+			expectedStderr:   "Usage: propre new [flags] <name>",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			files := tc.files
+			if files == nil {
+				files = make(map[string]string)
+			}
+
+			root := newModule(t, files)
+			for i, arg := range tc.args {
+				if strings.HasSuffix(arg, ".yaml") {
+					tc.args[i] = filepath.Join(root, arg)
+				}
+			}
+
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"new", "-dir", root}, tc.args...)
+			exitCode := newApp().Run(context.Background(), args, &stdout, &stderr)
+			if exitCode != tc.expectedExitCode {
+				t.Fatalf("expected the exit code %d, got %d\nstderr: %s", tc.expectedExitCode, exitCode, stderr.String())
+			}
+
+			if !strings.Contains(stderr.String(), tc.expectedStderr) {
+				t.Fatalf("expected %q in the standard error, got %q", tc.expectedStderr, stderr.String())
+			}
+
+			for _, f := range tc.expectedFiles {
+				if _, err := os.Stat(filepath.Join(root, f)); err != nil {
+					t.Fatalf("the file %s should be generated: %s", f, err)
+				}
+
+				if !strings.Contains(stdout.String(), "created "+f) {
+					t.Fatalf("the file %s should be listed, got %q", f, stdout.String())
+				}
+			}
+		})
+	}
+}
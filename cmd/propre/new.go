@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/internal/scaffold"
+)
+
+// layoutFile is the file read at the root of the module to set the layout.
+const layoutFile = "propre.yaml"
+
+type newEndpointPayload struct {
+	Method string   `flag:"method" usage:"HTTP method of the route" default:"POST"`
+	Path   string   `flag:"path" usage:"path of the route, like /todos/{id}"`
+	Fields []string `flag:"field" usage:"field of the input as name:type[:source], can be repeated"`
+	Spec   string   `flag:"spec" usage:"YAML file describing the endpoint, instead of the name and the flags"`
+	Config string   `flag:"config" usage:"YAML file of the package layout (default propre.yaml at the root of the module)"`
+	Dir    string   `flag:"dir" usage:"directory of the Go module" default:"."`
+	Force  bool     `flag:"force" usage:"overwrite the existing files"`
+	Name   string   `arg:"name,optional"`
+}
+
+func (p newEndpointPayload) Validate() error {
+	if p.Spec == "" && (p.Name == "" || p.Path == "") {
+		return fmt.Errorf("%w: the name and the -path flag are required without -spec", scaffold.ErrInvalidSpec)
+	}
+
+	return nil
+}
+
+type newEndpointInput struct {
+	Data struct {
+		Spec      scaffold.Spec
+		Layout    scaffold.Layout
+		Root      string
+		Module    string
+		Overwrite bool
+	}
+	Error error
+}
+
+type newEndpointOutput struct {
+	Data struct {
+		Files []string
+	}
+	Error error
+}
+
+type newEndpointCommandDecoder struct {
+	extractor *propre.CommandArgsExtractor[newEndpointPayload]
+}
+
+func (d *newEndpointCommandDecoder) Decode(args []string) newEndpointInput {
+	var input newEndpointInput
+	payload, err := d.extractor.Extract(args)
+	if err != nil {
+		input.Error = err
+		return input
+	}
+
+	input.Data.Spec = scaffold.Spec{Name: payload.Name, Method: payload.Method, Path: payload.Path}
+	if payload.Spec != "" {
+		input.Data.Spec, err = scaffold.LoadSpec(payload.Spec)
+		if err != nil {
+			input.Error = err
+			return input
+		}
+	}
+
+	for _, f := range payload.Fields {
+		field, err := scaffold.ParseField(f)
+		if err != nil {
+			input.Error = err
+			return input
+		}
+
+		input.Data.Spec.Fields = append(input.Data.Spec.Fields, field)
+	}
+
+	input.Data.Root, input.Data.Module, err = scaffold.FindModule(payload.Dir)
+	if err != nil {
+		input.Error = err
+		return input
+	}
+
+	input.Data.Layout = scaffold.DefaultLayout()
+	config := payload.Config
+	if config == "" {
+		config = filepath.Join(input.Data.Root, layoutFile)
+		if _, err := os.Stat(config); errors.Is(err, os.ErrNotExist) {
+			config = ""
+		}
+	}
+
+	if config != "" {
+		input.Data.Layout, err = scaffold.LoadLayout(config)
+		if err != nil {
+			input.Error = err
+			return input
+		}
+	}
+
+	input.Data.Overwrite = payload.Force
+	return input
+}
+
+type newEndpointInteractor struct {
+	write func(root string, files []scaffold.File, overwrite bool) error
+}
+
+func (i *newEndpointInteractor) Handle(ctx context.Context, input newEndpointInput) newEndpointOutput {
+	var output newEndpointOutput
+	if input.Error != nil {
+		output.Error = input.Error
+		return output
+	}
+
+	files, err := scaffold.Generate(input.Data.Spec, input.Data.Layout, input.Data.Module)
+	if err != nil {
+		output.Error = err
+		return output
+	}
+
+	if err := i.write(input.Data.Root, files, input.Data.Overwrite); err != nil {
+		output.Error = err
+		return output
+	}
+
+	for _, f := range files {
+		output.Data.Files = append(output.Data.Files, f.Path)
+	}
+
+	return output
+}
+
+type newEndpointPresenter struct {
+	extractor *propre.CommandArgsExtractor[newEndpointPayload]
+}
+
+func (p *newEndpointPresenter) Present(ctx context.Context, w *propre.CLIWriter, output newEndpointOutput) {
+	switch {
+	case errors.Is(output.Error, flag.ErrHelp):
+		fmt.Fprintln(w.Stderr, "Usage: propre new [flags] <name>")
+		p.extractor.Usage(w.Stderr)
+		w.SetExitCode(propre.ExitUsage)
+	case errors.Is(output.Error, propre.ErrCommandArgsExtraction):
+		fmt.Fprintf(w.Stderr, "propre new: %s\n\nUsage: propre new [flags] <name>\n", output.Error)
+		p.extractor.Usage(w.Stderr)
+		w.SetExitCode(propre.ExitUsage)
+	case errors.Is(output.Error, scaffold.ErrInvalidSpec):
+		fmt.Fprintf(w.Stderr, "propre new: %s\n", output.Error)
+		w.SetExitCode(propre.ExitUsage)
+	case output.Error != nil:
+		fmt.Fprintf(w.Stderr, "propre new: %s\n", output.Error)
+		w.SetExitCode(propre.ExitFailure)
+	default:
+		for _, f := range output.Data.Files {
+			fmt.Fprintf(w, "created %s\n", f)
+		}
+	}
+}
+
+func newEndpointCommand() propre.Command {
+	extractor := propre.NewCommandArgsExtractor[newEndpointPayload]()
+
+	return propre.NewCLIHandler(
+		&newEndpointCommandDecoder{extractor: extractor},
+		&newEndpointInteractor{write: scaffold.Write},
+		&newEndpointPresenter{extractor: extractor},
+	)
+}
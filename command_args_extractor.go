@@ -0,0 +1,301 @@
+package propre
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrCommandArgsExtraction is returned by [CommandArgsExtractor] if its
+	// Extract method failed to parse the command line arguments.
+	ErrCommandArgsExtraction = errors.New("command arguments extraction error")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// CommandArgsExtractor is the CLI counterpart of [RequestPayloadExtractor].
+// It extracts the command line arguments into the fields of the Payload struct
+// thanks to their tags, then validates the payload:
+//   - `flag:"name"` binds the field to the flag -name, the tags `usage:"..."` and
+//     `default:"..."` can be used to document the flag and to set its default value,
+//   - `arg:"name"` binds the field to the next positional argument, in the order
+//     of declaration of the fields. A field of type []string takes all the remaining
+//     positional arguments. The other arguments are required, unless they are
+//     tagged `arg:"name,optional"`, and an optional argument cannot be followed
+//     by a required one.
+//
+// The supported field types are string, bool, the integer and float types,
+// time.Duration and []string. A []string flag can be repeated.
+type CommandArgsExtractor[Payload Validatable] struct {
+	flags []commandFlag
+	args  []commandArg
+}
+
+type commandFlag struct {
+	index        []int
+	name         string
+	usage        string
+	defaultValue string
+	isBool       bool
+}
+
+type commandArg struct {
+	index    []int
+	name     string
+	variadic bool
+	optional bool
+}
+
+// NewCommandArgsExtractor builds a new [CommandArgsExtractor] for the given
+// Payload type, which must be a struct. It panics if a tagged field has an
+// unsupported type, as it is a programming error.
+func NewCommandArgsExtractor[Payload Validatable]() *CommandArgsExtractor[Payload] {
+	payloadType := reflect.TypeOf((*Payload)(nil)).Elem()
+	if payloadType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("propre: %s must be a struct to be extracted from command arguments", payloadType))
+	}
+
+	extractor := &CommandArgsExtractor[Payload]{}
+	for _, field := range reflect.VisibleFields(payloadType) {
+		flagName, isFlag := field.Tag.Lookup("flag")
+		argName, isArg := field.Tag.Lookup("arg")
+		if !isFlag && !isArg {
+			continue
+		}
+
+		if !isSupportedCommandFieldType(field.Type) {
+			panic(fmt.Sprintf("propre: unsupported type %s for command field %s.%s", field.Type, payloadType, field.Name))
+		}
+
+		if isFlag {
+			extractor.flags = append(extractor.flags, commandFlag{
+				index:        field.Index,
+				name:         flagName,
+				usage:        field.Tag.Get("usage"),
+				defaultValue: field.Tag.Get("default"),
+				isBool:       field.Type.Kind() == reflect.Bool,
+			})
+
+			continue
+		}
+
+		argName, option, _ := strings.Cut(argName, ",")
+		variadic := field.Type.Kind() == reflect.Slice
+		optional := variadic || option == "optional"
+		if len(extractor.args) > 0 {
+			previous := extractor.args[len(extractor.args)-1]
+			if previous.variadic {
+				panic(fmt.Sprintf("propre: command argument %s.%s is declared after a variadic one", payloadType, field.Name))
+			}
+
+			if previous.optional && !optional {
+				panic(fmt.Sprintf("propre: required command argument %s.%s is declared after an optional one", payloadType, field.Name))
+			}
+		}
+
+		extractor.args = append(extractor.args, commandArg{
+			index:    field.Index,
+			name:     argName,
+			variadic: variadic,
+			optional: optional,
+		})
+	}
+
+	return extractor
+}
+
+// Extract parses the arguments into the Payload type.
+// If the parsing fails, an error [ErrCommandArgsExtraction] wraps the parsing error,
+// which is [flag.ErrHelp] if the -h or -help flag is given. It is also returned
+// if a positional argument is invalid, missing or unexpected.
+// Then the method Validate of the Payload type is called and its error is returned.
+func (extractor *CommandArgsExtractor[Payload]) Extract(args []string) (Payload, error) {
+	var payload Payload
+	value := reflect.ValueOf(&payload).Elem()
+
+	flagSet := extractor.flagSet(value)
+	err := flagSet.Parse(args)
+	if err != nil {
+		return payload, fmt.Errorf("%w caused by %w", ErrCommandArgsExtraction, err)
+	}
+
+	positional := flagSet.Args()
+	var missing []string
+	for _, arg := range extractor.args {
+		if len(positional) == 0 {
+			if !arg.optional {
+				missing = append(missing, "<"+arg.name+">")
+			}
+
+			continue
+		}
+
+		field := value.FieldByIndex(arg.index)
+		if arg.variadic {
+			for _, p := range positional {
+				err = setCommandField(field, p)
+				if err != nil {
+					return payload, fmt.Errorf("%w caused by invalid argument %s: %w", ErrCommandArgsExtraction, arg.name, err)
+				}
+			}
+
+			positional = nil
+			break
+		}
+
+		err = setCommandField(field, positional[0])
+		if err != nil {
+			return payload, fmt.Errorf("%w caused by invalid argument %s: %w", ErrCommandArgsExtraction, arg.name, err)
+		}
+
+		positional = positional[1:]
+	}
+
+	if len(missing) > 0 {
+		return payload, fmt.Errorf("%w caused by missing arguments %s", ErrCommandArgsExtraction, strings.Join(missing, " "))
+	}
+
+	if len(positional) > 0 {
+		return payload, fmt.Errorf("%w caused by unexpected arguments %q", ErrCommandArgsExtraction, positional)
+	}
+
+	return payload, payload.Validate()
+}
+
+// Usage writes the usage of the arguments and the flags to the given writer.
+func (extractor *CommandArgsExtractor[Payload]) Usage(w io.Writer) {
+	var payload Payload
+	flagSet := extractor.flagSet(reflect.ValueOf(&payload).Elem())
+
+	names := make([]string, 0, len(extractor.args))
+	for _, arg := range extractor.args {
+		name := "<" + arg.name + ">"
+		if arg.variadic {
+			name += "..."
+		} else if arg.optional {
+			name = "[" + name + "]"
+		}
+
+		names = append(names, name)
+	}
+
+	fmt.Fprintf(w, "Arguments: [flags] %s\n\nFlags:\n", strings.Join(names, " "))
+	flagSet.SetOutput(w)
+	flagSet.PrintDefaults()
+}
+
+func (extractor *CommandArgsExtractor[Payload]) flagSet(value reflect.Value) *flag.FlagSet {
+	flagSet := flag.NewFlagSet("", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	for _, f := range extractor.flags {
+		field := value.FieldByIndex(f.index)
+		if f.defaultValue != "" {
+			err := setCommandField(field, f.defaultValue)
+			if err != nil {
+				panic(fmt.Sprintf("propre: invalid default value for flag %s: %s", f.name, err))
+			}
+		}
+
+		flagSet.Var(&commandFieldValue{field: field, isBool: f.isBool}, f.name, f.usage)
+	}
+
+	return flagSet
+}
+
+// commandFieldValue implements flag.Value over a struct field.
+type commandFieldValue struct {
+	field  reflect.Value
+	isBool bool
+	isSet  bool
+}
+
+func (v *commandFieldValue) String() string {
+	if v == nil || !v.field.IsValid() {
+		return ""
+	}
+
+	return fmt.Sprint(v.field.Interface())
+}
+
+func (v *commandFieldValue) Set(s string) error {
+	// The default value of a repeated flag is replaced by the first occurrence.
+	if !v.isSet && v.field.Kind() == reflect.Slice {
+		v.field.SetLen(0)
+	}
+
+	v.isSet = true
+	return setCommandField(v.field, s)
+}
+
+func (v *commandFieldValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+func isSupportedCommandFieldType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+
+	return false
+}
+
+func setCommandField(field reflect.Value, s string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	case reflect.Slice:
+		field.Set(reflect.Append(field, reflect.ValueOf(s).Convert(field.Type().Elem())))
+	}
+
+	return nil
+}
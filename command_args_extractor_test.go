@@ -0,0 +1,196 @@
+package propre_test
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type commandPayload struct {
+	Title    string        `flag:"title" usage:"title of the todo"`
+	Priority int           `flag:"priority" default:"3"`
+	Done     bool          `flag:"done"`
+	Timeout  time.Duration `flag:"timeout" default:"1s"`
+	Tags     []string      `flag:"tag"`
+	List     string        `arg:"list"`
+	Items    []string      `arg:"items"`
+}
+
+func (p commandPayload) Validate() error {
+	if p.List == "" {
+		return errInvalidPayload
+	}
+
+	return nil
+}
+
+type commandArgsTestCase struct {
+	args            []string
+	expectedPayload commandPayload
+	expectedError   error
+}
+
+func TestExtractCommandArgs(t *testing.T) {
+	testCases := map[string]commandArgsTestCase{
+		"flags and arguments": {
+			args: []string{"-title", "New todo", "-priority=1", "-done", "-timeout", "1m", "-tag", "a", "-tag", "b", "groceries", "milk", "eggs"},
+			expectedPayload: commandPayload{
+				Title:    "New todo",
+				Priority: 1,
+				Done:     true,
+				Timeout:  time.Minute,
+				Tags:     []string{"a", "b"},
+				List:     "groceries",
+				Items:    []string{"milk", "eggs"},
+			},
+		},
+		"default values": {
+			args: []string{"groceries"},
+			expectedPayload: commandPayload{
+				Priority: 3,
+				Timeout:  time.Second,
+				List:     "groceries",
+			},
+		},
+		"invalid flag value": {
+			args:          []string{"-priority", "high", "groceries"},
+			expectedError: propre.ErrCommandArgsExtraction,
+		},
+		"unknown flag": {
+			args:          []string{"-unknown", "groceries"},
+			expectedError: propre.ErrCommandArgsExtraction,
+		},
+		"help flag": {
+			args:          []string{"-h"},
+			expectedError: flag.ErrHelp,
+		},
+		"missing argument": {
+			args:          []string{"-title", "New todo"},
+			expectedError: propre.ErrCommandArgsExtraction,
+		},
+		"invalid payload": {
+			args:          []string{"-title", "New todo", ""},
+			expectedError: errInvalidPayload,
+		},
+	}
+
+	extractor := propre.NewCommandArgsExtractor[commandPayload]()
+	for scenario, testCase := range testCases {
+		t.Run(scenario, func(t *testing.T) {
+			payload, err := extractor.Extract(testCase.args)
+			if testCase.expectedError != nil {
+				if !errors.Is(err, testCase.expectedError) {
+					t.Fatalf("unexpected error, expected %v, got %v", testCase.expectedError, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !reflect.DeepEqual(payload, testCase.expectedPayload) {
+				t.Fatalf("unexpected payload, expected %#v, got %#v", testCase.expectedPayload, payload)
+			}
+		})
+	}
+}
+
+type tooManyArgsPayload struct {
+	Name string `arg:"name"`
+}
+
+func (p tooManyArgsPayload) Validate() error {
+	return nil
+}
+
+func TestExtractCommandArgsRejectsUnexpectedArguments(t *testing.T) {
+	extractor := propre.NewCommandArgsExtractor[tooManyArgsPayload]()
+	_, err := extractor.Extract([]string{"first", "second"})
+	if !errors.Is(err, propre.ErrCommandArgsExtraction) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type optionalArgsPayload struct {
+	Source string `arg:"source"`
+	Target string `arg:"target,optional"`
+}
+
+func (p optionalArgsPayload) Validate() error {
+	return nil
+}
+
+func TestExtractCommandArgsWithOptionalArguments(t *testing.T) {
+	extractor := propre.NewCommandArgsExtractor[optionalArgsPayload]()
+
+	payload, err := extractor.Extract([]string{"a.txt"})
+	if err != nil || payload != (optionalArgsPayload{Source: "a.txt"}) {
+		t.Fatalf("unexpected payload %#v and error %v", payload, err)
+	}
+
+	_, err = extractor.Extract(nil)
+	if !errors.Is(err, propre.ErrCommandArgsExtraction) || !strings.Contains(err.Error(), "missing arguments <source>") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var usage strings.Builder
+	extractor.Usage(&usage)
+	if !strings.Contains(usage.String(), "<source> [<target>]") {
+		t.Fatalf("the optional argument should be documented, got:\n%s", usage.String())
+	}
+}
+
+type requiredAfterOptionalPayload struct {
+	Source string `arg:"source,optional"`
+	Target string `arg:"target"`
+}
+
+func (p requiredAfterOptionalPayload) Validate() error {
+	return nil
+}
+
+func TestNewCommandArgsExtractorPanicsWithARequiredArgumentAfterAnOptionalOne(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	propre.NewCommandArgsExtractor[requiredAfterOptionalPayload]()
+}
+
+type unsupportedCommandPayload struct {
+	Values map[string]string `flag:"values"`
+}
+
+func (p unsupportedCommandPayload) Validate() error {
+	return nil
+}
+
+func TestNewCommandArgsExtractorPanicsWithUnsupportedTypes(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	propre.NewCommandArgsExtractor[unsupportedCommandPayload]()
+}
+
+func TestCommandArgsExtractorUsage(t *testing.T) {
+	var usage strings.Builder
+	propre.NewCommandArgsExtractor[commandPayload]().Usage(&usage)
+
+	for _, expected := range []string{"<list> <items>...", "-title", "title of the todo", "-priority", "(default 3)"} {
+		if !strings.Contains(usage.String(), expected) {
+			t.Fatalf("usage does not contain %q:\n%s", expected, usage.String())
+		}
+	}
+}
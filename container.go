@@ -0,0 +1,637 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrMissingProvider is returned by [Container] when a type has no provider.
+	ErrMissingProvider = errors.New("missing provider")
+
+	// ErrDependencyCycle is returned by [Container.Start] when providers depend on each other.
+	ErrDependencyCycle = errors.New("dependency cycle")
+
+	// ErrScopeMismatch is returned by [Container.Start] when a singleton depends on a
+	// request scoped type, which would outlive its request.
+	ErrScopeMismatch = errors.New("singleton depending on a request scoped type")
+
+	// ErrContainerNotStarted is returned by [Resolve] when the container is not started.
+	ErrContainerNotStarted = errors.New("container not started")
+
+	// ErrNoRequestScope is returned by [Resolve] when a request scoped type is resolved
+	// with a context without request scope, see [Container.WithRequestScope].
+	ErrNoRequestScope = errors.New("no request scope")
+
+	// ErrDependencyConstruction wraps the errors returned by the constructors of the providers.
+	ErrDependencyConstruction = errors.New("dependency construction error")
+
+	// ErrLifecycleHook wraps the errors returned by the Start and Stop hooks.
+	ErrLifecycleHook = errors.New("lifecycle hook error")
+
+	// ErrResolveInProvider is returned by [Resolve] when it is called with the
+	// context given to a constructor or a Start hook, which would deadlock. The
+	// constructor must take the dependency as a parameter instead.
+	ErrResolveInProvider = errors.New("resolve called by a provider")
+
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Starter is implemented by the singletons to be started by [Container.Start],
+// like a connection pool or a message worker pool.
+type Starter interface {
+	Start(context.Context) error
+}
+
+// Stopper is implemented by the singletons to be stopped by [Container.Stop].
+type Stopper interface {
+	Stop(context.Context) error
+}
+
+type providerScope int
+
+const (
+	singletonScope providerScope = iota
+	requestScope
+)
+
+type provider struct {
+	typ         reflect.Type
+	scope       providerScope
+	constructor reflect.Value
+	params      []reflect.Type
+	returnsErr  bool
+	start       func(ctx context.Context, value any) error
+	stop        func(ctx context.Context, value any) error
+}
+
+// dependencies returns the types the constructor depends on.
+func (p *provider) dependencies() []reflect.Type {
+	deps := make([]reflect.Type, 0, len(p.params))
+	for _, param := range p.params {
+		if param != contextType {
+			deps = append(deps, param)
+		}
+	}
+
+	return deps
+}
+
+// startHook calls the OnStart hook of the value, or its Start method if it is a [Starter].
+func (p *provider) startHook(ctx context.Context, value any) error {
+	if p.start != nil {
+		return p.start(ctx, value)
+	}
+
+	if starter, ok := value.(Starter); ok {
+		return starter.Start(ctx)
+	}
+
+	return nil
+}
+
+// stopHook calls the OnStop hook of the value, or its Stop method if it is a [Stopper].
+func (p *provider) stopHook(ctx context.Context, value any) error {
+	if p.stop != nil {
+		return p.stop(ctx, value)
+	}
+
+	if stopper, ok := value.(Stopper); ok {
+		return stopper.Stop(ctx)
+	}
+
+	return nil
+}
+
+// build calls the constructor with the context and the resolved dependencies.
+func (p *provider) build(ctx context.Context, resolve func(reflect.Type) (reflect.Value, error)) (reflect.Value, error) {
+	args := make([]reflect.Value, len(p.params))
+	for i, param := range p.params {
+		if param == contextType {
+			args[i] = reflect.ValueOf(&ctx).Elem()
+			continue
+		}
+
+		arg, err := resolve(param)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		args[i] = arg
+	}
+
+	results := p.constructor.Call(args)
+	if p.returnsErr && !results[1].IsNil() {
+		return reflect.Value{}, fmt.Errorf("%w of %s caused by %w", ErrDependencyConstruction, p.typ, results[1].Interface().(error))
+	}
+
+	value := reflect.New(p.typ).Elem()
+	value.Set(results[0])
+
+	return value, nil
+}
+
+// ProviderOpts is the alias for the options of [Provide].
+type ProviderOpts func(p *provider)
+
+// WithRequestScope is a [Provide] option to build a value per request instead of
+// a single value shared by all the requests, like a unit of work.
+// The request scoped values have no Start hook, their Stop hooks are called when
+// their request scope is released, see [Container.ReleaseRequestScope].
+func WithRequestScope() ProviderOpts {
+	return func(p *provider) {
+		p.scope = requestScope
+	}
+}
+
+// OnStart is a [Provide] option to call a hook on the singleton when the container
+// starts, after the hooks of its dependencies. It replaces the Start method of a [Starter].
+func OnStart[T any](hook func(ctx context.Context, value T) error) ProviderOpts {
+	return func(p *provider) {
+		p.start = func(ctx context.Context, value any) error {
+			return hook(ctx, value.(T))
+		}
+	}
+}
+
+// OnStop is a [Provide] option to call a hook on the value when the container
+// stops, or when its request scope is released for a request scoped value, before
+// the hooks of its dependencies. It replaces the Stop method of a [Stopper].
+func OnStop[T any](hook func(ctx context.Context, value T) error) ProviderOpts {
+	return func(p *provider) {
+		p.stop = func(ctx context.Context, value any) error {
+			return hook(ctx, value.(T))
+		}
+	}
+}
+
+// Container is the composition root of an application. It builds the
+// repositories, the use cases, the decoders and the presenters from their
+// providers, registered with [Provide] and [Supply] before [Container.Start].
+//
+// The dependencies of a provider are the parameters of its constructor, so the
+// whole graph is checked when the container starts: the missing providers, the
+// cycles and the singletons depending on request scoped types are reported
+// before anything is built. Then the singletons are built and started in the
+// order of their dependencies, and [Container.Stop] stops them in reverse order.
+//
+// The request scoped values are built once per request, in the scope stored in the
+// context by [Container.WithRequestScope], which [HandleHTTP] does for each request.
+type Container struct {
+	mu             sync.RWMutex
+	providers      map[reflect.Type]*provider
+	order          []reflect.Type
+	required       []reflect.Type
+	singletons     map[reflect.Type]reflect.Value
+	started        []reflect.Type
+	running        bool
+	errorPresenter Presenter[error, http.ResponseWriter]
+}
+
+// ContainerOpts is the alias for the [Container] builder options.
+type ContainerOpts func(c *Container)
+
+// WithContainerErrorPresenter is a [Container] option to customize the responses
+// of the handlers registered with [HandleHTTP] when their components cannot be
+// resolved. The default presenter sends a plain text response with the status code 500.
+func WithContainerErrorPresenter(presenter Presenter[error, http.ResponseWriter]) ContainerOpts {
+	return func(c *Container) {
+		c.errorPresenter = presenter
+	}
+}
+
+// NewContainer builds an empty Container.
+func NewContainer(opts ...ContainerOpts) *Container {
+	container := &Container{
+		providers:      make(map[reflect.Type]*provider),
+		singletons:     make(map[reflect.Type]reflect.Value),
+		errorPresenter: containerErrorPresenter{},
+	}
+
+	for _, opt := range opts {
+		opt(container)
+	}
+
+	return container
+}
+
+// Provide registers the constructor of the type T. The constructor is a function
+// returning a value assignable to T, and optionally an error. Its parameters are
+// resolved from the container, except a [context.Context] parameter which receives
+// the context given to [Container.Start] for the singletons, and the request
+// context for the request scoped values:
+//
+//	propre.Provide[*sql.DB](container, openDB)
+//	propre.Provide[TodoRepository](container, NewPostgresTodoRepository)
+//	propre.Provide[propre.UseCaseHandler[CreateTodoInput, CreateTodoOutput]](container, NewCreateTodoInteractor)
+//
+// The values are singletons unless [WithRequestScope] is given. A singleton
+// implementing [Starter] or [Stopper] is started and stopped with the container,
+// a request scoped value implementing Stopper is stopped with its request scope.
+//
+// It panics if the constructor is not a function returning T, if T already has a
+// provider, or if the container is started, as it is a programming error.
+func Provide[T any](c *Container, constructor any, opts ...ProviderOpts) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		panic(fmt.Sprintf("propre: the provider of %s must be a function, got %T", typ, constructor))
+	}
+
+	fnType := fn.Type()
+	returnsErr := fnType.NumOut() == 2 && fnType.Out(1) == errorType
+	if fnType.NumOut() != 1 && !returnsErr || fnType.NumOut() == 0 || !fnType.Out(0).AssignableTo(typ) || fnType.IsVariadic() {
+		panic(fmt.Sprintf("propre: the provider of %s must return %s and an optional error, got %s", typ, typ, fnType))
+	}
+
+	p := &provider{
+		typ:         typ,
+		constructor: fn,
+		returnsErr:  returnsErr,
+	}
+
+	for i := range fnType.NumIn() {
+		p.params = append(p.params, fnType.In(i))
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	c.register(p)
+}
+
+// Supply registers a value of the type T, like a configuration, see [Provide].
+func Supply[T any](c *Container, value T, opts ...ProviderOpts) {
+	Provide[T](c, func() T { return value }, opts...)
+}
+
+func (c *Container) register(p *provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		panic(fmt.Sprintf("propre: the provider of %s is registered in a started container", p.typ))
+	}
+
+	if _, exists := c.providers[p.typ]; exists {
+		panic(fmt.Sprintf("propre: %s already has a provider", p.typ))
+	}
+
+	c.providers[p.typ] = p
+	c.order = append(c.order, p.typ)
+}
+
+// require records types which must have a provider when the container starts.
+func (c *Container) require(types ...reflect.Type) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.required = append(c.required, types...)
+}
+
+// Start checks the dependency graph, then builds the singletons and calls
+// their Start hooks in the order of their dependencies. If a constructor or a
+// hook fails, the singletons already started are stopped and the error is returned.
+func (c *Container) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return nil
+	}
+
+	order, err := c.sortProviders()
+	if err != nil {
+		return err
+	}
+
+	for _, typ := range order {
+		p := c.providers[typ]
+		if p.scope != singletonScope {
+			continue
+		}
+
+		value, err := p.build(c.providerContext(ctx), func(dep reflect.Type) (reflect.Value, error) {
+			return c.singletons[dep], nil
+		})
+
+		if err == nil {
+			if err = p.startHook(c.providerContext(ctx), value.Interface()); err != nil {
+				err = fmt.Errorf("%w: start of %s caused by %w", ErrLifecycleHook, typ, err)
+			}
+		}
+
+		if err != nil {
+			return errors.Join(err, c.stop(ctx, c.takeSingletons()))
+		}
+
+		c.singletons[typ] = value
+		c.started = append(c.started, typ)
+	}
+
+	c.running = true
+	return nil
+}
+
+// Stop calls the Stop hooks of the singletons in the reverse order of their
+// dependencies. All the hooks are called, their errors are joined.
+func (c *Container) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	c.running = false
+	hooks := c.takeSingletons()
+	c.mu.Unlock()
+
+	return c.stop(ctx, hooks)
+}
+
+// takeSingletons returns the Stop hooks of the started singletons and forgets
+// them. It is called with the lock of the container held.
+func (c *Container) takeSingletons() []func(ctx context.Context) error {
+	hooks := c.stopHooks(c.started, c.singletons)
+	c.started = nil
+	c.singletons = make(map[reflect.Type]reflect.Value)
+
+	return hooks
+}
+
+// stopHooks returns the Stop hooks of the given values in the reverse order of
+// their construction. It is called with the lock of the container held, so the
+// hooks can be called without it.
+func (c *Container) stopHooks(built []reflect.Type, values map[reflect.Type]reflect.Value) []func(ctx context.Context) error {
+	hooks := make([]func(ctx context.Context) error, 0, len(built))
+	for i := len(built) - 1; i >= 0; i-- {
+		typ := built[i]
+		p, value := c.providers[typ], values[typ].Interface()
+		hooks = append(hooks, func(ctx context.Context) error {
+			if err := p.stopHook(ctx, value); err != nil {
+				return fmt.Errorf("%w: stop of %s caused by %w", ErrLifecycleHook, typ, err)
+			}
+
+			return nil
+		})
+	}
+
+	return hooks
+}
+
+// stop calls the hooks with the context given to the providers, so a hook
+// calling [Resolve] gets [ErrResolveInProvider].
+func (c *Container) stop(ctx context.Context, hooks []func(ctx context.Context) error) error {
+	var errs []error
+	for _, hook := range hooks {
+		errs = append(errs, hook(c.providerContext(ctx)))
+	}
+
+	return errors.Join(errs...)
+}
+
+// sortProviders checks the graph and returns the types in the order of their
+// dependencies.
+func (c *Container) sortProviders() ([]reflect.Type, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	states := make(map[reflect.Type]int, len(c.providers))
+	order := make([]reflect.Type, 0, len(c.providers))
+	var path []reflect.Type
+
+	var visit func(typ reflect.Type, dependent *provider) error
+	visit = func(typ reflect.Type, dependent *provider) error {
+		p, ok := c.providers[typ]
+		if !ok {
+			if dependent == nil {
+				return fmt.Errorf("%w for %s", ErrMissingProvider, typ)
+			}
+
+			return fmt.Errorf("%w for %s, required by %s", ErrMissingProvider, typ, dependent.typ)
+		}
+
+		if dependent != nil && dependent.scope == singletonScope && p.scope == requestScope {
+			return fmt.Errorf("%w: %s depends on %s", ErrScopeMismatch, dependent.typ, typ)
+		}
+
+		switch states[typ] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := []string{typ.String()}
+			for i := len(path) - 1; i >= 0 && path[i] != typ; i-- {
+				cycle = append([]string{path[i].String()}, cycle...)
+			}
+
+			return fmt.Errorf("%w: %s -> %s", ErrDependencyCycle, typ, strings.Join(cycle, " -> "))
+		}
+
+		states[typ] = visiting
+		path = append(path, typ)
+		for _, dep := range p.dependencies() {
+			if err := visit(dep, p); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[typ] = visited
+		order = append(order, typ)
+
+		return nil
+	}
+
+	for _, typ := range append(c.order, c.required...) {
+		if err := visit(typ, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+type requestScopeKey struct{}
+
+type providerContextKey struct{}
+
+// providerContext returns the context given to the constructors and the
+// lifecycle hooks. The constructors and the Start hooks are called with the lock
+// of the container or of a request scope held, so they cannot call [Resolve].
+func (c *Container) providerContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, providerContextKey{}, c)
+}
+
+type requestScopeValues struct {
+	container *Container
+	mu        sync.Mutex
+	values    map[reflect.Type]reflect.Value
+	built     []reflect.Type
+}
+
+// WithRequestScope returns a context holding a new request scope, the request
+// scoped values resolved with it are built once and shared until the scope is
+// released with [Container.ReleaseRequestScope].
+func (c *Container) WithRequestScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestScopeKey{}, &requestScopeValues{
+		container: c,
+		values:    make(map[reflect.Type]reflect.Value),
+	})
+}
+
+// Resolve returns the value of the type T. The singletons are available once
+// the container is started, the request scoped values require a context
+// returned by [Container.WithRequestScope]. A provider of an interface type
+// returning nil resolves to the zero value of T.
+func Resolve[T any](ctx context.Context, c *Container) (T, error) {
+	var zero T
+	value, err := c.resolve(ctx, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, err
+	}
+
+	typed, _ := value.Interface().(T)
+	return typed, nil
+}
+
+func (c *Container) resolve(ctx context.Context, typ reflect.Type) (reflect.Value, error) {
+	if ctx.Value(providerContextKey{}) == c {
+		return reflect.Value{}, fmt.Errorf("%w to resolve %s", ErrResolveInProvider, typ)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.running {
+		return reflect.Value{}, ErrContainerNotStarted
+	}
+
+	p, ok := c.providers[typ]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w for %s", ErrMissingProvider, typ)
+	}
+
+	if p.scope == singletonScope {
+		return c.singletons[typ], nil
+	}
+
+	scope, ok := ctx.Value(requestScopeKey{}).(*requestScopeValues)
+	if !ok || scope.container != c {
+		return reflect.Value{}, fmt.Errorf("%w to resolve %s", ErrNoRequestScope, typ)
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+
+	return c.resolveInScope(ctx, scope, typ)
+}
+
+// resolveInScope resolves a type in a request scope, the graph being checked
+// by [Container.Start] there is no cycle.
+func (c *Container) resolveInScope(ctx context.Context, scope *requestScopeValues, typ reflect.Type) (reflect.Value, error) {
+	p := c.providers[typ]
+	if p.scope == singletonScope {
+		return c.singletons[typ], nil
+	}
+
+	if value, ok := scope.values[typ]; ok {
+		return value, nil
+	}
+
+	value, err := p.build(c.providerContext(ctx), func(dep reflect.Type) (reflect.Value, error) {
+		return c.resolveInScope(ctx, scope, dep)
+	})
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	scope.values[typ] = value
+	scope.built = append(scope.built, typ)
+
+	return value, nil
+}
+
+// ReleaseRequestScope calls the Stop hooks of the request scoped values built in
+// the request scope of the context, in the reverse order of their construction,
+// and empties the scope. All the hooks are called, their errors are joined.
+// [HandleHTTP] releases the request scope of each request once it is handled.
+func (c *Container) ReleaseRequestScope(ctx context.Context) error {
+	scope, ok := ctx.Value(requestScopeKey{}).(*requestScopeValues)
+	if !ok || scope.container != c {
+		return nil
+	}
+
+	c.mu.RLock()
+	scope.mu.Lock()
+	hooks := c.stopHooks(scope.built, scope.values)
+	scope.values = make(map[reflect.Type]reflect.Value)
+	scope.built = nil
+	scope.mu.Unlock()
+	c.mu.RUnlock()
+
+	return c.stop(ctx, hooks)
+}
+
+// HandleHTTP registers an [HTTPHandler] on the mux, built from the request
+// decoder, the use case handler and the presenter resolved from the container:
+//
+//	propre.Provide[propre.RequestDecoder[CreateTodoInput]](container, NewCreateTodoRequestDecoder)
+//	propre.Provide[propre.UseCaseHandler[CreateTodoInput, CreateTodoOutput]](container, NewCreateTodoInteractor)
+//	propre.Provide[propre.Presenter[CreateTodoOutput, http.ResponseWriter]](container, NewCreateTodoPresenter)
+//	propre.HandleHTTP[CreateTodoInput, CreateTodoOutput](container, mux, "POST /todos")
+//
+// Each request gets its own request scope, so the components can depend on request
+// scoped values, released once the request is handled. The errors of their Stop
+// hooks are dropped as the response is already sent. The three components must
+// have a provider when the container starts.
+func HandleHTTP[Input, Output any](c *Container, mux *http.ServeMux, pattern string) {
+	c.require(
+		reflect.TypeOf((*RequestDecoder[Input])(nil)).Elem(),
+		reflect.TypeOf((*UseCaseHandler[Input, Output])(nil)).Elem(),
+		reflect.TypeOf((*Presenter[Output, http.ResponseWriter])(nil)).Elem(),
+	)
+
+	mux.HandleFunc(pattern, func(rw http.ResponseWriter, req *http.Request) {
+		ctx := c.WithRequestScope(req.Context())
+		defer c.ReleaseRequestScope(context.WithoutCancel(ctx))
+
+		handler, err := resolveHTTPHandler[Input, Output](ctx, c)
+		if err != nil {
+			c.errorPresenter.Present(ctx, rw, err)
+			return
+		}
+
+		handler.ServeHTTP(rw, req.WithContext(ctx))
+	})
+}
+
+func resolveHTTPHandler[Input, Output any](ctx context.Context, c *Container) (*HTTPHandler[Input, Output], error) {
+	decoder, err := Resolve[RequestDecoder[Input]](ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	useCaseHandler, err := Resolve[UseCaseHandler[Input, Output]](ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	presenter, err := Resolve[Presenter[Output, http.ResponseWriter]](ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHTTPHandler(decoder, useCaseHandler, presenter), nil
+}
+
+type containerErrorPresenter struct{}
+
+func (p containerErrorPresenter) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
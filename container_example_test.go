@@ -0,0 +1,52 @@
+package propre_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+// In this example the components of the HTTPHandler example are wired by a
+// container instead of by hand. Each component is provided as the interface
+// expected by HTTPHandler, then HandleHTTP registers the route on the mux.
+//
+// The graph is checked when the container starts: a missing provider or a
+// dependency cycle would be returned by Start before any request is served.
+func ExampleContainer() {
+	container := propre.NewContainer()
+	propre.Provide[propre.RequestDecoder[CreateTodoInput]](container, func() *CreateTodoRequestDecoder[CreateTodoInput] {
+		return &CreateTodoRequestDecoder[CreateTodoInput]{}
+	})
+
+	propre.Provide[propre.UseCaseHandler[CreateTodoInput, CreateTodoOutput]](container, func() *CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput] {
+		return &CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput]{}
+	})
+
+	propre.Provide[propre.Presenter[CreateTodoOutput, http.ResponseWriter]](container, func() *CreateTodoPresenter[CreateTodoOutput] {
+		return &CreateTodoPresenter[CreateTodoOutput]{}
+	})
+
+	mux := http.NewServeMux()
+	propre.HandleHTTP[CreateTodoInput, CreateTodoOutput](container, mux, "POST /todos")
+
+	ctx := context.Background()
+	if err := container.Start(ctx); err != nil {
+		panic(err)
+	}
+
+	defer container.Stop(ctx)
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/todos", strings.NewReader(`{"title":"New todo title"}`))
+	mux.ServeHTTP(rw, req)
+
+	fmt.Println(rw.Code)
+	fmt.Print(rw.Body.String())
+	// Output:
+	// 201
+	// {"data":{"id":42,"title":"New todo title"}}
+}
@@ -0,0 +1,408 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type containerConfig struct {
+	DSN string
+}
+
+type containerDB struct {
+	dsn    string
+	events *[]string
+}
+
+func (db *containerDB) Start(ctx context.Context) error {
+	*db.events = append(*db.events, "start db")
+	return nil
+}
+
+func (db *containerDB) Stop(ctx context.Context) error {
+	*db.events = append(*db.events, "stop db")
+	return nil
+}
+
+type containerRepository struct {
+	db *containerDB
+}
+
+type containerUnitOfWork struct {
+	id int
+}
+
+type containerCycleA struct{}
+
+type containerCycleB struct{}
+
+type containerCycleC struct{}
+
+func TestContainerStartsAndStopsTheSingletonsInOrder(t *testing.T) {
+	var events []string
+	container := propre.NewContainer()
+
+	propre.Provide[*containerRepository](container, func(db *containerDB) *containerRepository {
+		events = append(events, "build repository")
+		return &containerRepository{db: db}
+	}, propre.OnStart(func(ctx context.Context, r *containerRepository) error {
+		events = append(events, "start repository")
+		return nil
+	}), propre.OnStop(func(ctx context.Context, r *containerRepository) error {
+		events = append(events, "stop repository")
+		return errors.New("stop error")
+	}))
+
+	propre.Provide[*containerDB](container, func(ctx context.Context, config containerConfig) (*containerDB, error) {
+		events = append(events, "build db")
+		return &containerDB{dsn: config.DSN, events: &events}, nil
+	})
+
+	propre.Supply(container, containerConfig{DSN: "postgres://"})
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	repository, err := propre.Resolve[*containerRepository](context.Background(), container)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	db, err := propre.Resolve[*containerDB](context.Background(), container)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if repository.db != db || db.dsn != "postgres://" {
+		t.Fatalf("the singletons should be shared, got %p and %p", repository.db, db)
+	}
+
+	err = container.Stop(context.Background())
+	if !errors.Is(err, propre.ErrLifecycleHook) || !strings.Contains(err.Error(), "stop error") {
+		t.Fatalf("the stop errors should be returned, got %v", err)
+	}
+
+	expected := []string{"build db", "start db", "build repository", "start repository", "stop repository", "stop db"}
+	if strings.Join(events, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("expected the events %q, got %q", expected, events)
+	}
+}
+
+func TestContainerChecksTheGraphAtStartup(t *testing.T) {
+	type testCase struct {
+		provide         func(c *propre.Container)
+		expectedErr     error
+		expectedMessage string
+	}
+
+	testCases := map[string]testCase{
+		"a dependency has no provider": {
+			provide: func(c *propre.Container) {
+				propre.Provide[*containerRepository](c, func(db *containerDB) *containerRepository { return nil })
+			},
+			expectedErr:     propre.ErrMissingProvider,
+			expectedMessage: "missing provider for *propre_test.containerDB, required by *propre_test.containerRepository",
+		},
+		"the providers depend on each other": {
+			provide: func(c *propre.Container) {
+				propre.Provide[containerCycleA](c, func(containerCycleB) containerCycleA { return containerCycleA{} })
+				propre.Provide[containerCycleB](c, func(containerCycleC) containerCycleB { return containerCycleB{} })
+				propre.Provide[containerCycleC](c, func(containerCycleA) containerCycleC { return containerCycleC{} })
+			},
+			expectedErr:     propre.ErrDependencyCycle,
+			expectedMessage: "propre_test.containerCycleA -> propre_test.containerCycleB -> propre_test.containerCycleC -> propre_test.containerCycleA",
+		},
+		"a singleton depends on a request scoped type": {
+			provide: func(c *propre.Container) {
+				propre.Provide[*containerUnitOfWork](c, func() *containerUnitOfWork { return nil }, propre.WithRequestScope())
+				propre.Provide[*containerRepository](c, func(*containerUnitOfWork) *containerRepository { return nil })
+			},
+			expectedErr:     propre.ErrScopeMismatch,
+			expectedMessage: "*propre_test.containerRepository depends on *propre_test.containerUnitOfWork",
+		},
+		"a handler component has no provider": {
+			provide: func(c *propre.Container) {
+				propre.HandleHTTP[string, string](c, http.NewServeMux(), "/")
+			},
+			expectedErr:     propre.ErrMissingProvider,
+			expectedMessage: "missing provider for propre.RequestDecoder[string]",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			container := propre.NewContainer()
+			tc.provide(container)
+
+			err := container.Start(context.Background())
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+
+			if !strings.Contains(err.Error(), tc.expectedMessage) {
+				t.Fatalf("expected the message %q, got %q", tc.expectedMessage, err.Error())
+			}
+		})
+	}
+}
+
+func TestContainerStopsTheStartedSingletonsWhenTheStartupFails(t *testing.T) {
+	var events []string
+	container := propre.NewContainer()
+	propre.Provide[*containerDB](container, func() *containerDB {
+		return &containerDB{events: &events}
+	})
+
+	propre.Provide[*containerRepository](container, func(db *containerDB) (*containerRepository, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	err := container.Start(context.Background())
+	if !errors.Is(err, propre.ErrDependencyConstruction) || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected the construction error, got %v", err)
+	}
+
+	if strings.Join(events, ", ") != "start db, stop db" {
+		t.Fatalf("the started singletons should be stopped, got %q", events)
+	}
+
+	if _, err := propre.Resolve[*containerDB](context.Background(), container); !errors.Is(err, propre.ErrContainerNotStarted) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrContainerNotStarted, err)
+	}
+}
+
+func TestContainerBuildsTheRequestScopedValuesOncePerRequest(t *testing.T) {
+	built := 0
+	container := propre.NewContainer()
+	propre.Provide[*containerUnitOfWork](container, func() *containerUnitOfWork {
+		built++
+		return &containerUnitOfWork{id: built}
+	}, propre.WithRequestScope())
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if _, err := propre.Resolve[*containerUnitOfWork](context.Background(), container); !errors.Is(err, propre.ErrNoRequestScope) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrNoRequestScope, err)
+	}
+
+	first := container.WithRequestScope(context.Background())
+	a, _ := propre.Resolve[*containerUnitOfWork](first, container)
+	b, _ := propre.Resolve[*containerUnitOfWork](first, container)
+
+	second := container.WithRequestScope(context.Background())
+	c, _ := propre.Resolve[*containerUnitOfWork](second, container)
+
+	if a != b || a.id != 1 || c.id != 2 {
+		t.Fatalf("expected one value per request, got %d, %d and %d", a.id, b.id, c.id)
+	}
+}
+
+func TestContainerReleasesTheRequestScopedValues(t *testing.T) {
+	var stopped []int
+	built := 0
+	container := propre.NewContainer()
+	propre.Provide[*containerUnitOfWork](container, func() *containerUnitOfWork {
+		built++
+		return &containerUnitOfWork{id: built}
+	}, propre.WithRequestScope(), propre.OnStop(func(ctx context.Context, u *containerUnitOfWork) error {
+		stopped = append(stopped, u.id)
+		return nil
+	}))
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	ctx := container.WithRequestScope(context.Background())
+	propre.Resolve[*containerUnitOfWork](ctx, container)
+	if err := container.ReleaseRequestScope(ctx); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if err := container.ReleaseRequestScope(ctx); err != nil || len(stopped) != 1 || stopped[0] != 1 {
+		t.Fatalf("the request scoped value should be stopped once, got %v and %v", stopped, err)
+	}
+
+	if u, _ := propre.Resolve[*containerUnitOfWork](ctx, container); u.id != 2 {
+		t.Fatalf("a released request scope should build new values, got %d", u.id)
+	}
+}
+
+func TestContainerStopHookCallingResolve(t *testing.T) {
+	container := propre.NewContainer()
+	propre.Supply(container, containerConfig{DSN: "postgres://"})
+	propre.Provide[*containerUnitOfWork](container, func() *containerUnitOfWork {
+		return &containerUnitOfWork{}
+	}, propre.OnStop(func(ctx context.Context, u *containerUnitOfWork) error {
+		_, err := propre.Resolve[containerConfig](ctx, container)
+		return err
+	}))
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- container.Stop(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, propre.ErrResolveInProvider) {
+			t.Fatalf("expected the error %v, got %v", propre.ErrResolveInProvider, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the Stop hook calling Resolve should not deadlock")
+	}
+}
+
+type containerClock interface {
+	Now() string
+}
+
+func TestResolveAProviderReturningANilInterface(t *testing.T) {
+	container := propre.NewContainer()
+	propre.Provide[containerClock](container, func() containerClock {
+		return nil
+	})
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	clock, err := propre.Resolve[containerClock](context.Background(), container)
+	if err != nil || clock != nil {
+		t.Fatalf("expected a nil value and no error, got %v and %v", clock, err)
+	}
+}
+
+func TestResolveFromAProvider(t *testing.T) {
+	type testCase struct {
+		opts []propre.ProviderOpts
+	}
+
+	testCases := map[string]testCase{
+		"singleton": {},
+		"request scoped": {
+			opts: []propre.ProviderOpts{propre.WithRequestScope()},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			container := propre.NewContainer()
+			propre.Supply(container, containerConfig{DSN: "postgres://"})
+			propre.Provide[*containerUnitOfWork](container, func(ctx context.Context) (*containerUnitOfWork, error) {
+				_, err := propre.Resolve[containerConfig](ctx, container)
+				return &containerUnitOfWork{}, err
+			}, tc.opts...)
+
+			err := container.Start(context.Background())
+			if err == nil {
+				_, err = propre.Resolve[*containerUnitOfWork](container.WithRequestScope(context.Background()), container)
+			}
+
+			if !errors.Is(err, propre.ErrResolveInProvider) {
+				t.Fatalf("expected the error %v, got %v", propre.ErrResolveInProvider, err)
+			}
+		})
+	}
+}
+
+func TestProvidePanicsWithAnInvalidConstructor(t *testing.T) {
+	testCases := map[string]any{
+		"not a function":         "value",
+		"wrong return type":      func() int { return 0 },
+		"no return value":        func() {},
+		"second value not error": func() (*containerDB, int) { return nil, 0 },
+	}
+
+	for name, constructor := range testCases {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("Provide should panic")
+				}
+			}()
+
+			propre.Provide[*containerDB](propre.NewContainer(), constructor)
+		})
+	}
+}
+
+type containerUseCase struct {
+	unitOfWork *containerUnitOfWork
+}
+
+func (u *containerUseCase) Handle(ctx context.Context, input string) string {
+	return fmt.Sprintf("%s in unit of work %d", input, u.unitOfWork.id)
+}
+
+type containerQueryDecoder struct{}
+
+func (d containerQueryDecoder) Decode(req *http.Request) string {
+	return req.URL.Query().Get("q")
+}
+
+type containerTextPresenter struct{}
+
+func (p containerTextPresenter) Present(ctx context.Context, rw http.ResponseWriter, output string) {
+	rw.Write([]byte(output))
+}
+
+type containerErrorPresenter struct{}
+
+func (p containerErrorPresenter) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	rw.WriteHeader(http.StatusServiceUnavailable)
+	rw.Write([]byte(err.Error()))
+}
+
+func TestHandleHTTPResolvesTheComponentsForEachRequest(t *testing.T) {
+	container := propre.NewContainer(propre.WithContainerErrorPresenter(containerErrorPresenter{}))
+	mux := http.NewServeMux()
+
+	built := 0
+	propre.Provide[*containerUnitOfWork](container, func(ctx context.Context) (*containerUnitOfWork, error) {
+		built++
+		if built > 1 {
+			return nil, errors.New("no more connections")
+		}
+
+		return &containerUnitOfWork{id: built}, nil
+	}, propre.WithRequestScope())
+
+	propre.Provide[propre.RequestDecoder[string]](container, func() containerQueryDecoder { return containerQueryDecoder{} })
+	propre.Provide[propre.UseCaseHandler[string, string]](container, func(u *containerUnitOfWork) *containerUseCase {
+		return &containerUseCase{unitOfWork: u}
+	}, propre.WithRequestScope())
+	propre.Provide[propre.Presenter[string, http.ResponseWriter]](container, func() containerTextPresenter { return containerTextPresenter{} })
+	propre.HandleHTTP[string, string](container, mux, "GET /")
+
+	if err := container.Start(context.Background()); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/?q=todo", nil))
+	if rw.Code != http.StatusOK || rw.Body.String() != "todo in unit of work 1" {
+		t.Fatalf("unexpected response %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/?q=todo", nil))
+	if rw.Code != http.StatusServiceUnavailable || !strings.Contains(rw.Body.String(), "no more connections") {
+		t.Fatalf("the resolution errors should be presented, got %d %q", rw.Code, rw.Body.String())
+	}
+}
@@ -13,6 +13,10 @@ Currently Propre (which means "clean" in French) exposes a [HTTPHandler] type wh
   - Output is the type produced by the use case as a result. It also holds either the data of a
     successful scenario or an error.
 
+The same use cases can be run from the command line with a [CLIHandler], triggered by queue messages
+with a [MessageHandler], or called as JSON-RPC methods with a [JSONRPCHandler]. Only the decoder and the
+presenter are specific to the transport.
+
 This is not idiomatic for functions or methods in Go to not return an error type in case of failure.
 Propre enforces the use of "monads" to make the mechanics easier between the application layers. If you're
 not familiar with monads, you can check out the fantastic [samber/mo] project.
@@ -0,0 +1,353 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNoEventRecorder is returned by [RecordEvent] if the context holds no
+	// [EventRecorder], like outside of an [EventDispatchingUseCase].
+	ErrNoEventRecorder = errors.New("no event recorder in the context")
+
+	// ErrEventHandling is returned by [EventDispatcher.Dispatch] if a subscriber
+	// still fails once its attempts are exhausted.
+	ErrEventHandling = errors.New("cannot handle the event")
+
+	// ErrEventSubscriberPanic is the error of a panicking subscriber.
+	ErrEventSubscriberPanic = errors.New("event subscriber panicked")
+
+	// ErrEventDispatcherStopped is returned by [EventDispatcher.Dispatch] once
+	// [EventDispatcher.Wait] has been called.
+	ErrEventDispatcherStopped = errors.New("event dispatcher stopped")
+)
+
+// EventRecorder collects the domain events recorded during a use case, like
+// a TodoCompleted event, to dispatch them once the use case succeeded.
+// It is safe for concurrent use.
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []any
+}
+
+// NewEventRecorder builds an empty [EventRecorder].
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{}
+}
+
+// Record appends the events to the recorded ones.
+func (r *EventRecorder) Record(events ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+}
+
+// Events returns the recorded events, in order.
+func (r *EventRecorder) Events() []any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]any(nil), r.events...)
+}
+
+type eventRecorderContextKey struct{}
+
+// ContextWithEventRecorder returns a copy of the context holding the given recorder.
+func ContextWithEventRecorder(ctx context.Context, recorder *EventRecorder) context.Context {
+	return context.WithValue(ctx, eventRecorderContextKey{}, recorder)
+}
+
+// EventRecorderFromContext returns the recorder stored in the context, if any.
+func EventRecorderFromContext(ctx context.Context) (*EventRecorder, bool) {
+	recorder, ok := ctx.Value(eventRecorderContextKey{}).(*EventRecorder)
+	return recorder, ok
+}
+
+// RecordEvent records the events in the recorder of the context. The domain
+// code calls it with the context given to the use case, instead of coding the
+// side effects inline. It returns [ErrNoEventRecorder] if the context holds no recorder.
+func RecordEvent(ctx context.Context, events ...any) error {
+	recorder, ok := EventRecorderFromContext(ctx)
+	if !ok {
+		return ErrNoEventRecorder
+	}
+
+	recorder.Record(events...)
+	return nil
+}
+
+type eventSubscriber struct {
+	handle func(ctx context.Context, event any) (bool, error)
+	async  bool
+}
+
+// EventDispatcher delivers the events to the subscribers of their type, see
+// [Subscribe] and [SubscribeAsync]. A failing subscriber is retried, with a
+// delay between the attempts.
+type EventDispatcher struct {
+	mu           sync.RWMutex
+	subscribers  []eventSubscriber
+	attempts     int
+	backoff      func(attempt int) time.Duration
+	errorHandler func(ctx context.Context, event any, err error)
+	inFlight     sync.WaitGroup
+	stopped      bool
+}
+
+// EventDispatcherOpts is the alias for the [EventDispatcher] builder options.
+type EventDispatcherOpts func(d *EventDispatcher)
+
+// WithEventRetries is an [EventDispatcher] option to set the number of attempts
+// of a failing subscriber, and the delay before each retry. A nil backoff retries
+// without delay. The default is 3 attempts, with a delay doubling from 100ms.
+func WithEventRetries(attempts int, backoff func(attempt int) time.Duration) EventDispatcherOpts {
+	return func(d *EventDispatcher) {
+		d.attempts = max(attempts, 1)
+		d.backoff = backoff
+		if backoff == nil {
+			d.backoff = func(int) time.Duration {
+				return 0
+			}
+		}
+	}
+}
+
+// WithEventErrorHandler is an [EventDispatcher] option to be notified of the
+// subscribers still failing once their attempts are exhausted. It is the only
+// way to know about the errors of the asynchronous subscribers.
+func WithEventErrorHandler(handler func(ctx context.Context, event any, err error)) EventDispatcherOpts {
+	return func(d *EventDispatcher) {
+		d.errorHandler = handler
+	}
+}
+
+// NewEventDispatcher builds an [EventDispatcher] without subscribers.
+func NewEventDispatcher(opts ...EventDispatcherOpts) *EventDispatcher {
+	d := &EventDispatcher{
+		attempts: 3,
+		backoff: func(attempt int) time.Duration {
+			return 100 * time.Millisecond << (attempt - 1)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// Subscribe registers a synchronous subscriber of the events of type Event,
+// or implementing Event if it is an interface. The synchronous subscribers are
+// called in the order of the registrations by [EventDispatcher.Dispatch].
+func Subscribe[Event any](d *EventDispatcher, handler func(ctx context.Context, event Event) error) {
+	d.subscribe(eventSubscriber{handle: eventHandler(handler)})
+}
+
+// SubscribeAsync registers an asynchronous subscriber of the events of type
+// Event, see [Subscribe]. The asynchronous subscribers are called in their own
+// goroutine with a context which is not canceled with the one of the dispatch.
+func SubscribeAsync[Event any](d *EventDispatcher, handler func(ctx context.Context, event Event) error) {
+	d.subscribe(eventSubscriber{handle: eventHandler(handler), async: true})
+}
+
+func eventHandler[Event any](handler func(ctx context.Context, event Event) error) func(ctx context.Context, event any) (bool, error) {
+	return func(ctx context.Context, event any) (bool, error) {
+		typed, ok := event.(Event)
+		if !ok {
+			return false, nil
+		}
+
+		return true, handler(ctx, typed)
+	}
+}
+
+func (d *EventDispatcher) subscribe(subscriber eventSubscriber) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers = append(d.subscribers, subscriber)
+}
+
+// Dispatch delivers the events, in order, to their subscribers. It returns once
+// the synchronous subscribers are done, with the errors of the ones still failing
+// once their attempts are exhausted, wrapping [ErrEventHandling]. Once [EventDispatcher.Wait]
+// has been called, the events are not delivered and [ErrEventDispatcherStopped]
+// is returned.
+func (d *EventDispatcher) Dispatch(ctx context.Context, events ...any) error {
+	d.mu.RLock()
+	subscribers, stopped := d.subscribers, d.stopped
+	d.mu.RUnlock()
+
+	if stopped {
+		return ErrEventDispatcherStopped
+	}
+
+	var errs []error
+	for _, event := range events {
+		for _, subscriber := range subscribers {
+			if !subscriber.async {
+				if err := d.deliver(ctx, subscriber, event); err != nil {
+					errs = append(errs, err)
+				}
+
+				continue
+			}
+
+			if !d.startAsync() {
+				errs = append(errs, fmt.Errorf("%w %T caused by %w", ErrEventHandling, event, ErrEventDispatcherStopped))
+				continue
+			}
+
+			go func() {
+				defer d.inFlight.Done()
+				d.deliver(context.WithoutCancel(ctx), subscriber, event)
+			}()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// startAsync counts an asynchronous delivery in flight, unless the dispatcher
+// is stopped. The lock orders it with Wait, which must not run concurrently
+// with an Add from zero.
+func (d *EventDispatcher) startAsync() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.stopped {
+		return false
+	}
+
+	d.inFlight.Add(1)
+	return true
+}
+
+// Wait stops the dispatcher, then blocks until the asynchronous subscribers are
+// done, or until the context is done. It is meant to be called when the
+// application stops: the events dispatched afterwards are rejected with
+// [ErrEventDispatcherStopped].
+func (d *EventDispatcher) Wait(ctx context.Context) error {
+	d.mu.Lock()
+	d.stopped = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *EventDispatcher) deliver(ctx context.Context, subscriber eventSubscriber, event any) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var handled bool
+		handled, err = d.call(ctx, subscriber, event)
+		if !handled || err == nil {
+			return nil
+		}
+
+		if attempt == d.attempts {
+			break
+		}
+
+		timer := time.NewTimer(d.backoff(attempt))
+		select {
+		case <-timer.C:
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+			err = errors.Join(err, ctx.Err())
+		}
+
+		break
+	}
+
+	err = fmt.Errorf("%w %T caused by %w", ErrEventHandling, event, err)
+	if d.errorHandler != nil {
+		d.errorHandler(ctx, event, err)
+	}
+
+	return err
+}
+
+func (d *EventDispatcher) call(ctx context.Context, subscriber eventSubscriber, event any) (handled bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			handled = true
+			err = fmt.Errorf("%w: %v", ErrEventSubscriberPanic, r)
+		}
+	}()
+
+	return subscriber.handle(ctx, event)
+}
+
+// EventDispatchingUseCase is a [UseCaseHandler] dispatching the events recorded
+// by the wrapped use case handler, see [RecordEvent]. The outputError function
+// returns the error held by the output: the events are dispatched only if it
+// is nil, and dropped otherwise.
+//
+// Inside a [TransactionalUseCase], the events are dispatched once its unit of
+// work is committed, see [AfterCommit]. Inside another EventDispatchingUseCase,
+// the events are passed to it, so they are dispatched once the outermost use
+// case succeeded. The errors of the subscribers do not change the output, they
+// are notified to the error handler of the dispatcher, see [WithEventErrorHandler].
+type EventDispatchingUseCase[Input, Output any] struct {
+	useCaseHandler UseCaseHandler[Input, Output]
+	dispatcher     *EventDispatcher
+	outputError    func(output Output) error
+}
+
+// NewEventDispatchingUseCase builds an [EventDispatchingUseCase] dispatching the
+// events with the given dispatcher.
+func NewEventDispatchingUseCase[Input, Output any](
+	useCaseHandler UseCaseHandler[Input, Output],
+	dispatcher *EventDispatcher,
+	outputError func(output Output) error,
+) *EventDispatchingUseCase[Input, Output] {
+	return &EventDispatchingUseCase[Input, Output]{
+		useCaseHandler: useCaseHandler,
+		dispatcher:     dispatcher,
+		outputError:    outputError,
+	}
+}
+
+// Handle implements [UseCaseHandler].
+func (u *EventDispatchingUseCase[Input, Output]) Handle(ctx context.Context, input Input) Output {
+	recorder := NewEventRecorder()
+	output := u.useCaseHandler.Handle(ContextWithEventRecorder(ctx, recorder), input)
+	if u.outputError(output) != nil {
+		return output
+	}
+
+	events := recorder.Events()
+	if len(events) == 0 {
+		return output
+	}
+
+	if outer, ok := EventRecorderFromContext(ctx); ok {
+		outer.Record(events...)
+		return output
+	}
+
+	dispatch := func(ctx context.Context) {
+		u.dispatcher.Dispatch(ctx, events...)
+	}
+
+	if !AfterCommit(ctx, dispatch) {
+		dispatch(ctx)
+	}
+
+	return output
+}
@@ -0,0 +1,376 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type todoCompleted struct {
+	ID string
+}
+
+type todoDeleted struct {
+	ID string
+}
+
+type todoEvent interface {
+	TodoID() string
+}
+
+func (e todoCompleted) TodoID() string { return e.ID }
+func (e todoDeleted) TodoID() string   { return e.ID }
+
+// eventLog records the events received by the subscribers.
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) Events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.events...)
+}
+
+func noEventBackoff(attempt int) time.Duration {
+	return 0
+}
+
+func TestEventDispatcher(t *testing.T) {
+	type testCase struct {
+		failures       int
+		panics         bool
+		expectedErr    error
+		expectedEvents []string
+		expectedErrors int
+	}
+
+	testCases := map[string]testCase{
+		"the events are delivered to the subscribers of their type": {
+			expectedEvents: []string{"completed todo-1", "todo event todo-1", "todo event todo-2"},
+		},
+		"a failing subscriber is retried": {
+			failures:       2,
+			expectedEvents: []string{"completed todo-1", "todo event todo-1", "todo event todo-2"},
+		},
+		"a subscriber failing once its attempts are exhausted is reported": {
+			failures:       3,
+			expectedErr:    propre.ErrEventHandling,
+			expectedEvents: []string{"todo event todo-1", "todo event todo-2"},
+			expectedErrors: 1,
+		},
+		"a panicking subscriber is reported": {
+			panics:         true,
+			expectedErr:    propre.ErrEventSubscriberPanic,
+			expectedEvents: []string{"todo event todo-1", "todo event todo-2"},
+			expectedErrors: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var reported []error
+			dispatcher := propre.NewEventDispatcher(
+				propre.WithEventRetries(3, noEventBackoff),
+				propre.WithEventErrorHandler(func(ctx context.Context, event any, err error) {
+					reported = append(reported, err)
+				}),
+			)
+
+			log := &eventLog{}
+			attempts := 0
+			propre.Subscribe(dispatcher, func(ctx context.Context, event todoCompleted) error {
+				attempts++
+				if tc.panics {
+					panic("boom")
+				}
+
+				if attempts <= tc.failures {
+					return errors.New("subscriber error")
+				}
+
+				log.add("completed " + event.ID)
+				return nil
+			})
+
+			propre.Subscribe(dispatcher, func(ctx context.Context, event todoEvent) error {
+				log.add("todo event " + event.TodoID())
+				return nil
+			})
+
+			err := dispatcher.Dispatch(context.Background(), todoCompleted{ID: "todo-1"}, todoDeleted{ID: "todo-2"})
+			if !errors.Is(err, tc.expectedErr) || (tc.expectedErr == nil && err != nil) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+
+			if events := log.Events(); !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Fatalf("expected the events %q, got %q", tc.expectedEvents, events)
+			}
+
+			if len(reported) != tc.expectedErrors {
+				t.Fatalf("expected %d reported errors, got %v", tc.expectedErrors, reported)
+			}
+		})
+	}
+}
+
+func TestEventDispatcherAsyncSubscribers(t *testing.T) {
+	dispatcher := propre.NewEventDispatcher(propre.WithEventRetries(2, noEventBackoff))
+
+	log := &eventLog{}
+	failed := false
+	propre.SubscribeAsync(dispatcher, func(ctx context.Context, event todoCompleted) error {
+		if !failed {
+			failed = true
+			return errors.New("subscriber error")
+		}
+
+		log.add("completed " + event.ID)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := dispatcher.Dispatch(ctx, todoCompleted{ID: "todo-1"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	cancel()
+
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), time.Second)
+	defer cancelWait()
+	if err := dispatcher.Wait(waitCtx); err != nil {
+		t.Fatalf("expected the subscribers to be done, got %v", err)
+	}
+
+	if events := log.Events(); !reflect.DeepEqual(events, []string{"completed todo-1"}) {
+		t.Fatalf("expected the event to be delivered, got %q", events)
+	}
+
+	if err := dispatcher.Dispatch(context.Background(), todoCompleted{ID: "todo-2"}); !errors.Is(err, propre.ErrEventDispatcherStopped) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrEventDispatcherStopped, err)
+	}
+}
+
+func TestEventDispatcherWaitsWhileDispatching(t *testing.T) {
+	dispatcher := propre.NewEventDispatcher()
+	propre.SubscribeAsync(dispatcher, func(ctx context.Context, event todoCompleted) error {
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				err := dispatcher.Dispatch(context.Background(), todoCompleted{ID: "todo-1"})
+				if err != nil && !errors.Is(err, propre.ErrEventDispatcherStopped) {
+					t.Errorf("unexpected error %v", err)
+				}
+			}
+		}()
+	}
+
+	if err := dispatcher.Wait(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	wg.Wait()
+}
+
+func TestEventDispatcherRetriesWithoutBackoff(t *testing.T) {
+	dispatcher := propre.NewEventDispatcher(propre.WithEventRetries(3, nil))
+
+	attempts := 0
+	propre.Subscribe(dispatcher, func(ctx context.Context, event todoCompleted) error {
+		attempts++
+		return errors.New("subscriber error")
+	})
+
+	err := dispatcher.Dispatch(context.Background(), todoCompleted{ID: "todo-1"})
+	if !errors.Is(err, propre.ErrEventHandling) || attempts != 3 {
+		t.Fatalf("expected 3 attempts and a handling error, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestRecordEventWithoutRecorder(t *testing.T) {
+	if err := propre.RecordEvent(context.Background(), todoCompleted{}); !errors.Is(err, propre.ErrNoEventRecorder) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrNoEventRecorder, err)
+	}
+}
+
+type completeTodoUseCase struct {
+	err error
+}
+
+func (u completeTodoUseCase) Handle(ctx context.Context, id string) saveTodoOutput {
+	if err := propre.RecordEvent(ctx, todoCompleted{ID: id}); err != nil {
+		return saveTodoOutput{Error: err}
+	}
+
+	return saveTodoOutput{Error: u.err}
+}
+
+func TestEventDispatchingUseCase(t *testing.T) {
+	type testCase struct {
+		useCaseErr     error
+		expectedEvents []string
+	}
+
+	testCases := map[string]testCase{
+		"the events are dispatched once the use case succeeded": {
+			expectedEvents: []string{"completed todo-1"},
+		},
+		"the events are dropped if the use case failed": {
+			useCaseErr: errors.New("use case error"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dispatcher := propre.NewEventDispatcher()
+			log := &eventLog{}
+			propre.Subscribe(dispatcher, func(ctx context.Context, event todoCompleted) error {
+				log.add("completed " + event.ID)
+				return nil
+			})
+
+			useCase := propre.NewEventDispatchingUseCase[string, saveTodoOutput](
+				completeTodoUseCase{err: tc.useCaseErr},
+				dispatcher,
+				saveTodoOutputError,
+			)
+
+			useCase.Handle(context.Background(), "todo-1")
+
+			if events := log.Events(); !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Fatalf("expected the events %q, got %q", tc.expectedEvents, events)
+			}
+		})
+	}
+}
+
+func TestEventDispatchingUseCaseInATransaction(t *testing.T) {
+	type testCase struct {
+		opts           []propre.InMemoryTxManagerOpts
+		savepoint      bool
+		nestedErr      error
+		expectedEvents []string
+	}
+
+	testCases := map[string]testCase{
+		"the events are dispatched once the unit of work is committed": {
+			expectedEvents: []string{"completed todo-1 after commit"},
+		},
+		"the events are dropped if the unit of work cannot be committed": {
+			opts: []propre.InMemoryTxManagerOpts{propre.WithInMemoryCommitError(errors.New("database error"))},
+		},
+		"the events of a savepoint are dispatched once the unit of work is committed": {
+			savepoint:      true,
+			expectedEvents: []string{"completed todo-1 after commit", "completed nested todo-1 after commit"},
+		},
+		"the events of a rolled back savepoint are dropped": {
+			savepoint:      true,
+			nestedErr:      errors.New("use case error"),
+			expectedEvents: []string{"completed todo-1 after commit"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			txManager := propre.NewInMemoryTxManager(tc.opts...)
+			dispatcher := propre.NewEventDispatcher()
+			log := &eventLog{}
+			propre.Subscribe(dispatcher, func(ctx context.Context, event todoCompleted) error {
+				state := "before commit"
+				if txManager.UnitsOfWork()[0].State() == propre.UnitOfWorkCommitted {
+					state = "after commit"
+				}
+
+				log.add("completed " + event.ID + " " + state)
+				return nil
+			})
+
+			var useCase propre.UseCaseHandler[string, saveTodoOutput] = propre.NewEventDispatchingUseCase[string, saveTodoOutput](
+				completeTodoUseCase{},
+				dispatcher,
+				saveTodoOutputError,
+			)
+
+			if tc.savepoint {
+				nested := propre.NewTransactionalUseCase[string, saveTodoOutput](
+					propre.NewEventDispatchingUseCase[string, saveTodoOutput](
+						completeTodoUseCase{err: tc.nestedErr},
+						dispatcher,
+						saveTodoOutputError,
+					),
+					txManager,
+					saveTodoOutputError,
+					saveTodoErrorOutput,
+					propre.WithSavepoints[string, saveTodoOutput](),
+				)
+
+				completeTodo := useCase
+				useCase = propre.UseCaseHandlerFunc[string, saveTodoOutput](func(ctx context.Context, id string) saveTodoOutput {
+					output := completeTodo.Handle(ctx, id)
+					nested.Handle(ctx, "nested "+id)
+
+					return output
+				})
+			}
+
+			propre.NewTransactionalUseCase[string, saveTodoOutput](
+				useCase,
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+			).Handle(context.Background(), "todo-1")
+
+			if events := log.Events(); !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Fatalf("expected the events %q, got %q", tc.expectedEvents, events)
+			}
+		})
+	}
+}
+
+func TestNestedEventDispatchingUseCases(t *testing.T) {
+	dispatcher := propre.NewEventDispatcher()
+	log := &eventLog{}
+	propre.Subscribe(dispatcher, func(ctx context.Context, event todoCompleted) error {
+		log.add("completed " + event.ID)
+		return nil
+	})
+
+	nested := propre.NewEventDispatchingUseCase[string, saveTodoOutput](completeTodoUseCase{}, dispatcher, saveTodoOutputError)
+	outer := propre.NewEventDispatchingUseCase[string, saveTodoOutput](
+		propre.UseCaseHandlerFunc[string, saveTodoOutput](func(ctx context.Context, id string) saveTodoOutput {
+			nested.Handle(ctx, id)
+			if len(log.Events()) != 0 {
+				t.Fatal("expected the events of the nested use case to wait for the outer one")
+			}
+
+			return saveTodoOutput{Error: errors.New("use case error")}
+		}),
+		dispatcher,
+		saveTodoOutputError,
+	)
+
+	outer.Handle(context.Background(), "todo-1")
+
+	if events := log.Events(); len(events) != 0 {
+		t.Fatalf("expected the events to be dropped with the outer use case, got %q", events)
+	}
+}
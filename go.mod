@@ -2,11 +2,16 @@ module github.com/cyb3rd4d/propre
 
 go 1.22.0
 
-require github.com/stretchr/testify v1.9.0
+// gopkg.in/yaml.v3 is only imported by the openapiyaml package and by the
+// propre command to read the scaffolding specs, the propre package itself
+// depends on the standard library only.
+require (
+	github.com/stretchr/testify v1.9.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,277 @@
+package propre
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyMissing is presented by [IdempotencyHandler] if the
+	// Idempotency-Key header is required and missing.
+	ErrIdempotencyKeyMissing = errors.New("idempotency key missing")
+
+	// ErrIdempotencyStore wraps the errors of an [IdempotencyStore] presented by
+	// [IdempotencyHandler].
+	ErrIdempotencyStore = errors.New("idempotency store error")
+)
+
+// IdempotencyKeyHeader is the header holding the idempotency key of a request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" in the responses replayed by an
+// [IdempotencyHandler].
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// IdempotencyHandler deduplicates the requests carrying an Idempotency-Key header.
+// It wraps an [http.Handler], like an [HTTPHandler], whose response is stored and
+// replayed to the retries of the same request.
+//
+// A request is identified by its key and its fingerprint, a hash of its method,
+// URL and body:
+//   - the first request reserves the key and is passed to the wrapped handler,
+//   - a concurrent duplicate is rejected with [ErrIdempotencyKeyInUse],
+//   - a retry once the first request completed receives the stored response,
+//   - a request reusing the key with a different fingerprint is rejected with
+//     [ErrIdempotencyKeyReused].
+//
+// Server errors (5xx) are not stored, the key is released so the request can be
+// retried. The errors are sent by a presenter which can be customized.
+type IdempotencyHandler struct {
+	next           http.Handler
+	store          IdempotencyStore
+	ttl            time.Duration
+	lockTTL        time.Duration
+	maxBodySize    int64
+	required       bool
+	scope          func(req *http.Request) string
+	errorPresenter Presenter[error, http.ResponseWriter]
+}
+
+// IdempotencyHandlerOpts is the alias for the [IdempotencyHandler] builder options.
+type IdempotencyHandlerOpts func(h *IdempotencyHandler)
+
+// WithIdempotencyTTL is an [IdempotencyHandler] option to set how long the responses
+// are stored. The default is 24 hours.
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.ttl = ttl
+	}
+}
+
+// WithIdempotencyLockTTL is an [IdempotencyHandler] option to set how long a key
+// is reserved while its request is in progress. It must exceed the duration of
+// the requests: a reservation left by a crashed server expires after it, so the
+// request can be retried. The default is 1 minute.
+func WithIdempotencyLockTTL(ttl time.Duration) IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.lockTTL = ttl
+	}
+}
+
+// WithIdempotencyMaxBodySize is an [IdempotencyHandler] option to limit the size
+// in bytes of the request bodies, which are read to compute the fingerprints.
+// A bigger body is rejected with a 413 Request Entity Too Large response by the
+// default error presenter. The default is 1 MiB.
+func WithIdempotencyMaxBodySize(size int64) IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithIdempotencyKeyRequired is an [IdempotencyHandler] option to reject the requests
+// without Idempotency-Key header with [ErrIdempotencyKeyMissing]. By default they
+// are passed to the wrapped handler.
+func WithIdempotencyKeyRequired() IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.required = true
+	}
+}
+
+// WithIdempotencyScope is an [IdempotencyHandler] option to scope the keys, for
+// example by client, so two clients cannot use the same key.
+func WithIdempotencyScope(scope func(req *http.Request) string) IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.scope = scope
+	}
+}
+
+// WithIdempotencyErrorPresenter is an [IdempotencyHandler] option to customize the
+// responses to the rejected requests. The default presenter sends a plain text
+// response with the status codes 413 for a body exceeding the limit, see
+// [WithIdempotencyMaxBodySize], 400 for [ErrIdempotencyKeyMissing], 409 for
+// [ErrIdempotencyKeyInUse], 422 for [ErrIdempotencyKeyReused] and 500 otherwise.
+func WithIdempotencyErrorPresenter(presenter Presenter[error, http.ResponseWriter]) IdempotencyHandlerOpts {
+	return func(h *IdempotencyHandler) {
+		h.errorPresenter = presenter
+	}
+}
+
+// NewIdempotencyHandler builds an IdempotencyHandler wrapping the given handler.
+// [IdempotencyHandlerOpts] can be passed to customize the TTLs, the scope of the keys
+// and the error responses.
+func NewIdempotencyHandler(next http.Handler, store IdempotencyStore, opts ...IdempotencyHandlerOpts) *IdempotencyHandler {
+	handler := &IdempotencyHandler{
+		next:           next,
+		store:          store,
+		ttl:            24 * time.Hour,
+		lockTTL:        time.Minute,
+		maxBodySize:    1 << 20,
+		errorPresenter: idempotencyErrorPresenter{},
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP allows IdempotencyHandler to be used by any HTTP "ServeMux".
+func (handler *IdempotencyHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	key := req.Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		if handler.required {
+			handler.errorPresenter.Present(ctx, rw, ErrIdempotencyKeyMissing)
+			return
+		}
+
+		handler.next.ServeHTTP(rw, req)
+		return
+	}
+
+	if handler.scope != nil {
+		key = handler.scope(req) + ":" + key
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, handler.maxBodySize))
+	if err != nil {
+		handler.errorPresenter.Present(ctx, rw, fmt.Errorf("%w caused by %w", ErrRequestPayloadExtraction, err))
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	fingerprint := idempotencyFingerprint(req, body)
+	stored, err := handler.store.Reserve(ctx, key, fingerprint, handler.lockTTL)
+	if err != nil {
+		if !errors.Is(err, ErrIdempotencyKeyInUse) && !errors.Is(err, ErrIdempotencyKeyReused) {
+			err = fmt.Errorf("%w caused by %w", ErrIdempotencyStore, err)
+		}
+
+		handler.errorPresenter.Present(ctx, rw, err)
+		return
+	}
+
+	if stored != nil {
+		replayIdempotentResponse(rw, stored)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: rw, statusCode: http.StatusOK}
+	completed := false
+	defer func() {
+		if !completed {
+			handler.store.Release(context.WithoutCancel(ctx), key, fingerprint)
+		}
+	}()
+
+	handler.next.ServeHTTP(recorder, req)
+
+	if recorder.statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	err = handler.store.Complete(context.WithoutCancel(ctx), key, fingerprint, recorder.response(), handler.ttl)
+	completed = err == nil || errors.Is(err, ErrIdempotencyReservationLost)
+}
+
+func idempotencyFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", req.Method, req.URL.RequestURI())
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replayIdempotentResponse(rw http.ResponseWriter, response *IdempotentResponse) {
+	for header, values := range response.Header {
+		rw.Header()[header] = append([]string(nil), values...)
+	}
+
+	rw.Header().Set(IdempotentReplayedHeader, "true")
+	rw.WriteHeader(response.StatusCode)
+	rw.Write(response.Body)
+}
+
+// responseRecorder writes the response to the client while recording it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	header      http.Header
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.wroteHeader = true
+	r.statusCode = statusCode
+	r.header = r.ResponseWriter.Header().Clone()
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) response() IdempotentResponse {
+	header := r.header
+	if !r.wroteHeader {
+		header = r.ResponseWriter.Header().Clone()
+	}
+
+	return IdempotentResponse{
+		StatusCode: r.statusCode,
+		Header:     header,
+		Body:       bytes.Clone(r.body.Bytes()),
+	}
+}
+
+type idempotencyErrorPresenter struct{}
+
+func (p idempotencyErrorPresenter) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		status = http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrIdempotencyKeyMissing), errors.Is(err, ErrRequestPayloadExtraction):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrIdempotencyKeyInUse):
+		status = http.StatusConflict
+	case errors.Is(err, ErrIdempotencyKeyReused):
+		status = http.StatusUnprocessableEntity
+	}
+
+	http.Error(rw, http.StatusText(status), status)
+}
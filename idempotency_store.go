@@ -0,0 +1,136 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned by an [IdempotencyStore] if the key is
+	// reserved by a request still in progress.
+	ErrIdempotencyKeyInUse = errors.New("idempotency key in use")
+
+	// ErrIdempotencyKeyReused is returned by an [IdempotencyStore] if the key has
+	// been used by a request with a different fingerprint.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+
+	// ErrIdempotencyReservationLost is returned by an [IdempotencyStore] if the
+	// reservation of a key expired and the key has been reserved or completed by
+	// another request in the meantime.
+	ErrIdempotencyReservationLost = errors.New("idempotency reservation lost")
+)
+
+// IdempotentResponse is a response stored by an [IdempotencyStore] to be replayed.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// IdempotencyStore is the interface of the storage of the responses of an
+// [IdempotencyHandler].
+type IdempotencyStore interface {
+	// Reserve atomically reserves the key for the request with the given fingerprint.
+	// It returns a nil response if the key is reserved, the stored response if the
+	// key has already been used by the same request, [ErrIdempotencyKeyInUse] if
+	// the request is still in progress, or [ErrIdempotencyKeyReused] if the
+	// fingerprint does not match. The reservation expires after the TTL.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error)
+	// Complete stores the response of the request with the given fingerprint, it
+	// expires after the TTL. If the reservation expired, the response is stored
+	// unless another request took the key, then [ErrIdempotencyReservationLost]
+	// is returned.
+	Complete(ctx context.Context, key, fingerprint string, response IdempotentResponse, ttl time.Duration) error
+	// Release removes the reservation of a key made by the request with the given
+	// fingerprint, so it can be used again. The keys taken by another request
+	// are left untouched.
+	Release(ctx context.Context, key, fingerprint string) error
+}
+
+type idempotencyEntry struct {
+	fingerprint string
+	response    *IdempotentResponse
+	expiresAt   time.Time
+}
+
+// InMemoryIdempotencyStore is an [IdempotencyStore] keeping the responses in memory.
+// The expired entries are removed each time a key is reserved.
+type InMemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+}
+
+// NewInMemoryIdempotencyStore builds an empty [InMemoryIdempotencyStore].
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+// Reserve implements [IdempotencyStore].
+func (s *InMemoryIdempotencyStore) Reserve(
+	ctx context.Context,
+	key, fingerprint string,
+	ttl time.Duration,
+) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		s.entries[key] = idempotencyEntry{fingerprint: fingerprint, expiresAt: now.Add(ttl)}
+		return nil, nil
+	}
+
+	if entry.fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if entry.response == nil {
+		return nil, ErrIdempotencyKeyInUse
+	}
+
+	return entry.response, nil
+}
+
+// Complete implements [IdempotencyStore].
+func (s *InMemoryIdempotencyStore) Complete(
+	ctx context.Context,
+	key, fingerprint string,
+	response IdempotentResponse,
+	ttl time.Duration,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.entries[key]
+	if ok && now.Before(entry.expiresAt) && (entry.fingerprint != fingerprint || entry.response != nil) {
+		return ErrIdempotencyReservationLost
+	}
+
+	s.entries[key] = idempotencyEntry{fingerprint: fingerprint, response: &response, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Release implements [IdempotencyStore].
+func (s *InMemoryIdempotencyStore) Release(ctx context.Context, key, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.fingerprint == fingerprint && entry.response == nil {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type countingHandler struct {
+	calls  atomic.Int32
+	status int
+	block  chan struct{}
+}
+
+func (h *countingHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	call := h.calls.Add(1)
+	if h.block != nil {
+		<-h.block
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	rw.Header().Set("content-type", "text/plain")
+	rw.WriteHeader(h.status)
+	fmt.Fprintf(rw, "call %d: %s", call, body)
+}
+
+func sendIdempotent(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(propre.IdempotencyKeyHeader, key)
+	}
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	return rw
+}
+
+func TestIdempotencyHandlerReplaysTheStoredResponse(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore())
+
+	first := sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	second := sendIdempotent(handler, "some-key", `{"item":"book"}`)
+
+	if next.calls.Load() != 1 {
+		t.Fatalf("the wrapped handler should be called once, got %d calls", next.calls.Load())
+	}
+
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("unexpected replayed response %d %q", second.Code, second.Body.String())
+	}
+
+	if second.Header().Get("content-type") != "text/plain" {
+		t.Fatalf("the headers should be replayed, got %v", second.Header())
+	}
+
+	if first.Header().Get(propre.IdempotentReplayedHeader) != "" || second.Header().Get(propre.IdempotentReplayedHeader) != "true" {
+		t.Fatal("only the replayed response should have the replayed header")
+	}
+}
+
+func TestIdempotencyHandlerRejectsAKeyReusedWithADifferentRequest(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore())
+
+	sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	rw := sendIdempotent(handler, "some-key", `{"item":"pen"}`)
+
+	if rw.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusUnprocessableEntity, rw.Code)
+	}
+}
+
+func TestIdempotencyHandlerRejectsConcurrentDuplicates(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated, block: make(chan struct{})}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore())
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	}()
+
+	for next.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rw := sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	close(next.block)
+
+	if rw.Code != http.StatusConflict {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusConflict, rw.Code)
+	}
+
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusCreated, first.Code)
+	}
+}
+
+func TestIdempotencyHandlerStoresTheResponseAfterTheLockExpired(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated, block: make(chan struct{})}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore(),
+		propre.WithIdempotencyLockTTL(time.Millisecond),
+	)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	}()
+
+	for next.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(next.block)
+	first := <-done
+
+	rw := sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	if rw.Code != http.StatusCreated || rw.Body.String() != first.Body.String() {
+		t.Fatalf("unexpected replayed response %d %q", rw.Code, rw.Body.String())
+	}
+
+	if next.calls.Load() != 1 {
+		t.Fatalf("the wrapped handler should be called once, got %d calls", next.calls.Load())
+	}
+}
+
+func TestInMemoryIdempotencyStoreKeepsTheKeysTakenAfterTheLockExpired(t *testing.T) {
+	ctx := context.Background()
+	store := propre.NewInMemoryIdempotencyStore()
+
+	if _, err := store.Reserve(ctx, "some-key", "first", time.Millisecond); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := store.Reserve(ctx, "some-key", "second", time.Minute); err != nil {
+		t.Fatalf("the expired reservation should be taken, got %v", err)
+	}
+
+	err := store.Complete(ctx, "some-key", "first", propre.IdempotentResponse{StatusCode: http.StatusCreated}, time.Hour)
+	if !errors.Is(err, propre.ErrIdempotencyReservationLost) {
+		t.Fatalf("wrong error, expected %v, got %v", propre.ErrIdempotencyReservationLost, err)
+	}
+
+	if err := store.Release(ctx, "some-key", "first"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err := store.Reserve(ctx, "some-key", "second", time.Minute); !errors.Is(err, propre.ErrIdempotencyKeyInUse) {
+		t.Fatalf("the second reservation should be kept, got %v", err)
+	}
+}
+
+func TestIdempotencyHandlerLimitsTheBodySize(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore(),
+		propre.WithIdempotencyMaxBodySize(8),
+	)
+
+	rw := sendIdempotent(handler, "some-key", `{"item":"book"}`)
+
+	if rw.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusRequestEntityTooLarge, rw.Code)
+	}
+
+	if next.calls.Load() != 0 {
+		t.Fatalf("the wrapped handler should not be called, got %d calls", next.calls.Load())
+	}
+}
+
+func TestIdempotencyHandlerDoesNotStoreServerErrors(t *testing.T) {
+	next := &countingHandler{status: http.StatusServiceUnavailable}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore())
+
+	sendIdempotent(handler, "some-key", `{"item":"book"}`)
+	sendIdempotent(handler, "some-key", `{"item":"book"}`)
+
+	if next.calls.Load() != 2 {
+		t.Fatalf("the request should be retried, got %d calls", next.calls.Load())
+	}
+}
+
+func TestIdempotencyHandlerScopesTheKeys(t *testing.T) {
+	next := &countingHandler{status: http.StatusCreated}
+	handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore(),
+		propre.WithIdempotencyScope(func(req *http.Request) string {
+			return req.URL.Query().Get("client")
+		}),
+	)
+
+	for _, client := range []string{"a", "b"} {
+		req := httptest.NewRequest(http.MethodPost, "/orders?client="+client, strings.NewReader(`{}`))
+		req.Header.Set(propre.IdempotencyKeyHeader, "some-key")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if next.calls.Load() != 2 {
+		t.Fatalf("each client should have its own key, got %d calls", next.calls.Load())
+	}
+}
+
+// ttlRecordingIdempotencyStore records the TTLs given to the store.
+type ttlRecordingIdempotencyStore struct {
+	*propre.InMemoryIdempotencyStore
+	reserveTTL  time.Duration
+	completeTTL time.Duration
+}
+
+func (s *ttlRecordingIdempotencyStore) Reserve(
+	ctx context.Context,
+	key, fingerprint string,
+	ttl time.Duration,
+) (*propre.IdempotentResponse, error) {
+	s.reserveTTL = ttl
+	return s.InMemoryIdempotencyStore.Reserve(ctx, key, fingerprint, ttl)
+}
+
+func (s *ttlRecordingIdempotencyStore) Complete(
+	ctx context.Context,
+	key, fingerprint string,
+	response propre.IdempotentResponse,
+	ttl time.Duration,
+) error {
+	s.completeTTL = ttl
+	return s.InMemoryIdempotencyStore.Complete(ctx, key, fingerprint, response, ttl)
+}
+
+func TestIdempotencyHandlerReservesTheKeysForTheLockTTL(t *testing.T) {
+	type testCase struct {
+		opts                []propre.IdempotencyHandlerOpts
+		expectedReserveTTL  time.Duration
+		expectedCompleteTTL time.Duration
+	}
+
+	testCases := map[string]testCase{
+		"default TTLs": {
+			expectedReserveTTL:  time.Minute,
+			expectedCompleteTTL: 24 * time.Hour,
+		},
+		"custom TTLs": {
+			opts: []propre.IdempotencyHandlerOpts{
+				propre.WithIdempotencyLockTTL(10 * time.Second),
+				propre.WithIdempotencyTTL(time.Hour),
+			},
+			expectedReserveTTL:  10 * time.Second,
+			expectedCompleteTTL: time.Hour,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			store := &ttlRecordingIdempotencyStore{InMemoryIdempotencyStore: propre.NewInMemoryIdempotencyStore()}
+			handler := propre.NewIdempotencyHandler(&countingHandler{status: http.StatusCreated}, store, testCase.opts...)
+
+			sendIdempotent(handler, "some-key", `{}`)
+
+			if store.reserveTTL != testCase.expectedReserveTTL || store.completeTTL != testCase.expectedCompleteTTL {
+				t.Fatalf("expected the TTLs %s and %s, got %s and %s",
+					testCase.expectedReserveTTL, testCase.expectedCompleteTTL, store.reserveTTL, store.completeTTL)
+			}
+		})
+	}
+}
+
+type idempotencyKeyTestCase struct {
+	opts               []propre.IdempotencyHandlerOpts
+	expectedHTTPStatus int
+	expectedCalls      int32
+}
+
+func TestIdempotencyHandlerWithoutKey(t *testing.T) {
+	testCases := map[string]idempotencyKeyTestCase{
+		"optional key": {
+			expectedHTTPStatus: http.StatusCreated,
+			expectedCalls:      2,
+		},
+		"required key": {
+			opts:               []propre.IdempotencyHandlerOpts{propre.WithIdempotencyKeyRequired()},
+			expectedHTTPStatus: http.StatusBadRequest,
+			expectedCalls:      0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			next := &countingHandler{status: http.StatusCreated}
+			handler := propre.NewIdempotencyHandler(next, propre.NewInMemoryIdempotencyStore(), testCase.opts...)
+
+			sendIdempotent(handler, "", `{}`)
+			rw := sendIdempotent(handler, "", `{}`)
+
+			if rw.Code != testCase.expectedHTTPStatus {
+				t.Fatalf("wrong status code, expected %d, got %d", testCase.expectedHTTPStatus, rw.Code)
+			}
+
+			if next.calls.Load() != testCase.expectedCalls {
+				t.Fatalf("wrong number of calls, expected %d, got %d", testCase.expectedCalls, next.calls.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// ErrFileExists is returned by [Write] when a generated file already exists.
+var ErrFileExists = errors.New("file already exists")
+
+// The roles of the generated packages, see [Layout].
+const (
+	roleUseCase   = "usecase"
+	roleDecoder   = "decoder"
+	rolePresenter = "presenter"
+	roleView      = "view"
+	roleRouter    = "router"
+)
+
+// reservedNames are the names of the packages imported by the generated files,
+// the generated packages with the same name are imported with an alias.
+var reservedNames = map[string]bool{
+	"context": true, "errors": true, "fmt": true, "http": true, "httptest": true, "json": true,
+	"propre": true, "propretest": true, "strconv": true, "strings": true, "testing": true,
+}
+
+// File is a generated file, its path is relative to the root of the module.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+type fileTemplate struct {
+	role     string
+	suffix   string
+	test     bool
+	refs     []string
+	template *template.Template
+}
+
+var fileTemplates = []fileTemplate{
+	{role: roleUseCase, suffix: ".go", template: useCaseTemplate},
+	{role: roleUseCase, suffix: "_test.go", test: true, refs: []string{roleUseCase}, template: useCaseTestTemplate},
+	{role: roleDecoder, suffix: "_decoder.go", refs: []string{roleUseCase}, template: decoderTemplate},
+	{role: roleDecoder, suffix: "_decoder_test.go", test: true, refs: []string{roleUseCase, roleDecoder}, template: decoderTestTemplate},
+	{role: roleView, suffix: "_view.go", template: viewTemplate},
+	{role: rolePresenter, suffix: "_presenter.go", refs: []string{roleUseCase, roleView}, template: presenterTemplate},
+	{role: rolePresenter, suffix: "_presenter_test.go", test: true, refs: []string{roleUseCase, rolePresenter}, template: presenterTestTemplate},
+	{role: roleRouter, suffix: "_route.go", refs: []string{roleUseCase, roleDecoder, rolePresenter}, template: routerTemplate},
+	{role: roleRouter, suffix: "_route_test.go", test: true, refs: []string{roleUseCase, roleRouter}, template: routerTestTemplate},
+}
+
+// endpoint is the data of the templates.
+type endpoint struct {
+	Name          string
+	Words         string
+	Method        string
+	Pattern       string
+	Target        string
+	Body          string
+	BodyLiteral   string
+	SuccessStatus string
+	Fields        []field
+}
+
+type field struct {
+	Field
+	GoName        string
+	Var           string
+	Sample        string
+	InvalidTarget string
+	Parse         string
+}
+
+// file is the data of the template of a file.
+type file struct {
+	*endpoint
+	Package    string
+	Imports    []string
+	qualifiers map[string]string
+}
+
+// Q returns the qualifier of the identifiers of a role, like "usecase.".
+func (f *file) Q(role string) string {
+	return f.qualifiers[role]
+}
+
+// FieldsFrom returns the fields read from a source.
+func (f *file) FieldsFrom(source string) []field {
+	var fields []field
+	for _, field := range f.Fields {
+		if field.Source == source {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// Parses tells if a field of a path or the query must be parsed.
+func (f *file) Parses() bool {
+	for _, field := range f.Fields {
+		if field.Source != SourceBody && field.Type != "string" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Generate generates the files of the endpoint described by the spec in the
+// packages of the layout. The module is the path of the Go module the
+// packages belong to, the generated files import each other with it.
+func Generate(spec Spec, layout Layout, module string) ([]File, error) {
+	spec, err := spec.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := map[string]string{
+		roleUseCase:   layout.UseCase,
+		roleDecoder:   layout.Decoder,
+		rolePresenter: layout.Presenter,
+		roleView:      layout.View,
+		roleRouter:    layout.Router,
+	}
+
+	for role, dir := range dirs {
+		dir = path.Clean(filepath.ToSlash(dir))
+		if dir == "" || path.IsAbs(dir) || strings.HasPrefix(dir, "../") || dir == ".." {
+			return nil, fmt.Errorf("%w: the %s directory %q must be inside the module", ErrInvalidLayout, role, dir)
+		}
+
+		dirs[role] = dir
+	}
+
+	data := newEndpoint(spec)
+	name := snakeCase(spec.Name)
+	files := make([]File, 0, len(fileTemplates))
+	for _, tmpl := range fileTemplates {
+		f, err := newFile(data, tmpl, dirs, module)
+		if err != nil {
+			return nil, err
+		}
+
+		var content bytes.Buffer
+		if err := tmpl.template.Execute(&content, f); err != nil {
+			return nil, err
+		}
+
+		formatted, err := format.Source(content.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("cannot format the generated file %s%s: %w", name, tmpl.suffix, err)
+		}
+
+		files = append(files, File{
+			Path:    path.Join(dirs[tmpl.role], name+tmpl.suffix),
+			Content: formatted,
+		})
+	}
+
+	return files, nil
+}
+
+func newEndpoint(spec Spec) *endpoint {
+	data := &endpoint{
+		Name:          spec.Name,
+		Words:         words(spec.Name),
+		Method:        spec.Method,
+		Pattern:       spec.Method + " " + spec.Path,
+		SuccessStatus: "http.StatusOK",
+	}
+
+	if spec.Method == http.MethodPost {
+		data.SuccessStatus = "http.StatusCreated"
+	}
+
+	body := make([]string, 0, len(spec.Fields))
+	var query []string
+	for _, f := range spec.Fields {
+		sample, text := sampleValue(f.Type)
+		data.Fields = append(data.Fields, field{
+			Field:  f,
+			GoName: exportedName(f.Name),
+			Var:    variableName(f.Name),
+			Sample: sample,
+			Parse:  parseExpression(f.Type),
+		})
+
+		switch f.Source {
+		case SourceBody:
+			body = append(body, fmt.Sprintf("%q:%s", f.Name, sample))
+		case SourceQuery:
+			query = append(query, f.Name+"="+text)
+		}
+	}
+
+	if len(body) > 0 {
+		data.Body = "{" + strings.Join(body, ",") + "}"
+	}
+
+	data.BodyLiteral = strconv.Quote(data.Body)
+	if strconv.CanBackquote(data.Body) {
+		data.BodyLiteral = "`" + data.Body + "`"
+	}
+
+	suffix := ""
+	if len(query) > 0 {
+		suffix = "?" + strings.Join(query, "&")
+	}
+
+	data.Target = samplePath(spec.Path, spec.Fields, "") + suffix
+	for i, f := range data.Fields {
+		if f.Source == SourcePath && f.Type != "string" {
+			data.Fields[i].InvalidTarget = samplePath(spec.Path, spec.Fields, f.Name) + suffix
+		}
+	}
+
+	return data
+}
+
+// samplePath replaces the wildcards of the path with sample values, and the
+// wildcard of the invalid field with a value which cannot be parsed.
+func samplePath(pattern string, fields []Field, invalid string) string {
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Name] = f.Type
+	}
+
+	return wildcardPattern.ReplaceAllStringFunc(pattern, func(wildcard string) string {
+		name := wildcardPattern.FindStringSubmatch(wildcard)[1]
+		if name == invalid {
+			return "invalid"
+		}
+
+		_, text := sampleValue(types[name])
+		return text
+	})
+}
+
+// sampleValue returns a sample value of the type, as a Go literal and as text.
+func sampleValue(fieldType string) (string, string) {
+	switch fieldType {
+	case "bool":
+		return "true", "true"
+	case "int", "int64":
+		return "1", "1"
+	case "float64":
+		return "1.5", "1.5"
+	default:
+		return `"example"`, "example"
+	}
+}
+
+// parseExpression returns the format of the expression parsing a text value to
+// the type, empty for strings.
+func parseExpression(fieldType string) string {
+	switch fieldType {
+	case "bool":
+		return "strconv.ParseBool(%s)"
+	case "int":
+		return "strconv.Atoi(%s)"
+	case "int64":
+		return "strconv.ParseInt(%s, 10, 64)"
+	case "float64":
+		return "strconv.ParseFloat(%s, 64)"
+	default:
+		return ""
+	}
+}
+
+// variableName returns a local variable name for a field, which does not
+// shadow the keywords and the identifiers of the generated decoder.
+func variableName(name string) string {
+	exported := exportedName(name)
+	i := 1
+	for i < len(exported) && isUpper(exported[i]) && (i+1 == len(exported) || isUpper(exported[i+1])) {
+		i++
+	}
+
+	variable := strings.ToLower(exported[:i]) + exported[i:]
+	switch {
+	case token.IsKeyword(variable), variable == "input", variable == "req", variable == "d",
+		variable == "err", variable == "payload", variable == "query", variable == "strconv",
+		variable == "fmt", variable == "errors", variable == "http", variable == "propre":
+		return variable + "Value"
+	}
+
+	return variable
+}
+
+func isUpper(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+func newFile(data *endpoint, tmpl fileTemplate, dirs map[string]string, module string) (*file, error) {
+	f := &file{
+		endpoint:   data,
+		Package:    packageName(dirs[tmpl.role], module),
+		qualifiers: make(map[string]string),
+	}
+
+	packages := make(map[string]string)
+	for _, role := range tmpl.refs {
+		if dirs[role] == dirs[tmpl.role] && !tmpl.test {
+			continue
+		}
+
+		name := packageName(dirs[role], module)
+		importPath := path.Join(module, dirs[role])
+		if other, ok := packages[name]; ok && other != importPath {
+			return nil, fmt.Errorf("%w: the packages %s and %s have the same name", ErrInvalidLayout, other, importPath)
+		}
+
+		packages[name] = importPath
+		f.qualifiers[role] = name + "."
+		if reservedNames[name] {
+			f.qualifiers[role] = name + "pkg."
+		}
+	}
+
+	for name, importPath := range packages {
+		if reservedNames[name] {
+			importPath = name + "pkg " + strconv.Quote(importPath)
+		} else {
+			importPath = strconv.Quote(importPath)
+		}
+
+		f.Imports = append(f.Imports, importPath)
+	}
+
+	sort.Strings(f.Imports)
+
+	if tmpl.test {
+		f.Package += "_test"
+	}
+
+	return f, nil
+}
+
+// packageName returns the name of the package in the directory.
+func packageName(dir, module string) string {
+	name := path.Base(dir)
+	if dir == "." {
+		name = path.Base(module)
+	}
+
+	return strings.ToLower(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// Write writes the files in the root directory of the module. It returns an
+// error wrapping [ErrFileExists] without writing anything if a file already
+// exists, unless overwrite is true.
+func Write(root string, files []File, overwrite bool) error {
+	if !overwrite {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(root, f.Path)); err == nil {
+				return fmt.Errorf("%w: %s", ErrFileExists, f.Path)
+			}
+		}
+	}
+
+	for _, f := range files {
+		target := filepath.Join(root, f.Path)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(target, f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindModule returns the root directory and the path of the Go module the
+// directory belongs to, read from the closest go.mod file.
+func FindModule(dir string) (string, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return dir, strings.Trim(strings.TrimSpace(module), `"`), nil
+				}
+			}
+
+			return "", "", fmt.Errorf("no module path in %s", filepath.Join(dir, "go.mod"))
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errors.New("no go.mod file found, run the command in a Go module")
+		}
+
+		dir = parent
+	}
+}
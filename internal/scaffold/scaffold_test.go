@@ -0,0 +1,439 @@
+package scaffold_test
+
+import (
+	"bytes"
+	"errors"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre/internal/scaffold"
+)
+
+func TestParseField(t *testing.T) {
+	type testCase struct {
+		field       string
+		expected    scaffold.Field
+		expectedErr error
+	}
+
+	testCases := map[string]testCase{
+		"name and type": {
+			field:    "title:string",
+			expected: scaffold.Field{Name: "title", Type: "string"},
+		},
+		"name, type and source": {
+			field:    "id:int:path",
+			expected: scaffold.Field{Name: "id", Type: "int", Source: scaffold.SourcePath},
+		},
+		"missing type": {
+			field:       "title",
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"too many parts": {
+			field:       "id:int:path:other",
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			field, err := scaffold.ParseField(tc.field)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+
+			if field != tc.expected {
+				t.Fatalf("expected the field %+v, got %+v", tc.expected, field)
+			}
+		})
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	spec := `name: UpdateTodo
+method: PUT
+path: /todos/{id}
+fields:
+  - id:int
+  - name: title
+    type: string
+`
+
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := scaffold.LoadSpec(path)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	expected := scaffold.Spec{
+		Name:   "UpdateTodo",
+		Method: "PUT",
+		Path:   "/todos/{id}",
+		Fields: []scaffold.Field{{Name: "id", Type: "int"}, {Name: "title", Type: "string"}},
+	}
+
+	if !reflect.DeepEqual(loaded, expected) {
+		t.Fatalf("expected the spec %+v, got %+v", expected, loaded)
+	}
+}
+
+func TestGenerateRejectsInvalidSpecsAndLayouts(t *testing.T) {
+	type testCase struct {
+		spec        scaffold.Spec
+		layout      scaffold.Layout
+		expectedErr error
+	}
+
+	sharedName := scaffold.DefaultLayout()
+	sharedName.View = "internal/other/usecase"
+
+	escaping := scaffold.DefaultLayout()
+	escaping.Router = "../router"
+
+	testCases := map[string]testCase{
+		"the name is not in PascalCase": {
+			spec:        scaffold.Spec{Name: "createTodo", Path: "/todos"},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"the path is relative": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "todos"},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"the type is not supported": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "/todos", Fields: []scaffold.Field{{Name: "at", Type: "time.Time"}}},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"the path has no wildcard for the field": {
+			spec:        scaffold.Spec{Name: "GetTodo", Path: "/todos", Fields: []scaffold.Field{{Name: "id", Type: "int", Source: "path"}}},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"a field is declared twice": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "/todos", Fields: []scaffold.Field{{Name: "due_date", Type: "string"}, {Name: "dueDate", Type: "string"}}},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"the source is not supported": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "/todos", Fields: []scaffold.Field{{Name: "title", Type: "string", Source: "header"}}},
+			expectedErr: scaffold.ErrInvalidSpec,
+		},
+		"two imported packages have the same name": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "/todos"},
+			layout:      sharedName,
+			expectedErr: scaffold.ErrInvalidLayout,
+		},
+		"a directory is outside of the module": {
+			spec:        scaffold.Spec{Name: "CreateTodo", Path: "/todos"},
+			layout:      escaping,
+			expectedErr: scaffold.ErrInvalidLayout,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			layout := tc.layout
+			if layout == (scaffold.Layout{}) {
+				layout = scaffold.DefaultLayout()
+			}
+
+			_, err := scaffold.Generate(tc.spec, layout, "example.com/app")
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateFollowsTheLayout(t *testing.T) {
+	spec := scaffold.Spec{
+		Name:   "UpdateTodo",
+		Method: "put",
+		Path:   "/todos/{id}",
+		Fields: []scaffold.Field{{Name: "id", Type: "int"}, {Name: "title", Type: "string"}},
+	}
+
+	layout := scaffold.Layout{
+		UseCase:   "internal/todo",
+		Decoder:   "internal/http/decoder",
+		Presenter: "internal/http/presenter",
+		View:      "internal/http/view",
+		Router:    "internal/http",
+	}
+
+	files, err := scaffold.Generate(spec, layout, "example.com/app")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	contents := make(map[string]string, len(files))
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		contents[f.Path] = string(f.Content)
+		paths = append(paths, f.Path)
+	}
+
+	expectedPaths := []string{
+		"internal/todo/update_todo.go",
+		"internal/todo/update_todo_test.go",
+		"internal/http/decoder/update_todo_decoder.go",
+		"internal/http/decoder/update_todo_decoder_test.go",
+		"internal/http/view/update_todo_view.go",
+		"internal/http/presenter/update_todo_presenter.go",
+		"internal/http/presenter/update_todo_presenter_test.go",
+		"internal/http/update_todo_route.go",
+		"internal/http/update_todo_route_test.go",
+	}
+
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Fatalf("expected the files %q, got %q", expectedPaths, paths)
+	}
+
+	expectedSnippets := map[string][]string{
+		"internal/todo/update_todo.go": {
+			"package todo",
+			"if input.Error != nil {",
+		},
+		"internal/http/decoder/update_todo_decoder.go": {
+			`"example.com/app/internal/todo"`,
+			`id, err := strconv.Atoi(req.PathValue("id"))`,
+			"Title string `json:\"title\"`",
+			"func (d *UpdateTodoRequestDecoder) Decode(req *http.Request) todo.UpdateTodoInput {",
+		},
+		"internal/http/update_todo_route.go": {
+			"package http",
+			`mux.Handle("PUT /todos/{id}", propre.NewHTTPHandler(`,
+		},
+		"internal/http/update_todo_route_test.go": {
+			`httppkg "example.com/app/internal/http"`,
+			`Method("PUT", "/todos/1")`,
+		},
+	}
+
+	for path, snippets := range expectedSnippets {
+		for _, snippet := range snippets {
+			if !strings.Contains(contents[path], snippet) {
+				t.Fatalf("%s should contain %q:\n%s", path, snippet, contents[path])
+			}
+		}
+	}
+}
+
+func TestWriteDoesNotOverwriteTheFiles(t *testing.T) {
+	root := t.TempDir()
+	files := []scaffold.File{
+		{Path: "internal/usecase/a.go", Content: []byte("package usecase\n")},
+		{Path: "internal/usecase/b.go", Content: []byte("package usecase\n")},
+	}
+
+	if err := scaffold.Write(root, files[1:], false); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	if err := scaffold.Write(root, files, false); !errors.Is(err, scaffold.ErrFileExists) {
+		t.Fatalf("expected the error %v, got %v", scaffold.ErrFileExists, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, files[0].Path)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("no file should be written if one exists, got %v", err)
+	}
+
+	if err := scaffold.Write(root, files, true); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+}
+
+// generatedSpecs and generatedLayouts cover the sources and the types of the
+// fields, and the layouts sharing or splitting the packages.
+func generatedSpecs() []scaffold.Spec {
+	return []scaffold.Spec{
+		{
+			Name:   "UpdateTodo",
+			Method: "PATCH",
+			Path:   "/todos/{id}/{slug}",
+			Fields: []scaffold.Field{
+				{Name: "id", Type: "int64"},
+				{Name: "title", Type: "string"},
+				{Name: "done", Type: "bool"},
+				{Name: "notify", Type: "bool", Source: scaffold.SourceQuery},
+				{Name: "type", Type: "string", Source: scaffold.SourceQuery},
+			},
+		},
+		{
+			Name:   "ListTodos",
+			Method: "GET",
+			Path:   "/todos",
+			Fields: []scaffold.Field{{Name: "page", Type: "int"}, {Name: "min_score", Type: "float64"}},
+		},
+		{Name: "DeleteTodo", Method: "DELETE", Path: "/todos/{id...}"},
+	}
+}
+
+func generatedLayouts() []scaffold.Layout {
+	return []scaffold.Layout{
+		scaffold.DefaultLayout(),
+		{UseCase: "internal/todo", Decoder: "internal/http/decoder", Presenter: "internal/http/presenter", View: "internal/http/view", Router: "internal/http"},
+		{UseCase: "internal/flat", Decoder: "internal/flat", Presenter: "internal/flat", View: "internal/flat", Router: "internal/flat"},
+	}
+}
+
+func generateAll(t *testing.T) []scaffold.File {
+	var files []scaffold.File
+	for _, layout := range generatedLayouts() {
+		for _, spec := range generatedSpecs() {
+			generated, err := scaffold.Generate(spec, layout, "example.com/app")
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+
+			files = append(files, generated...)
+		}
+	}
+
+	return files
+}
+
+// generatedImporter type-checks the generated packages and the packages of the
+// propre module from their sources, the standard library comes from its export data.
+type generatedImporter struct {
+	fset       *token.FileSet
+	propreRoot string
+	generated  map[string][]*ast.File
+	packages   map[string]*types.Package
+	std        types.Importer
+}
+
+func (im *generatedImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := im.packages[importPath]; ok {
+		return pkg, nil
+	}
+
+	files, ok := im.generated[importPath]
+	if !ok {
+		rel, ok := strings.CutPrefix(importPath, "github.com/cyb3rd4d/propre")
+		if !ok {
+			return im.std.Import(importPath)
+		}
+
+		var err error
+		if files, err = im.parseDir(filepath.Join(im.propreRoot, rel)); err != nil {
+			return nil, err
+		}
+	}
+
+	conf := types.Config{Importer: im}
+	pkg, err := conf.Check(importPath, im.fset, files, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	im.packages[importPath] = pkg
+	return pkg, nil
+}
+
+func (im *generatedImporter) parseDir(dir string) ([]*ast.File, error) {
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*ast.File, 0, len(buildPkg.GoFiles))
+	for _, name := range buildPkg.GoFiles {
+		file, err := parser.ParseFile(im.fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func TestGeneratedCodeTypeChecks(t *testing.T) {
+	propreRoot, _, err := scaffold.FindModule(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	im := &generatedImporter{
+		fset:       fset,
+		propreRoot: propreRoot,
+		generated:  make(map[string][]*ast.File),
+		packages:   make(map[string]*types.Package),
+		std:        importer.Default(),
+	}
+
+	for _, file := range generateAll(t) {
+		parsed, err := parser.ParseFile(fset, file.Path, file.Content, 0)
+		if err != nil {
+			t.Fatalf("unexpected error %s", err)
+		}
+
+		importPath := path.Join("example.com/app", path.Dir(file.Path))
+		if strings.HasSuffix(parsed.Name.Name, "_test") {
+			importPath += "_test"
+		}
+
+		im.generated[importPath] = append(im.generated[importPath], parsed)
+	}
+
+	for importPath := range im.generated {
+		if _, err := im.Import(importPath); err != nil {
+			t.Fatalf("the package %s does not type check: %s", importPath, err)
+		}
+	}
+}
+
+func TestGeneratedCodeBuildsAndPasses(t *testing.T) {
+	if os.Getenv("PROPRE_SCAFFOLD_E2E") == "" {
+		t.Skip("set PROPRE_SCAFFOLD_E2E=1 to build and test the generated code with the go command")
+	}
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not available")
+	}
+
+	propreRoot, _, err := scaffold.FindModule(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	goMod := "module example.com/app\n\ngo 1.22.0\n\nrequire github.com/cyb3rd4d/propre v0.0.0\n\n" +
+		"replace github.com/cyb3rd4d/propre => " + propreRoot + "\n"
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := scaffold.Write(root, generateAll(t), false); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	for _, args := range [][]string{{"mod", "tidy"}, {"vet", "./..."}, {"test", "./..."}} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = root
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("go %s failed: %s\n%s", strings.Join(args, " "), err, output.String())
+		}
+	}
+}
@@ -0,0 +1,279 @@
+// Package scaffold generates the files of a new endpoint: the input and the
+// output of its use case, the interactor, the request decoder, the presenter,
+// the view models, the route registration and their tests.
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrInvalidSpec is returned when the spec of an endpoint is invalid.
+	ErrInvalidSpec = errors.New("invalid endpoint spec")
+
+	// ErrInvalidLayout is returned when the package layout is invalid.
+	ErrInvalidLayout = errors.New("invalid package layout")
+
+	namePattern     = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	fieldPattern    = regexp.MustCompile(`^[a-z][A-Za-z0-9_]*$`)
+	wildcardPattern = regexp.MustCompile(`\{([^{}.]+)(\.\.\.)?\}`)
+
+	fieldTypes = map[string]bool{"string": true, "bool": true, "int": true, "int64": true, "float64": true}
+)
+
+// The sources of the field values in the request.
+const (
+	SourceBody  = "body"
+	SourcePath  = "path"
+	SourceQuery = "query"
+)
+
+// Field is a field of the input of the endpoint, read from the request.
+type Field struct {
+	Name   string `yaml:"name"`
+	Type   string `yaml:"type"`
+	Source string `yaml:"source"`
+}
+
+// ParseField parses a field written as "name:type[:source]", like "title:string"
+// or "id:int:path". The type is one of string, bool, int, int64 and float64.
+// Without source, the field is read from the path if the path has a wildcard of
+// the same name, from the body for POST, PUT and PATCH, and from the query otherwise.
+func ParseField(s string) (Field, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Field{}, fmt.Errorf("%w: the field %q must be written as name:type[:source]", ErrInvalidSpec, s)
+	}
+
+	field := Field{Name: parts[0], Type: parts[1]}
+	if len(parts) == 3 {
+		field.Source = parts[2]
+	}
+
+	return field, nil
+}
+
+// Spec describes an endpoint to generate.
+type Spec struct {
+	// Name is the name of the use case in PascalCase, like "CreateTodo".
+	Name string `yaml:"name"`
+	// Method is the HTTP method of the route.
+	Method string `yaml:"method"`
+	// Path is the path of the route, in the syntax of [http.ServeMux].
+	Path string `yaml:"path"`
+	// Fields are the fields of the input.
+	Fields []Field `yaml:"fields"`
+}
+
+// LoadSpec reads a spec from a YAML file. The fields can be written either as
+// mappings or in the short syntax of [ParseField]:
+//
+//	name: CreateTodo
+//	method: POST
+//	path: /todos
+//	fields:
+//	  - title:string
+//	  - name: done
+//	    type: bool
+func LoadSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	var raw struct {
+		Name   string      `yaml:"name"`
+		Method string      `yaml:"method"`
+		Path   string      `yaml:"path"`
+		Fields []yaml.Node `yaml:"fields"`
+	}
+
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Spec{}, fmt.Errorf("%w caused by %w", ErrInvalidSpec, err)
+	}
+
+	spec := Spec{Name: raw.Name, Method: raw.Method, Path: raw.Path}
+	for _, node := range raw.Fields {
+		var field Field
+		if node.Kind == yaml.ScalarNode {
+			field, err = ParseField(node.Value)
+		} else {
+			err = node.Decode(&field)
+		}
+
+		if err != nil {
+			return Spec{}, fmt.Errorf("%w caused by %w", ErrInvalidSpec, err)
+		}
+
+		spec.Fields = append(spec.Fields, field)
+	}
+
+	return spec, nil
+}
+
+// normalize checks the spec and returns a copy with the default method, the
+// sources of the fields and the fields of the undeclared path wildcards.
+func (s Spec) normalize() (Spec, error) {
+	if !namePattern.MatchString(s.Name) {
+		return s, fmt.Errorf("%w: the name %q must be in PascalCase", ErrInvalidSpec, s.Name)
+	}
+
+	s.Method = strings.ToUpper(s.Method)
+	if s.Method == "" {
+		s.Method = http.MethodPost
+	}
+
+	if !strings.HasPrefix(s.Path, "/") {
+		return s, fmt.Errorf("%w: the path %q must start with /", ErrInvalidSpec, s.Path)
+	}
+
+	wildcards := make(map[string]bool)
+	for _, match := range wildcardPattern.FindAllStringSubmatch(s.Path, -1) {
+		wildcards[match[1]] = true
+	}
+
+	defaultSource := SourceQuery
+	switch s.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		defaultSource = SourceBody
+	}
+
+	declared := make(map[string]bool)
+	fields := make([]Field, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		if !fieldPattern.MatchString(field.Name) {
+			return s, fmt.Errorf("%w: the field name %q must be in camelCase or snake_case", ErrInvalidSpec, field.Name)
+		}
+
+		if declared[exportedName(field.Name)] {
+			return s, fmt.Errorf("%w: the field %q is declared twice", ErrInvalidSpec, field.Name)
+		}
+
+		if !fieldTypes[field.Type] {
+			return s, fmt.Errorf("%w: the type %q of the field %q is not supported", ErrInvalidSpec, field.Type, field.Name)
+		}
+
+		switch field.Source {
+		case "":
+			field.Source = defaultSource
+			if wildcards[field.Name] {
+				field.Source = SourcePath
+			}
+		case SourcePath:
+			if !wildcards[field.Name] {
+				return s, fmt.Errorf("%w: the path %q has no wildcard {%s}", ErrInvalidSpec, s.Path, field.Name)
+			}
+		case SourceBody, SourceQuery:
+		default:
+			return s, fmt.Errorf("%w: the source %q of the field %q is not supported", ErrInvalidSpec, field.Source, field.Name)
+		}
+
+		declared[exportedName(field.Name)] = true
+		fields = append(fields, field)
+	}
+
+	for _, match := range wildcardPattern.FindAllStringSubmatch(s.Path, -1) {
+		if !declared[exportedName(match[1])] {
+			declared[exportedName(match[1])] = true
+			fields = append(fields, Field{Name: match[1], Type: "string", Source: SourcePath})
+		}
+	}
+
+	s.Fields = fields
+	return s, nil
+}
+
+// Layout sets the directories of the generated packages, relative to the root
+// of the module. Several components can share the same directory.
+type Layout struct {
+	UseCase   string `yaml:"usecase"`
+	Decoder   string `yaml:"decoder"`
+	Presenter string `yaml:"presenter"`
+	View      string `yaml:"view"`
+	Router    string `yaml:"router"`
+}
+
+// DefaultLayout puts the use cases in internal/usecase and the HTTP adapters in
+// internal/api.
+func DefaultLayout() Layout {
+	return Layout{
+		UseCase:   "internal/usecase",
+		Decoder:   "internal/api",
+		Presenter: "internal/api",
+		View:      "internal/api",
+		Router:    "internal/api",
+	}
+}
+
+// LoadLayout reads a layout from a YAML file, the missing directories are the
+// ones of [DefaultLayout]:
+//
+//	usecase: internal/todo
+//	decoder: internal/http/decoder
+//	presenter: internal/http/presenter
+//	view: internal/http/view
+//	router: internal/http
+func LoadLayout(path string) (Layout, error) {
+	layout := DefaultLayout()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return layout, err
+	}
+
+	if err := yaml.Unmarshal(data, &layout); err != nil {
+		return layout, fmt.Errorf("%w caused by %w", ErrInvalidLayout, err)
+	}
+
+	return layout, nil
+}
+
+// snakeCase converts a PascalCase or camelCase name, like "CreateTodo", to
+// snake_case.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// words converts a PascalCase name to lower case words, like "create todo".
+func words(name string) string {
+	return strings.ReplaceAll(snakeCase(name), "_", " ")
+}
+
+// exportedName converts a camelCase or snake_case field name to an exported
+// Go identifier, like "due_date" to "DueDate". The common initialisms are upper cased.
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snakeCase(name), "_") {
+		switch part {
+		case "":
+		case "id", "url", "uri", "api", "http", "json", "xml", "uuid", "ip":
+			b.WriteString(strings.ToUpper(part))
+		default:
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+
+	return b.String()
+}
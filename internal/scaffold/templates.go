@@ -0,0 +1,481 @@
+package scaffold
+
+import (
+	"fmt"
+	"text/template"
+)
+
+var templateFuncs = template.FuncMap{
+	// tag returns a struct tag with a single key, like `json:"title"`.
+	"tag": func(key, value string) string {
+		return fmt.Sprintf("`%s:%q`", key, value)
+	},
+}
+
+func newTemplate(name, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(templateFuncs).Parse(importsTemplate + text))
+}
+
+const importsTemplate = `{{define "imports"}}{{if .Imports}}
+{{end}}{{range .Imports}}
+	{{.}}{{end}}{{end}}`
+
+var useCaseTemplate = newTemplate("usecase", `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Err{{.Name}}InvalidInput is returned by the {{.Name}} use case when the
+// request cannot be decoded.
+var Err{{.Name}}InvalidInput = errors.New("{{.Words}}: invalid input")
+
+// {{.Name}}Input is the input of the {{.Name}} use case, either the data read
+// from the request or the decoding error.
+type {{.Name}}Input struct {
+	Data struct {
+{{- range .Fields}}
+		{{.GoName}} {{.Type}}
+{{- end}}
+	}
+	Error error
+}
+
+// {{.Name}}Output is the output of the {{.Name}} use case, either the data
+// returned to the client or the error.
+type {{.Name}}Output struct {
+	Data struct {
+{{- range .Fields}}
+		{{.GoName}} {{.Type}}
+{{- end}}
+	}
+	Error error
+}
+
+// {{.Name}}Interactor implements the {{.Name}} use case.
+type {{.Name}}Interactor struct{}
+
+// New{{.Name}}Interactor builds the interactor of the {{.Name}} use case.
+func New{{.Name}}Interactor() *{{.Name}}Interactor {
+	return &{{.Name}}Interactor{}
+}
+
+// Handle checks the input error first, then applies the business rules.
+func (i *{{.Name}}Interactor) Handle(ctx context.Context, input {{.Name}}Input) {{.Name}}Output {
+	var output {{.Name}}Output
+	if input.Error != nil {
+		output.Error = fmt.Errorf("%w caused by %w", Err{{.Name}}InvalidInput, input.Error)
+		return output
+	}
+
+	// TODO: apply the business rules of the use case.
+{{- range .Fields}}
+	output.Data.{{.GoName}} = input.Data.{{.GoName}}
+{{- end}}
+
+	return output
+}
+`)
+
+var useCaseTestTemplate = newTemplate("usecase_test", `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"testing"
+{{template "imports" .}}
+)
+
+func Test{{.Name}}Interactor(t *testing.T) {
+	type testCase struct {
+		input       {{.Q "usecase"}}{{.Name}}Input
+		expectedErr error
+	}
+
+	var valid {{.Q "usecase"}}{{.Name}}Input
+{{- range .Fields}}
+	valid.Data.{{.GoName}} = {{.Sample}}
+{{- end}}
+
+	testCases := map[string]testCase{
+		"the decoding errors are returned": {
+			input:       {{.Q "usecase"}}{{.Name}}Input{Error: errors.New("decoding error")},
+			expectedErr: {{.Q "usecase"}}Err{{.Name}}InvalidInput,
+		},
+		"a valid input is handled": {
+			input: valid,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			interactor := {{.Q "usecase"}}New{{.Name}}Interactor()
+			output := interactor.Handle(context.Background(), tc.input)
+			if !errors.Is(output.Error, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, output.Error)
+			}
+		})
+	}
+}
+`)
+
+var decoderTemplate = newTemplate("decoder", `package {{.Package}}
+
+import (
+	"errors"
+{{- if or (.FieldsFrom "body") .Parses}}
+	"fmt"
+{{- end}}
+	"net/http"
+{{- if .Parses}}
+	"strconv"
+{{- end}}
+{{- if .FieldsFrom "body"}}
+
+	"github.com/cyb3rd4d/propre"
+{{- end}}
+{{template "imports" .}}
+)
+
+// Err{{.Name}}RequestDecoding is the error of the {{.Words}} requests which
+// cannot be decoded.
+var Err{{.Name}}RequestDecoding = errors.New("{{.Words}} request decoding error")
+{{- if .FieldsFrom "body"}}
+
+// {{.Name}}Payload is the body of the {{.Words}} requests.
+type {{.Name}}Payload struct {
+{{- range .FieldsFrom "body"}}
+	{{.GoName}} {{.Type}} {{tag "json" .Name}}
+{{- end}}
+}
+
+// Validate implements [propre.Validatable].
+func (p {{.Name}}Payload) Validate() error {
+	// TODO: validate the payload.
+	return nil
+}
+{{- end}}
+
+// {{.Name}}RequestDecoder reads the input of the {{.Name}} use case from the requests.
+type {{.Name}}RequestDecoder struct {
+{{- if .FieldsFrom "body"}}
+	extractor *propre.RequestPayloadExtractor[{{.Name}}Payload]
+{{- end}}
+}
+
+// New{{.Name}}RequestDecoder builds the request decoder of the {{.Name}} use case.
+func New{{.Name}}RequestDecoder() *{{.Name}}RequestDecoder {
+	return &{{.Name}}RequestDecoder{
+{{- if .FieldsFrom "body"}}
+		extractor: propre.NewRequestPayloadExtractor(
+			propre.JSONDecoder,
+			propre.WithReflectedJSONSchema[{{.Name}}Payload](),
+		),
+{{- end}}
+	}
+}
+
+// Decode implements [propre.RequestDecoder].
+func (d *{{.Name}}RequestDecoder) Decode(req *http.Request) {{.Q "usecase"}}{{.Name}}Input {
+	var input {{.Q "usecase"}}{{.Name}}Input
+{{- range .FieldsFrom "path"}}
+{{- if .Parse}}
+
+	{{.Var}}, err := {{printf .Parse (printf "req.PathValue(%q)" .Name)}}
+	if err != nil {
+		input.Error = fmt.Errorf("%w: invalid path value {{.Name}} caused by %w", Err{{$.Name}}RequestDecoding, err)
+		return input
+	}
+
+	input.Data.{{.GoName}} = {{.Var}}
+{{- else}}
+
+	input.Data.{{.GoName}} = req.PathValue({{printf "%q" .Name}})
+{{- end}}
+{{- end}}
+{{- if .FieldsFrom "query"}}
+
+	query := req.URL.Query()
+{{- range .FieldsFrom "query"}}
+{{- if .Parse}}
+	if query.Has({{printf "%q" .Name}}) {
+		{{.Var}}, err := {{printf .Parse (printf "query.Get(%q)" .Name)}}
+		if err != nil {
+			input.Error = fmt.Errorf("%w: invalid query value {{.Name}} caused by %w", Err{{$.Name}}RequestDecoding, err)
+			return input
+		}
+
+		input.Data.{{.GoName}} = {{.Var}}
+	}
+{{- else}}
+	input.Data.{{.GoName}} = query.Get({{printf "%q" .Name}})
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .FieldsFrom "body"}}
+
+	payload, err := d.extractor.Extract(req)
+	if err != nil {
+		input.Error = fmt.Errorf("%w caused by %w", Err{{.Name}}RequestDecoding, err)
+		return input
+	}
+{{range .FieldsFrom "body"}}
+	input.Data.{{.GoName}} = payload.{{.GoName}}
+{{- end}}
+{{- end}}
+
+	return input
+}
+`)
+
+var decoderTestTemplate = newTemplate("decoder_test", `package {{.Package}}
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+{{template "imports" .}}
+)
+
+func Test{{.Name}}RequestDecoder(t *testing.T) {
+	type testCase struct {
+		target      string
+		body        string
+		expectedErr error
+	}
+
+	testCases := map[string]testCase{
+		"a valid request is decoded": {
+			target: {{printf "%q" .Target}},
+			body:   {{.BodyLiteral}},
+		},
+{{- if .FieldsFrom "body"}}
+		"a malformed body is rejected": {
+			target:      {{printf "%q" .Target}},
+			body:        "{",
+			expectedErr: {{.Q "decoder"}}Err{{.Name}}RequestDecoding,
+		},
+{{- end}}
+{{- range .FieldsFrom "path"}}
+{{- if .Parse}}
+		"an invalid {{.Name}} is rejected": {
+			target:      {{printf "%q" .InvalidTarget}},
+			body:        {{$.BodyLiteral}},
+			expectedErr: {{$.Q "decoder"}}Err{{$.Name}}RequestDecoding,
+		},
+{{- end}}
+{{- end}}
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var input {{.Q "usecase"}}{{.Name}}Input
+			mux := http.NewServeMux()
+			mux.HandleFunc({{printf "%q" .Pattern}}, func(rw http.ResponseWriter, req *http.Request) {
+				input = {{.Q "decoder"}}New{{.Name}}RequestDecoder().Decode(req)
+			})
+
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest({{printf "%q" .Method}}, tc.target, strings.NewReader(tc.body)))
+			if !errors.Is(input.Error, tc.expectedErr) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, input.Error)
+			}
+		})
+	}
+}
+`)
+
+var viewTemplate = newTemplate("view", `package {{.Package}}
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// {{.Name}}View is the view model of the successful {{.Words}} responses.
+type {{.Name}}View struct {
+{{- range .Fields}}
+	{{.GoName}} {{.Type}} {{tag "json" .Name}}
+{{- end}}
+}
+
+// ContentType implements [propre.HTTPSendable].
+func (v {{.Name}}View) ContentType(context.Context) string {
+	return "application/json"
+}
+
+// Encode implements [propre.HTTPSendable], the view is the data member of the body.
+func (v {{.Name}}View) Encode(context.Context) ([]byte, error) {
+	return json.Marshal(map[string]any{"data": v})
+}
+
+// StatusCode implements [propre.HTTPSendable].
+func (v {{.Name}}View) StatusCode(context.Context) int {
+	return {{.SuccessStatus}}
+}
+
+// {{.Name}}ErrorView is the view model of the failed {{.Words}} responses.
+type {{.Name}}ErrorView struct {
+	Status  int    {{tag "json" "-"}}
+	Message string {{tag "json" "message"}}
+}
+
+// ContentType implements [propre.HTTPSendable].
+func (v {{.Name}}ErrorView) ContentType(context.Context) string {
+	return "application/json"
+}
+
+// Encode implements [propre.HTTPSendable], the view is the error member of the body.
+func (v {{.Name}}ErrorView) Encode(context.Context) ([]byte, error) {
+	return json.Marshal(map[string]any{"error": v})
+}
+
+// StatusCode implements [propre.HTTPSendable].
+func (v {{.Name}}ErrorView) StatusCode(context.Context) int {
+	return v.Status
+}
+`)
+
+var presenterTemplate = newTemplate("presenter", `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/cyb3rd4d/propre"
+{{template "imports" .}}
+)
+
+// {{.Name}}Presenter sends the responses of the {{.Name}} use case.
+type {{.Name}}Presenter struct {
+	response      *propre.HTTPResponse[{{.Q "view"}}{{.Name}}View]
+	errorResponse *propre.HTTPResponse[{{.Q "view"}}{{.Name}}ErrorView]
+}
+
+// New{{.Name}}Presenter builds the presenter of the {{.Name}} use case.
+func New{{.Name}}Presenter() *{{.Name}}Presenter {
+	return &{{.Name}}Presenter{
+		response:      propre.NewHTTPResponse[{{.Q "view"}}{{.Name}}View](),
+		errorResponse: propre.NewHTTPResponse[{{.Q "view"}}{{.Name}}ErrorView](),
+	}
+}
+
+// Present implements [propre.Presenter].
+func (p *{{.Name}}Presenter) Present(ctx context.Context, rw http.ResponseWriter, output {{.Q "usecase"}}{{.Name}}Output) {
+	if output.Error != nil {
+		p.errorResponse.Send(ctx, rw, p.errorView(output.Error))
+		return
+	}
+
+	p.response.Send(ctx, rw, {{.Q "view"}}{{.Name}}View{
+{{- range .Fields}}
+		{{.GoName}}: output.Data.{{.GoName}},
+{{- end}}
+	})
+}
+
+// errorView maps the errors of the use case to the responses.
+func (p *{{.Name}}Presenter) errorView(err error) {{.Q "view"}}{{.Name}}ErrorView {
+	switch {
+	case errors.Is(err, {{.Q "usecase"}}Err{{.Name}}InvalidInput):
+		return {{.Q "view"}}{{.Name}}ErrorView{Status: http.StatusBadRequest, Message: "invalid request"}
+	default:
+		return {{.Q "view"}}{{.Name}}ErrorView{Status: http.StatusInternalServerError, Message: "internal error"}
+	}
+}
+`)
+
+var presenterTestTemplate = newTemplate("presenter_test", `package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+{{template "imports" .}}
+)
+
+func Test{{.Name}}Presenter(t *testing.T) {
+	type testCase struct {
+		output         {{.Q "usecase"}}{{.Name}}Output
+		expectedStatus int
+	}
+
+	testCases := map[string]testCase{
+		"a successful output is presented": {
+			expectedStatus: {{.SuccessStatus}},
+		},
+		"an invalid input is a bad request": {
+			output:         {{.Q "usecase"}}{{.Name}}Output{Error: {{.Q "usecase"}}Err{{.Name}}InvalidInput},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"an unexpected error is an internal error": {
+			output:         {{.Q "usecase"}}{{.Name}}Output{Error: errors.New("unexpected error")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			{{.Q "presenter"}}New{{.Name}}Presenter().Present(context.Background(), rw, tc.output)
+			if rw.Code != tc.expectedStatus {
+				t.Fatalf("expected the status code %d, got %d", tc.expectedStatus, rw.Code)
+			}
+		})
+	}
+}
+`)
+
+var routerTemplate = newTemplate("router", `package {{.Package}}
+
+import (
+	"net/http"
+
+	"github.com/cyb3rd4d/propre"
+{{template "imports" .}}
+)
+
+// Register{{.Name}} registers the {{.Words}} endpoint in the mux, handled by
+// the given use case.
+func Register{{.Name}}(mux *http.ServeMux, useCase propre.UseCaseHandler[{{.Q "usecase"}}{{.Name}}Input, {{.Q "usecase"}}{{.Name}}Output]) {
+	mux.Handle({{printf "%q" .Pattern}}, propre.NewHTTPHandler(
+		{{.Q "decoder"}}New{{.Name}}RequestDecoder(),
+		useCase,
+		{{.Q "presenter"}}New{{.Name}}Presenter(),
+	))
+}
+`)
+
+var routerTestTemplate = newTemplate("router_test", `package {{.Package}}
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cyb3rd4d/propre/propretest"
+{{template "imports" .}}
+)
+
+func TestRegister{{.Name}}(t *testing.T) {
+	useCase := propretest.NewUseCaseHandlerFake[{{.Q "usecase"}}{{.Name}}Input]({{.Q "usecase"}}{{.Name}}Output{})
+	mux := http.NewServeMux()
+	{{.Q "router"}}Register{{.Name}}(mux, useCase)
+
+	propretest.NewRequest(t, mux).
+		Method({{printf "%q" .Method}}, {{printf "%q" .Target}}).
+		WithBody({{.BodyLiteral}}).
+		Send().
+		AssertStatus({{.SuccessStatus}})
+
+	if useCase.Calls() != 1 {
+		t.Fatalf("the use case should be called once, got %d calls", useCase.Calls())
+	}
+}
+`)
@@ -0,0 +1,98 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrJobNotFound is returned by a [JobStore] if the job does not exist or has expired.
+	ErrJobNotFound = errors.New("job not found")
+)
+
+// JobStatus is the state of a [Job].
+type JobStatus string
+
+const (
+	// JobRunning is the status of a job whose use case is still running.
+	JobRunning JobStatus = "running"
+	// JobCompleted is the status of a job whose use case returned an output.
+	JobCompleted JobStatus = "completed"
+	// JobCanceled is the status of a job canceled before its use case returned.
+	JobCanceled JobStatus = "canceled"
+)
+
+// Job is a use case running in the background, created by an [AsyncHandler].
+// Output is the zero value until the job is completed.
+type Job[Output any] struct {
+	ID        string
+	Status    JobStatus
+	Output    Output
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// ExpiresAt is the time after which the store can forget the job,
+	// the zero value means the job never expires.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether the job is expired at the given time.
+func (job Job[Output]) Expired(now time.Time) bool {
+	return !job.ExpiresAt.IsZero() && !now.Before(job.ExpiresAt)
+}
+
+// JobStore is the interface of the storage of the jobs of an [AsyncHandler].
+// Get must return [ErrJobNotFound] if the job does not exist or has expired.
+type JobStore[Output any] interface {
+	Save(ctx context.Context, job Job[Output]) error
+	Get(ctx context.Context, id string) (Job[Output], error)
+}
+
+// InMemoryJobStore is a [JobStore] keeping the jobs in memory. The expired jobs
+// are removed when they are fetched and each time a job is saved.
+type InMemoryJobStore[Output any] struct {
+	mu   sync.Mutex
+	jobs map[string]Job[Output]
+}
+
+// NewInMemoryJobStore builds an empty [InMemoryJobStore].
+func NewInMemoryJobStore[Output any]() *InMemoryJobStore[Output] {
+	return &InMemoryJobStore[Output]{
+		jobs: make(map[string]Job[Output]),
+	}
+}
+
+// Save implements [JobStore].
+func (s *InMemoryJobStore[Output]) Save(ctx context.Context, job Job[Output]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, stored := range s.jobs {
+		if stored.Expired(now) {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Get implements [JobStore].
+func (s *InMemoryJobStore[Output]) Get(ctx context.Context, id string) (Job[Output], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return job, ErrJobNotFound
+	}
+
+	if job.Expired(time.Now()) {
+		delete(s.jobs, id)
+		return Job[Output]{}, ErrJobNotFound
+	}
+
+	return job, nil
+}
@@ -0,0 +1,52 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func TestInMemoryJobStore(t *testing.T) {
+	ctx := context.Background()
+	store := propre.NewInMemoryJobStore[string]()
+
+	err := store.Save(ctx, propre.Job[string]{ID: "some-id", Status: propre.JobCompleted, Output: "some output"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	job, err := store.Get(ctx, "some-id")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if job.Status != propre.JobCompleted || job.Output != "some output" {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	_, err = store.Get(ctx, "unknown-id")
+	if !errors.Is(err, propre.ErrJobNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestInMemoryJobStoreForgetsExpiredJobs(t *testing.T) {
+	ctx := context.Background()
+	store := propre.NewInMemoryJobStore[string]()
+
+	store.Save(ctx, propre.Job[string]{ID: "expired", ExpiresAt: time.Now().Add(-time.Second)})
+	store.Save(ctx, propre.Job[string]{ID: "valid", ExpiresAt: time.Now().Add(time.Hour)})
+
+	_, err := store.Get(ctx, "expired")
+	if !errors.Is(err, propre.ErrJobNotFound) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = store.Get(ctx, "valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,416 @@
+package propre
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+const jsonrpcVersion = "2.0"
+
+var jsonrpcNull = json.RawMessage("null")
+
+// JSONRPCError is a JSON-RPC 2.0 error object. It implements error so the use
+// cases and presenters can return it directly.
+type JSONRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// NewJSONRPCError builds a [JSONRPCError].
+func NewJSONRPCError(code int, message string, data any) *JSONRPCError {
+	return &JSONRPCError{Code: code, Message: message, Data: data}
+}
+
+// Error implements error.
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// JSONRPCRequest is a JSON-RPC 2.0 request object.
+// ID is empty for a notification.
+type JSONRPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// IsNotification reports whether the request is a notification, which has no ID
+// and expects no response.
+func (req *JSONRPCRequest) IsNotification() bool {
+	return len(req.ID) == 0
+}
+
+type jsonrpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// JSONRPCDecoder is the JSON-RPC counterpart of [RequestDecoder]. It checks and
+// extracts the params of a call required by a use case. The produced input can
+// be either an error or the actual data required by the use case.
+type JSONRPCDecoder[Input any] interface {
+	Decode(req *JSONRPCRequest) Input
+}
+
+// JSONRPCWriter is the writer given to the presenters of a [JSONRPCHandler].
+// The data written to it is the raw JSON result of the call, Result can be used
+// to encode a value instead. Error sets the error of the call, which takes
+// precedence over the result.
+type JSONRPCWriter struct {
+	result bytes.Buffer
+	err    error
+}
+
+// Write appends raw JSON to the result of the call.
+func (w *JSONRPCWriter) Write(p []byte) (int, error) {
+	return w.result.Write(p)
+}
+
+// Result encodes the given value as the result of the call.
+func (w *JSONRPCWriter) Result(v any) error {
+	w.result.Reset()
+	return json.NewEncoder(&w.result).Encode(v)
+}
+
+// Error sets the error of the call. A [JSONRPCError] is sent as is, any other
+// error is converted by the error mapper of the [JSONRPCServer].
+func (w *JSONRPCWriter) Error(err error) {
+	w.err = err
+}
+
+// JSONRPCMethod is implemented by anything able to handle a JSON-RPC call.
+// It returns either the raw JSON result or an error.
+type JSONRPCMethod interface {
+	Call(ctx context.Context, req *JSONRPCRequest) (json.RawMessage, error)
+}
+
+// JSONRPCHandler is the JSON-RPC counterpart of [HTTPHandler]. Each method requires:
+//   - a JSON-RPC decoder to transform the params of a call to a use case input,
+//   - a use case handler,
+//   - a presenter to write the result or the error of the call depending on the output.
+//
+// It implements [JSONRPCMethod] to be registered in a [JSONRPCServer].
+type JSONRPCHandler[Input, Output any] struct {
+	jsonrpcDecoder JSONRPCDecoder[Input]
+	useCaseHandler UseCaseHandler[Input, Output]
+	presenter      Presenter[Output, *JSONRPCWriter]
+}
+
+// NewJSONRPCHandler builds a JSONRPCHandler with the given dependencies.
+func NewJSONRPCHandler[Input, Output any](
+	jsonrpcDecoder JSONRPCDecoder[Input],
+	useCaseHandler UseCaseHandler[Input, Output],
+	presenter Presenter[Output, *JSONRPCWriter],
+) *JSONRPCHandler[Input, Output] {
+	return &JSONRPCHandler[Input, Output]{
+		jsonrpcDecoder: jsonrpcDecoder,
+		useCaseHandler: useCaseHandler,
+		presenter:      presenter,
+	}
+}
+
+// Call decodes the params, passes the input to the use case handler and presents
+// the output. It returns the result or the error set by the presenter, or an
+// internal error if the result written by the presenter is not valid JSON.
+func (handler *JSONRPCHandler[Input, Output]) Call(ctx context.Context, req *JSONRPCRequest) (json.RawMessage, error) {
+	input := handler.jsonrpcDecoder.Decode(req)
+	output := handler.useCaseHandler.Handle(ctx, input)
+
+	w := new(JSONRPCWriter)
+	handler.presenter.Present(ctx, w, output)
+	if w.err != nil {
+		return nil, w.err
+	}
+
+	result := bytes.TrimSpace(w.result.Bytes())
+	if len(result) == 0 {
+		return jsonrpcNull, nil
+	}
+
+	if !json.Valid(result) {
+		return nil, NewJSONRPCError(JSONRPCInternalError, "Internal error", nil)
+	}
+
+	return result, nil
+}
+
+// JSONRPCServer is an [http.Handler] serving JSON-RPC 2.0 calls over HTTP POST
+// requests. It supports batches and notifications.
+type JSONRPCServer struct {
+	methods      map[string]JSONRPCMethod
+	errorMapper  func(context.Context, error) *JSONRPCError
+	maxBodySize  int64
+	maxBatchSize int
+	concurrency  int
+}
+
+// JSONRPCServerOpts is the alias for the [JSONRPCServer] builder options.
+type JSONRPCServerOpts func(s *JSONRPCServer)
+
+// WithJSONRPCErrorMapper is a [JSONRPCServer] option to convert the domain errors
+// returned by the methods to JSON-RPC error objects. Errors wrapping a
+// [JSONRPCError] are not passed to the mapper. By default, the other errors
+// are sent as internal errors.
+func WithJSONRPCErrorMapper(mapper func(context.Context, error) *JSONRPCError) JSONRPCServerOpts {
+	return func(s *JSONRPCServer) {
+		s.errorMapper = mapper
+	}
+}
+
+// WithJSONRPCMaxBodySize is a [JSONRPCServer] option to limit the size in bytes
+// of the request body. A bigger body is rejected with a 413 Request Entity Too
+// Large response. The default is 1 MiB.
+func WithJSONRPCMaxBodySize(size int64) JSONRPCServerOpts {
+	return func(s *JSONRPCServer) {
+		s.maxBodySize = size
+	}
+}
+
+// WithJSONRPCMaxBatchSize is a [JSONRPCServer] option to limit the number of
+// calls of a batch. A bigger batch is rejected with an invalid request error.
+// The default is 100.
+func WithJSONRPCMaxBatchSize(size int) JSONRPCServerOpts {
+	return func(s *JSONRPCServer) {
+		s.maxBatchSize = size
+	}
+}
+
+// WithJSONRPCConcurrency is a [JSONRPCServer] option to set the maximum number
+// of calls of a batch handled concurrently. The default is 8.
+func WithJSONRPCConcurrency(concurrency int) JSONRPCServerOpts {
+	return func(s *JSONRPCServer) {
+		s.concurrency = max(concurrency, 1)
+	}
+}
+
+// NewJSONRPCServer returns a [JSONRPCServer] without any method.
+func NewJSONRPCServer(opts ...JSONRPCServerOpts) *JSONRPCServer {
+	server := &JSONRPCServer{
+		methods:      make(map[string]JSONRPCMethod),
+		maxBodySize:  1 << 20,
+		maxBatchSize: 100,
+		concurrency:  8,
+	}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+// Register registers a method under the given name. It panics if a method is
+// already registered with the same name.
+func (server *JSONRPCServer) Register(name string, method JSONRPCMethod) {
+	if _, exists := server.methods[name]; exists {
+		panic(fmt.Sprintf("propre: JSON-RPC method %q already registered", name))
+	}
+
+	server.methods[name] = method
+}
+
+// ServeHTTP allows JSONRPCServer to be used by any HTTP "ServeMux".
+// The calls of a batch are handled concurrently. If a request contains only
+// notifications, a 204 No Content response is sent. A method panicking is
+// reported as an internal error.
+func (server *JSONRPCServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.Header().Set("allow", http.MethodPost)
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, req.Body, server.maxBodySize))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		rw.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err != nil {
+		server.send(rw, jsonrpcErrorResponse(jsonrpcNull, NewJSONRPCError(JSONRPCParseError, "Parse error", err.Error())))
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		server.send(rw, jsonrpcErrorResponse(jsonrpcNull, NewJSONRPCError(JSONRPCParseError, "Parse error", nil)))
+		return
+	}
+
+	if len(body) == 0 || body[0] != '[' {
+		response := server.call(req.Context(), body)
+		if response == nil {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		server.send(rw, response)
+		return
+	}
+
+	var batch []json.RawMessage
+	err = json.Unmarshal(body, &batch)
+	if err != nil || len(batch) == 0 {
+		server.send(rw, jsonrpcErrorResponse(jsonrpcNull, NewJSONRPCError(JSONRPCInvalidRequest, "Invalid Request", nil)))
+		return
+	}
+
+	if server.maxBatchSize > 0 && len(batch) > server.maxBatchSize {
+		data := fmt.Sprintf("%d calls exceeding the limit of %d", len(batch), server.maxBatchSize)
+		server.send(rw, jsonrpcErrorResponse(jsonrpcNull, NewJSONRPCError(JSONRPCInvalidRequest, "Invalid Request", data)))
+		return
+	}
+
+	responses := make([]*jsonrpcResponse, len(batch))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, server.concurrency)
+	for i, raw := range batch {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			responses[i] = server.call(req.Context(), raw)
+		}()
+	}
+
+	wg.Wait()
+
+	sent := make([]*jsonrpcResponse, 0, len(responses))
+	for _, response := range responses {
+		if response != nil {
+			sent = append(sent, response)
+		}
+	}
+
+	if len(sent) == 0 {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	server.send(rw, sent)
+}
+
+// call handles a single request object and returns nil for a notification.
+func (server *JSONRPCServer) call(ctx context.Context, raw json.RawMessage) *jsonrpcResponse {
+	var req JSONRPCRequest
+	err := json.Unmarshal(raw, &req)
+	if err != nil || !isValidJSONRPCRequest(&req) {
+		id := jsonrpcNull
+		if err == nil && isValidJSONRPCID(req.ID) {
+			id = req.ID
+		}
+
+		return jsonrpcErrorResponse(id, NewJSONRPCError(JSONRPCInvalidRequest, "Invalid Request", nil))
+	}
+
+	method, ok := server.methods[req.Method]
+	if !ok {
+		if req.IsNotification() {
+			return nil
+		}
+
+		return jsonrpcErrorResponse(req.ID, NewJSONRPCError(JSONRPCMethodNotFound, "Method not found", nil))
+	}
+
+	result, err := server.invoke(ctx, method, &req)
+	if req.IsNotification() {
+		return nil
+	}
+
+	if err != nil {
+		return jsonrpcErrorResponse(req.ID, server.mapError(ctx, err))
+	}
+
+	return &jsonrpcResponse{JSONRPC: jsonrpcVersion, Result: result, ID: req.ID}
+}
+
+// invoke calls the method and converts a panic to an internal error, so a
+// faulty method cannot crash the server from the goroutine of a batch call.
+func (server *JSONRPCServer) invoke(ctx context.Context, method JSONRPCMethod, req *JSONRPCRequest) (result json.RawMessage, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = nil
+			err = NewJSONRPCError(JSONRPCInternalError, "Internal error", nil)
+		}
+	}()
+
+	return method.Call(ctx, req)
+}
+
+func (server *JSONRPCServer) mapError(ctx context.Context, err error) *JSONRPCError {
+	var jsonrpcErr *JSONRPCError
+	if errors.As(err, &jsonrpcErr) {
+		return jsonrpcErr
+	}
+
+	if server.errorMapper != nil {
+		if mapped := server.errorMapper(ctx, err); mapped != nil {
+			return mapped
+		}
+	}
+
+	return NewJSONRPCError(JSONRPCInternalError, "Internal error", nil)
+}
+
+func (server *JSONRPCServer) send(rw http.ResponseWriter, response any) {
+	rw.Header().Set("content-type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	json.NewEncoder(rw).Encode(response)
+}
+
+func jsonrpcErrorResponse(id json.RawMessage, err *JSONRPCError) *jsonrpcResponse {
+	return &jsonrpcResponse{JSONRPC: jsonrpcVersion, Error: err, ID: id}
+}
+
+func isValidJSONRPCRequest(req *JSONRPCRequest) bool {
+	if req.JSONRPC != jsonrpcVersion || req.Method == "" {
+		return false
+	}
+
+	if !req.IsNotification() && !isValidJSONRPCID(req.ID) {
+		return false
+	}
+
+	if len(req.Params) > 0 && req.Params[0] != '{' && req.Params[0] != '[' {
+		return false
+	}
+
+	return true
+}
+
+func isValidJSONRPCID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return false
+	}
+
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package propre_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type CreateTodoJSONRPCDecoder struct{}
+
+func (decoder *CreateTodoJSONRPCDecoder) Decode(req *propre.JSONRPCRequest) CreateTodoInput {
+	var input CreateTodoInput
+	var params struct {
+		Title string `json:"title"`
+	}
+
+	err := json.Unmarshal(req.Params, &params)
+	if err != nil || params.Title == "" {
+		input.Error = propre.NewJSONRPCError(propre.JSONRPCInvalidParams, "Invalid params", nil)
+		return input
+	}
+
+	input.Data.Title = params.Title
+	return input
+}
+
+type CreateTodoJSONRPCPresenter struct{}
+
+func (p *CreateTodoJSONRPCPresenter) Present(ctx context.Context, w *propre.JSONRPCWriter, output CreateTodoOutput) {
+	if output.Error != nil {
+		// The error is converted by the error mapper of the server.
+		w.Error(output.Error)
+		return
+	}
+
+	w.Result(map[string]any{"id": output.Data.ID, "title": output.Data.Title})
+}
+
+// In this example the use case of the HTTPHandler example is exposed as the
+// JSON-RPC method "todo.create". The server is an http.Handler, it can be
+// registered in the same "ServeMux" as the HTTPHandler.
+func ExampleJSONRPCServer() {
+	useCaseHandler := &CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput]{}
+
+	server := propre.NewJSONRPCServer()
+	server.Register("todo.create", propre.NewJSONRPCHandler(
+		&CreateTodoJSONRPCDecoder{},
+		useCaseHandler,
+		&CreateTodoJSONRPCPresenter{},
+	))
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(
+		`{"jsonrpc":"2.0","method":"todo.create","params":{"title":"New todo title"},"id":1}`,
+	))
+
+	server.ServeHTTP(rw, req)
+
+	fmt.Print(rw.Body.String())
+	// Output:
+	// {"jsonrpc":"2.0","result":{"id":42,"title":"New todo title"},"id":1}
+}
@@ -0,0 +1,327 @@
+package propre_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+var errTodoNotFound = errors.New("todo not found")
+
+type sumInput struct {
+	Data  []int
+	Error error
+}
+
+type sumOutput struct {
+	Data  int
+	Error error
+}
+
+type sumDecoder struct{}
+
+func (d sumDecoder) Decode(req *propre.JSONRPCRequest) sumInput {
+	var input sumInput
+	err := json.Unmarshal(req.Params, &input.Data)
+	if err != nil {
+		input.Error = propre.NewJSONRPCError(propre.JSONRPCInvalidParams, "Invalid params", nil)
+	}
+
+	return input
+}
+
+type sumUseCase struct{}
+
+func (u sumUseCase) Handle(ctx context.Context, input sumInput) sumOutput {
+	var output sumOutput
+	if input.Error != nil {
+		output.Error = input.Error
+		return output
+	}
+
+	for _, n := range input.Data {
+		output.Data += n
+	}
+
+	if output.Data < 0 {
+		output.Error = errTodoNotFound
+	}
+
+	return output
+}
+
+type sumPresenter struct{}
+
+func (p sumPresenter) Present(ctx context.Context, w *propre.JSONRPCWriter, output sumOutput) {
+	if output.Error != nil {
+		w.Error(output.Error)
+		return
+	}
+
+	w.Result(output.Data)
+}
+
+type invalidResultPresenter struct{}
+
+func (p invalidResultPresenter) Present(ctx context.Context, w *propre.JSONRPCWriter, output sumOutput) {
+	w.Write([]byte(`{"sum":`))
+}
+
+type jsonrpcTestCase struct {
+	method             string
+	body               string
+	expectedHTTPStatus int
+	expectedBody       string
+}
+
+func runJSONRPCTestCases(t *testing.T, server *propre.JSONRPCServer, testCases map[string]jsonrpcTestCase) {
+	t.Helper()
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			method := testCase.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest(method, "/rpc", strings.NewReader(testCase.body))
+			server.ServeHTTP(rw, req)
+
+			if rw.Code != testCase.expectedHTTPStatus {
+				t.Fatalf("wrong status code, expected %d, got %d", testCase.expectedHTTPStatus, rw.Code)
+			}
+
+			if testCase.expectedBody == "" {
+				if rw.Body.Len() != 0 {
+					t.Fatalf("unexpected body %s", rw.Body.String())
+				}
+
+				return
+			}
+
+			var expected, got any
+			json.Unmarshal([]byte(testCase.expectedBody), &expected)
+			err := json.Unmarshal(rw.Body.Bytes(), &got)
+			if err != nil {
+				t.Fatalf("could not decode the response body %q: %s", rw.Body.String(), err)
+			}
+
+			expectedJSON, _ := json.Marshal(expected)
+			gotJSON, _ := json.Marshal(got)
+			if string(expectedJSON) != string(gotJSON) {
+				t.Fatalf("unexpected body, expected %s, got %s", expectedJSON, gotJSON)
+			}
+		})
+	}
+}
+
+func TestJSONRPCServer(t *testing.T) {
+	server := propre.NewJSONRPCServer(
+		propre.WithJSONRPCErrorMapper(func(ctx context.Context, err error) *propre.JSONRPCError {
+			if errors.Is(err, errTodoNotFound) {
+				return propre.NewJSONRPCError(404, "Not found", nil)
+			}
+
+			return nil
+		}),
+	)
+
+	server.Register("sum", propre.NewJSONRPCHandler(sumDecoder{}, sumUseCase{}, sumPresenter{}))
+	server.Register("invalid", propre.NewJSONRPCHandler(sumDecoder{}, sumUseCase{}, invalidResultPresenter{}))
+
+	testCases := map[string]jsonrpcTestCase{
+		"call": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[1,2,3],"id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","result":6,"id":1}`,
+		},
+		"call with a string ID": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[1],"id":"abc"}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","result":1,"id":"abc"}`,
+		},
+		"call with a null ID": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[1],"id":null}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","result":1,"id":null}`,
+		},
+		"notification": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[1,2,3]}`,
+			expectedHTTPStatus: http.StatusNoContent,
+		},
+		"invalid params": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":{"a":1},"id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":1}`,
+		},
+		"mapped domain error": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[-1],"id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":404,"message":"Not found"},"id":1}`,
+		},
+		"invalid result": {
+			body:               `{"jsonrpc":"2.0","method":"invalid","params":[1],"id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":1}`,
+		},
+		"method not found": {
+			body:               `{"jsonrpc":"2.0","method":"unknown","id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`,
+		},
+		"parse error": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[1,2`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		"invalid request": {
+			body:               `{"jsonrpc":"1.0","method":"sum","id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":1}`,
+		},
+		"empty batch": {
+			body:               `[]`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		"batch": {
+			body: `[
+				{"jsonrpc":"2.0","method":"sum","params":[1,2],"id":"1"},
+				{"jsonrpc":"2.0","method":"sum","params":[3]},
+				1,
+				{"jsonrpc":"2.0","method":"unknown","id":"2"}
+			]`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody: `[
+				{"jsonrpc":"2.0","result":3,"id":"1"},
+				{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},
+				{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":"2"}
+			]`,
+		},
+		"batch of notifications": {
+			body:               `[{"jsonrpc":"2.0","method":"sum","params":[1]},{"jsonrpc":"2.0","method":"sum","params":[2]}]`,
+			expectedHTTPStatus: http.StatusNoContent,
+		},
+		"wrong HTTP method": {
+			method:             http.MethodGet,
+			expectedHTTPStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	runJSONRPCTestCases(t, server, testCases)
+}
+
+func TestJSONRPCServerPanicsOnDuplicateMethods(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+
+	server := propre.NewJSONRPCServer()
+	server.Register("sum", propre.NewJSONRPCHandler(sumDecoder{}, sumUseCase{}, sumPresenter{}))
+	server.Register("sum", propre.NewJSONRPCHandler(sumDecoder{}, sumUseCase{}, sumPresenter{}))
+}
+
+type panicMethod struct{}
+
+func (m panicMethod) Call(ctx context.Context, req *propre.JSONRPCRequest) (json.RawMessage, error) {
+	panic("method failure")
+}
+
+// concurrencyMethod records the maximum number of concurrent calls.
+type concurrencyMethod struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (m *concurrencyMethod) Call(ctx context.Context, req *propre.JSONRPCRequest) (json.RawMessage, error) {
+	m.mu.Lock()
+	m.current++
+	m.max = max(m.max, m.current)
+	m.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	m.mu.Lock()
+	m.current--
+	m.mu.Unlock()
+
+	return json.RawMessage("true"), nil
+}
+
+func TestJSONRPCServerLimits(t *testing.T) {
+	server := propre.NewJSONRPCServer(
+		propre.WithJSONRPCMaxBodySize(256),
+		propre.WithJSONRPCMaxBatchSize(2),
+	)
+
+	server.Register("sum", propre.NewJSONRPCHandler(sumDecoder{}, sumUseCase{}, sumPresenter{}))
+	server.Register("panic", panicMethod{})
+
+	testCases := map[string]jsonrpcTestCase{
+		"panicking method": {
+			body:               `{"jsonrpc":"2.0","method":"panic","id":1}`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":1}`,
+		},
+		"panicking method in a batch": {
+			body:               `[{"jsonrpc":"2.0","method":"panic","id":1},{"jsonrpc":"2.0","method":"sum","params":[1],"id":2}]`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody: `[
+				{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":1},
+				{"jsonrpc":"2.0","result":1,"id":2}
+			]`,
+		},
+		"panicking notification": {
+			body:               `[{"jsonrpc":"2.0","method":"panic"}]`,
+			expectedHTTPStatus: http.StatusNoContent,
+		},
+		"batch too long": {
+			body:               `[{"jsonrpc":"2.0","method":"sum","id":1},{"jsonrpc":"2.0","method":"sum","id":2},{"jsonrpc":"2.0","method":"sum","id":3}]`,
+			expectedHTTPStatus: http.StatusOK,
+			expectedBody:       `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"3 calls exceeding the limit of 2"},"id":null}`,
+		},
+		"body too large": {
+			body:               `{"jsonrpc":"2.0","method":"sum","params":[` + strings.Repeat("1,", 200) + `1],"id":1}`,
+			expectedHTTPStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	runJSONRPCTestCases(t, server, testCases)
+}
+
+func TestJSONRPCServerConcurrency(t *testing.T) {
+	method := new(concurrencyMethod)
+	server := propre.NewJSONRPCServer(propre.WithJSONRPCConcurrency(2))
+	server.Register("wait", method)
+
+	calls := make([]string, 6)
+	for i := range calls {
+		calls[i] = fmt.Sprintf(`{"jsonrpc":"2.0","method":"wait","id":%d}`, i)
+	}
+
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader("["+strings.Join(calls, ",")+"]"))
+	server.ServeHTTP(rw, req)
+
+	var responses []map[string]any
+	if err := json.Unmarshal(rw.Body.Bytes(), &responses); err != nil || len(responses) != len(calls) {
+		t.Fatalf("expected %d responses, got %s", len(calls), rw.Body.String())
+	}
+
+	if method.max != 2 {
+		t.Fatalf("expected at most 2 concurrent calls, got %d", method.max)
+	}
+}
@@ -0,0 +1,159 @@
+package propre
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// Message is a broker-agnostic representation of a message consumed from a queue.
+// Broker adapters are responsible of converting their own messages to it.
+type Message struct {
+	ID string
+	// Body is the raw payload of the message.
+	Body []byte
+	// Headers are the application headers of the message.
+	Headers map[string]string
+	// Attributes are the metadata set by the broker, like a routing key or a partition.
+	Attributes map[string]string
+	// DeliveryAttempt is the number of times the message has been delivered,
+	// starting at 1.
+	DeliveryAttempt int
+}
+
+// MessageDecoder is the message counterpart of [RequestDecoder]. It checks and
+// extracts the message data required by a use case. The produced input can be
+// either an error or the actual data required by the use case.
+type MessageDecoder[Input any] interface {
+	Decode(msg *Message) Input
+}
+
+// MessageAction is the action a broker must take once a message is handled.
+type MessageAction int
+
+const (
+	// MessageAck acknowledges the message, it will not be delivered again.
+	MessageAck MessageAction = iota
+	// MessageNack rejects the message, it is redelivered as soon as possible.
+	MessageNack
+	// MessageRetry rejects the message, it is redelivered after a delay.
+	MessageRetry
+	// MessageDeadLetter moves the message to the dead letter queue.
+	MessageDeadLetter
+)
+
+// String returns the name of the action.
+func (a MessageAction) String() string {
+	switch a {
+	case MessageAck:
+		return "ack"
+	case MessageNack:
+		return "nack"
+	case MessageRetry:
+		return "retry"
+	case MessageDeadLetter:
+		return "dead-letter"
+	}
+
+	return "unknown"
+}
+
+// MessageDecision is the outcome of a [MessageHandler], applied by the broker.
+type MessageDecision struct {
+	Action MessageAction
+	// Delay is the redelivery delay of a [MessageRetry] action.
+	Delay time.Duration
+	// Reason explains a [MessageDeadLetter] action.
+	Reason string
+	// Reply is the data written by the presenter, brokers supporting
+	// request/reply can publish it.
+	Reply []byte
+}
+
+// MessageWriter is the writer given to the presenters of a [MessageHandler].
+// The presenter decides what to do with the message with the methods Ack, Nack,
+// Retry and DeadLetter. The message is acknowledged if none of them is called.
+// The data written to it is the reply of the message.
+type MessageWriter struct {
+	decision MessageDecision
+	reply    bytes.Buffer
+}
+
+// Write appends data to the reply of the message.
+func (w *MessageWriter) Write(p []byte) (int, error) {
+	return w.reply.Write(p)
+}
+
+// Ack acknowledges the message.
+func (w *MessageWriter) Ack() {
+	w.decision = MessageDecision{Action: MessageAck}
+}
+
+// Nack rejects the message to be redelivered as soon as possible.
+func (w *MessageWriter) Nack() {
+	w.decision = MessageDecision{Action: MessageNack}
+}
+
+// Retry rejects the message to be redelivered after the given delay.
+func (w *MessageWriter) Retry(delay time.Duration) {
+	w.decision = MessageDecision{Action: MessageRetry, Delay: delay}
+}
+
+// DeadLetter moves the message to the dead letter queue.
+func (w *MessageWriter) DeadLetter(reason string) {
+	w.decision = MessageDecision{Action: MessageDeadLetter, Reason: reason}
+}
+
+// Decision returns the decision taken by the presenter.
+func (w *MessageWriter) Decision() MessageDecision {
+	decision := w.decision
+	decision.Reply = w.reply.Bytes()
+
+	return decision
+}
+
+// MessageConsumer is implemented by anything able to handle a [Message].
+// It is used by [MessageWorkerPool].
+type MessageConsumer interface {
+	Consume(ctx context.Context, msg *Message) MessageDecision
+}
+
+// MessageHandler is the message counterpart of [HTTPHandler]. It allows to
+// trigger the same use cases from queue messages. Each consumer requires:
+//   - a message decoder to transform a message to a use case input,
+//   - a use case handler,
+//   - a presenter deciding if the message is acknowledged, rejected, retried or dead-lettered
+//     depending on the output returned by the use case.
+//
+// It implements [MessageConsumer] to be used by a [MessageWorkerPool] or any
+// broker adapter.
+type MessageHandler[Input, Output any] struct {
+	messageDecoder MessageDecoder[Input]
+	useCaseHandler UseCaseHandler[Input, Output]
+	presenter      Presenter[Output, *MessageWriter]
+}
+
+// NewMessageHandler builds a MessageHandler with the given dependencies.
+func NewMessageHandler[Input, Output any](
+	messageDecoder MessageDecoder[Input],
+	useCaseHandler UseCaseHandler[Input, Output],
+	presenter Presenter[Output, *MessageWriter],
+) *MessageHandler[Input, Output] {
+	return &MessageHandler[Input, Output]{
+		messageDecoder: messageDecoder,
+		useCaseHandler: useCaseHandler,
+		presenter:      presenter,
+	}
+}
+
+// Consume decodes the message, passes the input to the use case handler and
+// presents the output. It returns the decision taken by the presenter.
+func (handler *MessageHandler[Input, Output]) Consume(ctx context.Context, msg *Message) MessageDecision {
+	input := handler.messageDecoder.Decode(msg)
+	output := handler.useCaseHandler.Handle(ctx, input)
+
+	w := new(MessageWriter)
+	handler.presenter.Present(ctx, w, output)
+
+	return w.Decision()
+}
@@ -0,0 +1,211 @@
+package propre
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SettledMessage is a message settled by an [InMemoryMessageBroker] along with
+// the decision applied to it.
+type SettledMessage struct {
+	Message  Message
+	Decision MessageDecision
+}
+
+// InMemoryMessageBroker is a [MessageBroker] keeping the messages in memory.
+// It is meant to test the consumers and their presenters without a real broker:
+// rejected and retried messages are redelivered with an incremented delivery
+// attempt, dead-lettered messages are kept aside, and every settled message is
+// recorded to be asserted.
+type InMemoryMessageBroker struct {
+	mu          sync.Mutex
+	pending     []*Message
+	inFlight    int
+	retrying    int
+	settled     []SettledMessage
+	deadLetters []Message
+	sequence    int
+	available   chan struct{}
+	closed      chan struct{}
+	closeOnce   sync.Once
+	idle        chan struct{}
+}
+
+// NewInMemoryMessageBroker builds an empty [InMemoryMessageBroker].
+func NewInMemoryMessageBroker() *InMemoryMessageBroker {
+	idle := make(chan struct{})
+	close(idle)
+
+	return &InMemoryMessageBroker{
+		available: make(chan struct{}, 1),
+		closed:    make(chan struct{}),
+		idle:      idle,
+	}
+}
+
+// Publish enqueues a message. An ID is generated if the message has none.
+// It returns [ErrMessageBrokerClosed] if the broker is closed.
+func (b *InMemoryMessageBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.closed:
+		return ErrMessageBrokerClosed
+	default:
+	}
+
+	b.sequence++
+	if msg.ID == "" {
+		msg.ID = strconv.Itoa(b.sequence)
+	}
+
+	msg.DeliveryAttempt = 1
+	b.enqueue(&msg)
+
+	return nil
+}
+
+// Receive implements [MessageBroker].
+func (b *InMemoryMessageBroker) Receive(ctx context.Context) (*Message, error) {
+	for {
+		select {
+		case <-b.closed:
+			return nil, ErrMessageBrokerClosed
+		default:
+		}
+
+		b.mu.Lock()
+		if len(b.pending) > 0 {
+			msg := b.pending[0]
+			b.pending = b.pending[1:]
+			b.inFlight++
+			if len(b.pending) > 0 {
+				b.signal()
+			}
+
+			b.mu.Unlock()
+			return msg, nil
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-b.closed:
+			return nil, ErrMessageBrokerClosed
+		case <-b.available:
+		}
+	}
+}
+
+// Settle implements [MessageBroker].
+func (b *InMemoryMessageBroker) Settle(ctx context.Context, msg *Message, decision MessageDecision) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+	b.settled = append(b.settled, SettledMessage{Message: *msg, Decision: decision})
+
+	switch decision.Action {
+	case MessageNack:
+		b.redeliver(msg)
+	case MessageRetry:
+		b.retrying++
+		time.AfterFunc(decision.Delay, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			b.retrying--
+			b.redeliver(msg)
+			b.updateIdle()
+		})
+	case MessageDeadLetter:
+		b.deadLetters = append(b.deadLetters, *msg)
+	}
+
+	b.updateIdle()
+	return nil
+}
+
+// Settled returns the messages settled so far, in order.
+func (b *InMemoryMessageBroker) Settled() []SettledMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]SettledMessage(nil), b.settled...)
+}
+
+// DeadLetters returns the dead-lettered messages.
+func (b *InMemoryMessageBroker) DeadLetters() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]Message(nil), b.deadLetters...)
+}
+
+// WaitIdle blocks until every published message has been settled with no
+// pending redelivery, or until the context is done.
+func (b *InMemoryMessageBroker) WaitIdle(ctx context.Context) error {
+	b.mu.Lock()
+	idle := b.idle
+	b.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-idle:
+		return nil
+	}
+}
+
+// Close closes the broker, the pending messages are dropped and Receive returns
+// [ErrMessageBrokerClosed].
+func (b *InMemoryMessageBroker) Close() {
+	b.closeOnce.Do(func() {
+		close(b.closed)
+	})
+}
+
+func (b *InMemoryMessageBroker) redeliver(msg *Message) {
+	select {
+	case <-b.closed:
+		return
+	default:
+	}
+
+	redelivered := *msg
+	redelivered.DeliveryAttempt++
+	b.enqueue(&redelivered)
+}
+
+func (b *InMemoryMessageBroker) enqueue(msg *Message) {
+	b.pending = append(b.pending, msg)
+	b.signal()
+	b.updateIdle()
+}
+
+func (b *InMemoryMessageBroker) signal() {
+	select {
+	case b.available <- struct{}{}:
+	default:
+	}
+}
+
+// updateIdle must be called with the lock held.
+func (b *InMemoryMessageBroker) updateIdle() {
+	isIdle := len(b.pending) == 0 && b.inFlight == 0 && b.retrying == 0
+
+	select {
+	case <-b.idle:
+		if !isIdle {
+			b.idle = make(chan struct{})
+		}
+	default:
+		if isIdle {
+			close(b.idle)
+		}
+	}
+}
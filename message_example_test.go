@@ -0,0 +1,74 @@
+package propre_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type CreateTodoMessageDecoder struct{}
+
+func (decoder *CreateTodoMessageDecoder) Decode(msg *propre.Message) CreateTodoInput {
+	var input CreateTodoInput
+	var body struct {
+		Title string `json:"title"`
+	}
+
+	err := json.Unmarshal(msg.Body, &body)
+	if err != nil {
+		input.Error = fmt.Errorf("[CreateTodoMessageDecoder] %w", err)
+		return input
+	}
+
+	input.Data.Title = body.Title
+	return input
+}
+
+type CreateTodoMessagePresenter struct{}
+
+func (p *CreateTodoMessagePresenter) Present(ctx context.Context, w *propre.MessageWriter, output CreateTodoOutput) {
+	if output.Error != nil {
+		// A malformed message will never succeed, there is no need to retry it.
+		w.DeadLetter(output.Error.Error())
+		return
+	}
+
+	fmt.Printf("todo #%d created: %s\n", output.Data.ID, output.Data.Title)
+	w.Ack()
+}
+
+// In this example the use case of the HTTPHandler example is triggered by
+// queue messages. The in-memory broker replaces a real one, and a worker pool
+// consumes its messages with at most 4 messages handled concurrently.
+//
+// The presenter acknowledges the successful messages and moves the malformed
+// ones to the dead letter queue.
+func ExampleMessageHandler() {
+	useCaseHandler := &CreateTodoUseCaseInteractor[CreateTodoInput, CreateTodoOutput]{}
+	handler := propre.NewMessageHandler(
+		&CreateTodoMessageDecoder{},
+		useCaseHandler,
+		&CreateTodoMessagePresenter{},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	broker := propre.NewInMemoryMessageBroker()
+	broker.Publish(ctx, propre.Message{Body: []byte(`{"title":"New todo title"}`)})
+	broker.Publish(ctx, propre.Message{Body: []byte(`{"title`)})
+
+	pool := propre.NewMessageWorkerPool(broker, handler, propre.WithMessageWorkerConcurrency(4))
+	go pool.Run(ctx)
+
+	broker.WaitIdle(ctx)
+	broker.Close()
+
+	fmt.Println(len(broker.DeadLetters()))
+	// Output:
+	// todo #42 created: New todo title
+	// 1
+}
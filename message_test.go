@@ -0,0 +1,127 @@
+package propre_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMessageHandlerImplementsMessageConsumer(t *testing.T) {
+	handler := propre.NewMessageHandler(
+		new(messageDecoderMock[any]),
+		new(useCaseHandlerMock[any, any]),
+		new(messagePresenterMock[any]),
+	)
+
+	f := func(c propre.MessageConsumer) {}
+	f(handler)
+}
+
+func TestMessageHandlerUsesAMessageDecoderThenAUseCaseHandlerThenPresentsTheOutput(t *testing.T) {
+	messageDecoder := new(messageDecoderMock[any])
+	defer messageDecoder.AssertExpectations(t)
+
+	useCaseHandler := new(useCaseHandlerMock[any, any])
+	defer useCaseHandler.AssertExpectations(t)
+
+	presenter := new(messagePresenterMock[any])
+	defer presenter.AssertExpectations(t)
+
+	handler := propre.NewMessageHandler(messageDecoder, useCaseHandler, presenter)
+	msg := &propre.Message{ID: "1", Body: []byte("some body")}
+
+	useCaseInput := "some input"
+	useCaseOutput := "some output"
+
+	messageDecoder.On("Decode", msg).Return(useCaseInput)
+
+	ctxArgMatcher := mock.MatchedBy(func(ctx context.Context) bool {
+		return true
+	})
+
+	useCaseHandler.On("Handle", ctxArgMatcher, useCaseInput).Return(useCaseOutput)
+	presenter.On("Present", ctxArgMatcher, mock.AnythingOfType("*propre.MessageWriter"), useCaseOutput).
+		Run(func(args mock.Arguments) {
+			w := args.Get(1).(*propre.MessageWriter)
+			fmt.Fprint(w, "some reply")
+			w.Retry(time.Second)
+		})
+
+	decision := handler.Consume(context.Background(), msg)
+
+	if decision.Action != propre.MessageRetry {
+		t.Fatalf("wrong action, expected %s, got %s", propre.MessageRetry, decision.Action)
+	}
+
+	if decision.Delay != time.Second {
+		t.Fatalf("wrong delay, expected %s, got %s", time.Second, decision.Delay)
+	}
+
+	if string(decision.Reply) != "some reply" {
+		t.Fatalf("unexpected reply %q", decision.Reply)
+	}
+}
+
+type messageWriterTestCase struct {
+	decide           func(w *propre.MessageWriter)
+	expectedDecision propre.MessageDecision
+}
+
+func TestMessageWriter(t *testing.T) {
+	testCases := map[string]messageWriterTestCase{
+		"no decision acknowledges the message": {
+			decide:           func(w *propre.MessageWriter) {},
+			expectedDecision: propre.MessageDecision{Action: propre.MessageAck},
+		},
+		"ack": {
+			decide:           func(w *propre.MessageWriter) { w.Nack(); w.Ack() },
+			expectedDecision: propre.MessageDecision{Action: propre.MessageAck},
+		},
+		"nack": {
+			decide:           func(w *propre.MessageWriter) { w.Nack() },
+			expectedDecision: propre.MessageDecision{Action: propre.MessageNack},
+		},
+		"retry": {
+			decide:           func(w *propre.MessageWriter) { w.Retry(time.Minute) },
+			expectedDecision: propre.MessageDecision{Action: propre.MessageRetry, Delay: time.Minute},
+		},
+		"dead letter": {
+			decide:           func(w *propre.MessageWriter) { w.Retry(time.Minute); w.DeadLetter("poison message") },
+			expectedDecision: propre.MessageDecision{Action: propre.MessageDeadLetter, Reason: "poison message"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			w := new(propre.MessageWriter)
+			testCase.decide(w)
+
+			decision := w.Decision()
+			if decision.Action != testCase.expectedDecision.Action ||
+				decision.Delay != testCase.expectedDecision.Delay ||
+				decision.Reason != testCase.expectedDecision.Reason {
+				t.Fatalf("unexpected decision, expected %+v, got %+v", testCase.expectedDecision, decision)
+			}
+		})
+	}
+}
+
+type messageDecoderMock[Input any] struct {
+	mock.Mock
+}
+
+func (m *messageDecoderMock[Input]) Decode(msg *propre.Message) Input {
+	return m.Called(msg).Get(0).(Input)
+}
+
+type messagePresenterMock[Output any] struct {
+	mock.Mock
+}
+
+func (m *messagePresenterMock[Output]) Present(ctx context.Context, w *propre.MessageWriter, output Output) {
+	m.Called(ctx, w, output)
+}
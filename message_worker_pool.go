@@ -0,0 +1,153 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrMessageBrokerClosed is returned by the Receive method of a [MessageBroker]
+	// once the broker is closed.
+	ErrMessageBrokerClosed = errors.New("message broker closed")
+
+	// ErrMessageConsumerPanic is reported by [MessageWorkerPool] when a consumer panics.
+	// The message is rejected to be redelivered.
+	ErrMessageConsumerPanic = errors.New("message consumer panic")
+
+	// ErrMessageSettlement is reported by [MessageWorkerPool] when the broker fails
+	// to apply the decision taken for a message.
+	ErrMessageSettlement = errors.New("message settlement error")
+)
+
+// MessageBroker is the interface a broker adapter must implement to be used by
+// a [MessageWorkerPool].
+type MessageBroker interface {
+	// Receive blocks until a message is available, the context is done or the
+	// broker is closed. In the latter case it returns [ErrMessageBrokerClosed].
+	Receive(ctx context.Context) (*Message, error)
+	// Settle applies the decision taken for the given message.
+	Settle(ctx context.Context, msg *Message, decision MessageDecision) error
+}
+
+// MessageWorkerPool receives messages from a [MessageBroker] and passes them to
+// a [MessageConsumer], like a [MessageHandler]. The number of messages handled
+// concurrently is limited by the number of workers.
+type MessageWorkerPool struct {
+	broker       MessageBroker
+	consumer     MessageConsumer
+	concurrency  int
+	errorHandler func(context.Context, *Message, error)
+}
+
+// MessageWorkerPoolOpts is the alias for the [MessageWorkerPool] builder options.
+type MessageWorkerPoolOpts func(p *MessageWorkerPool)
+
+// WithMessageWorkerConcurrency is a [MessageWorkerPool] option to set the number
+// of workers, which is the maximum number of messages handled concurrently.
+// The default is 1.
+func WithMessageWorkerConcurrency(workers int) MessageWorkerPoolOpts {
+	return func(p *MessageWorkerPool) {
+		p.concurrency = max(workers, 1)
+	}
+}
+
+// WithMessageErrorHandler is a [MessageWorkerPool] option to be notified of the
+// errors the workers cannot return, like a panicking consumer or a settlement error.
+func WithMessageErrorHandler(handler func(ctx context.Context, msg *Message, err error)) MessageWorkerPoolOpts {
+	return func(p *MessageWorkerPool) {
+		p.errorHandler = handler
+	}
+}
+
+// NewMessageWorkerPool returns a [MessageWorkerPool]. [MessageWorkerPoolOpts] can
+// be passed to customize the concurrency and the error handler.
+func NewMessageWorkerPool(
+	broker MessageBroker,
+	consumer MessageConsumer,
+	opts ...MessageWorkerPoolOpts,
+) *MessageWorkerPool {
+	pool := &MessageWorkerPool{
+		broker:      broker,
+		consumer:    consumer,
+		concurrency: 1,
+	}
+
+	for _, opt := range opts {
+		opt(pool)
+	}
+
+	return pool
+}
+
+// Run starts the workers and blocks until the context is done or the broker is
+// closed, in which case it returns nil. If the broker fails to receive a message,
+// the workers are stopped and the error is returned.
+//
+// Once received, a message is handled and settled with a context which is not
+// canceled with the one given to Run, so the messages in flight are not lost
+// when the pool stops.
+func (pool *MessageWorkerPool) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for range pool.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pool.work(ctx)
+			if err != nil {
+				cancel(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	err := context.Cause(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+
+	return err
+}
+
+func (pool *MessageWorkerPool) work(ctx context.Context) error {
+	for {
+		msg, err := pool.broker.Receive(ctx)
+		if err != nil {
+			if errors.Is(err, ErrMessageBrokerClosed) || ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		handlingCtx := context.WithoutCancel(ctx)
+		decision := pool.consume(handlingCtx, msg)
+		err = pool.broker.Settle(handlingCtx, msg, decision)
+		if err != nil {
+			pool.reportError(handlingCtx, msg, fmt.Errorf("%w caused by %w", ErrMessageSettlement, err))
+		}
+	}
+}
+
+func (pool *MessageWorkerPool) consume(ctx context.Context, msg *Message) (decision MessageDecision) {
+	defer func() {
+		if r := recover(); r != nil {
+			pool.reportError(ctx, msg, fmt.Errorf("%w: %v", ErrMessageConsumerPanic, r))
+			decision = MessageDecision{Action: MessageNack}
+		}
+	}()
+
+	return pool.consumer.Consume(ctx, msg)
+}
+
+func (pool *MessageWorkerPool) reportError(ctx context.Context, msg *Message, err error) {
+	if pool.errorHandler == nil {
+		return
+	}
+
+	pool.errorHandler(ctx, msg, err)
+}
@@ -0,0 +1,188 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type consumerFunc func(ctx context.Context, msg *propre.Message) propre.MessageDecision
+
+func (f consumerFunc) Consume(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+	return f(ctx, msg)
+}
+
+func runPool(t *testing.T, broker *propre.InMemoryMessageBroker, pool *propre.MessageWorkerPool) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error)
+	go func() {
+		done <- pool.Run(ctx)
+	}()
+
+	err := broker.WaitIdle(ctx)
+	if err != nil {
+		t.Fatalf("broker never became idle: %s", err)
+	}
+
+	broker.Close()
+	err = <-done
+	if err != nil {
+		t.Fatalf("unexpected pool error: %s", err)
+	}
+}
+
+func TestMessageWorkerPoolLimitsConcurrency(t *testing.T) {
+	const concurrency = 3
+
+	var running, maxRunning atomic.Int32
+	consumer := consumerFunc(func(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			previous := maxRunning.Load()
+			if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		return propre.MessageDecision{Action: propre.MessageAck}
+	})
+
+	broker := propre.NewInMemoryMessageBroker()
+	for range 20 {
+		broker.Publish(context.Background(), propre.Message{Body: []byte("some body")})
+	}
+
+	runPool(t, broker, propre.NewMessageWorkerPool(broker, consumer, propre.WithMessageWorkerConcurrency(concurrency)))
+
+	if maxRunning.Load() > concurrency {
+		t.Fatalf("too many concurrent messages, expected at most %d, got %d", concurrency, maxRunning.Load())
+	}
+
+	if len(broker.Settled()) != 20 {
+		t.Fatalf("expected 20 settled messages, got %d", len(broker.Settled()))
+	}
+}
+
+func TestMessageWorkerPoolRedeliversRejectedMessages(t *testing.T) {
+	consumer := consumerFunc(func(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+		switch msg.DeliveryAttempt {
+		case 1:
+			return propre.MessageDecision{Action: propre.MessageNack}
+		case 2:
+			return propre.MessageDecision{Action: propre.MessageRetry, Delay: time.Millisecond}
+		}
+
+		return propre.MessageDecision{Action: propre.MessageDeadLetter, Reason: "too many attempts"}
+	})
+
+	broker := propre.NewInMemoryMessageBroker()
+	broker.Publish(context.Background(), propre.Message{ID: "some-id"})
+
+	runPool(t, broker, propre.NewMessageWorkerPool(broker, consumer))
+
+	settled := broker.Settled()
+	expectedActions := []propre.MessageAction{propre.MessageNack, propre.MessageRetry, propre.MessageDeadLetter}
+	if len(settled) != len(expectedActions) {
+		t.Fatalf("expected %d settled messages, got %d", len(expectedActions), len(settled))
+	}
+
+	for i, expectedAction := range expectedActions {
+		if settled[i].Decision.Action != expectedAction {
+			t.Fatalf("wrong action for attempt %d, expected %s, got %s", i+1, expectedAction, settled[i].Decision.Action)
+		}
+
+		if settled[i].Message.DeliveryAttempt != i+1 {
+			t.Fatalf("wrong delivery attempt, expected %d, got %d", i+1, settled[i].Message.DeliveryAttempt)
+		}
+	}
+
+	deadLetters := broker.DeadLetters()
+	if len(deadLetters) != 1 || deadLetters[0].ID != "some-id" {
+		t.Fatalf("unexpected dead letters %+v", deadLetters)
+	}
+}
+
+func TestMessageWorkerPoolRecoversPanickingConsumers(t *testing.T) {
+	consumer := consumerFunc(func(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+		if msg.DeliveryAttempt == 1 {
+			panic("boom")
+		}
+
+		return propre.MessageDecision{Action: propre.MessageAck}
+	})
+
+	var mu sync.Mutex
+	var reported []error
+	errorHandler := func(ctx context.Context, msg *propre.Message, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reported = append(reported, err)
+	}
+
+	broker := propre.NewInMemoryMessageBroker()
+	broker.Publish(context.Background(), propre.Message{})
+
+	runPool(t, broker, propre.NewMessageWorkerPool(broker, consumer, propre.WithMessageErrorHandler(errorHandler)))
+
+	if len(reported) != 1 || !errors.Is(reported[0], propre.ErrMessageConsumerPanic) {
+		t.Fatalf("unexpected reported errors %v", reported)
+	}
+
+	settled := broker.Settled()
+	if len(settled) != 2 || settled[0].Decision.Action != propre.MessageNack || settled[1].Decision.Action != propre.MessageAck {
+		t.Fatalf("unexpected settled messages %+v", settled)
+	}
+}
+
+type failingBroker struct {
+	err error
+}
+
+func (b failingBroker) Receive(ctx context.Context) (*propre.Message, error) {
+	return nil, b.err
+}
+
+func (b failingBroker) Settle(ctx context.Context, msg *propre.Message, decision propre.MessageDecision) error {
+	return nil
+}
+
+func TestMessageWorkerPoolStopsOnReceiveErrors(t *testing.T) {
+	receiveErr := errors.New("connection lost")
+	consumer := consumerFunc(func(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+		return propre.MessageDecision{}
+	})
+
+	pool := propre.NewMessageWorkerPool(failingBroker{err: receiveErr}, consumer, propre.WithMessageWorkerConcurrency(2))
+	err := pool.Run(context.Background())
+	if !errors.Is(err, receiveErr) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMessageWorkerPoolStopsWhenTheContextIsDone(t *testing.T) {
+	consumer := consumerFunc(func(ctx context.Context, msg *propre.Message) propre.MessageDecision {
+		return propre.MessageDecision{}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool := propre.NewMessageWorkerPool(propre.NewInMemoryMessageBroker(), consumer)
+	err := pool.Run(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
@@ -0,0 +1,402 @@
+package propre
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIInfo is the info object of an OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIParameter is a path, query or header parameter of an [OpenAPIOperation].
+// Use [OpenAPIParametersOf] to reflect them from a struct.
+type OpenAPIParameter struct {
+	Name        string
+	In          string
+	Required    bool
+	Description string
+	Type        reflect.Type
+}
+
+// OpenAPIRequestBody is the request body of an [OpenAPIOperation].
+// Use [OpenAPIRequestBodyOf] to reflect it from a payload type.
+type OpenAPIRequestBody struct {
+	ContentType string
+	Description string
+	Type        reflect.Type
+}
+
+// OpenAPIResponse is a response of an [OpenAPIOperation].
+// Use [OpenAPIResponseOf] to reflect it from a view model.
+type OpenAPIResponse struct {
+	StatusCode  int
+	ContentType string
+	Description string
+	// Type is the type of the response body, nil if the response has no body.
+	Type reflect.Type
+}
+
+// OpenAPIOperation documents a route registered in [OpenAPI].
+type OpenAPIOperation struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Parameters  []OpenAPIParameter
+	RequestBody *OpenAPIRequestBody
+	Responses   []OpenAPIResponse
+}
+
+// OpenAPIParametersOf reflects the parameters of an operation from the fields
+// of the Params struct and their tags:
+//   - `path:"name"` documents a path parameter, always required,
+//   - `query:"name"` and `header:"name"` document a query or a header parameter,
+//     required if the tag has the "required" option, like `query:"page,required"`,
+//   - `description:"..."` describes the parameter.
+//
+// The fields of the embedded structs without tag are promoted like with
+// encoding/json, a parameter of the outer struct hides the embedded ones with
+// the same name. It panics if Params is not a struct.
+func OpenAPIParametersOf[Params any]() []OpenAPIParameter {
+	t := reflect.TypeOf((*Params)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("propre: the parameters type %s must be a struct", t))
+	}
+
+	return openAPIParametersOf(t, map[reflect.Type]bool{})
+}
+
+var openAPIParameterLocations = []string{"path", "query", "header"}
+
+func openAPIParametersOf(t reflect.Type, visited map[reflect.Type]bool) []OpenAPIParameter {
+	visited[t] = true
+	defer delete(visited, t)
+
+	own := make(map[string]bool)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		for _, in := range openAPIParameterLocations {
+			if tag, ok := field.Tag.Lookup(in); ok {
+				name, _, _ := strings.Cut(tag, ",")
+				own[in+" "+name] = true
+			}
+		}
+	}
+
+	var parameters []OpenAPIParameter
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if embedded := embeddedStruct(field); embedded != nil {
+			if visited[embedded] {
+				continue
+			}
+
+			for _, parameter := range openAPIParametersOf(embedded, visited) {
+				if !own[parameter.In+" "+parameter.Name] {
+					parameters = append(parameters, parameter)
+				}
+			}
+
+			continue
+		}
+
+		for _, in := range openAPIParameterLocations {
+			tag, ok := field.Tag.Lookup(in)
+			if !ok {
+				continue
+			}
+
+			name, options, _ := strings.Cut(tag, ",")
+			parameters = append(parameters, OpenAPIParameter{
+				Name:        name,
+				In:          in,
+				Required:    in == "path" || options == "required",
+				Description: field.Tag.Get("description"),
+				Type:        field.Type,
+			})
+		}
+	}
+
+	return parameters
+}
+
+// embeddedStruct returns the struct type of an embedded field without parameter
+// tag, nil if the field is not promoted.
+func embeddedStruct(field reflect.StructField) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+
+	for _, in := range openAPIParameterLocations {
+		if _, ok := field.Tag.Lookup(in); ok {
+			return nil
+		}
+	}
+
+	t := field.Type
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return t
+}
+
+// OpenAPIRequestBodyOf reflects the request body of an operation from the payload
+// type of a [RequestPayloadExtractor], following the encoding/json tags. The
+// fields are required unless they have the "omitempty" option or are pointers.
+func OpenAPIRequestBodyOf[Payload Validatable](contentType string) *OpenAPIRequestBody {
+	return &OpenAPIRequestBody{
+		ContentType: contentType,
+		Type:        reflect.TypeOf((*Payload)(nil)).Elem(),
+	}
+}
+
+// OpenAPIResponseOf reflects a response of an operation from a view model: the
+// status code and the content type are read from the given view, and the body
+// schema is reflected from its type following the encoding/json tags. The views
+// implementing [HTTPBodyless] have no body.
+func OpenAPIResponseOf[View HTTPSendable](view View, description string) OpenAPIResponse {
+	ctx := context.Background()
+	response := OpenAPIResponse{
+		StatusCode:  view.StatusCode(ctx),
+		ContentType: view.ContentType(ctx),
+		Description: description,
+		Type:        reflect.TypeOf((*View)(nil)).Elem(),
+	}
+
+	if bodyless, ok := any(view).(HTTPBodyless); ok && bodyless.Bodyless(ctx) {
+		response.ContentType = ""
+		response.Type = nil
+	}
+
+	return response
+}
+
+type openAPIRoute struct {
+	method    string
+	path      string
+	operation OpenAPIOperation
+}
+
+// OpenAPI generates an OpenAPI 3.1 document from the registered routes.
+//
+// It implements [http.Handler] to serve the document in JSON, and [Command] to
+// export it from a [CLIMux]. The package openapiyaml serves and exports it in
+// YAML, so the applications serving JSON only do not depend on a YAML library.
+type OpenAPI struct {
+	info   OpenAPIInfo
+	routes []openAPIRoute
+}
+
+// NewOpenAPI builds an empty OpenAPI document.
+func NewOpenAPI(info OpenAPIInfo) *OpenAPI {
+	return &OpenAPI{info: info}
+}
+
+// Register documents a route. The pattern uses the syntax of [http.ServeMux],
+// like "POST /todos/{id}", a pattern without method is documented as GET.
+// The wildcards of the path without matching parameter are documented as
+// string path parameters.
+func (api *OpenAPI) Register(pattern string, operation OpenAPIOperation) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = http.MethodGet, pattern
+	}
+
+	path = strings.TrimSpace(path)
+	if i := strings.Index(path, "/"); i > 0 {
+		path = path[i:]
+	}
+
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "...}", "}"), "{$}", "")
+	api.routes = append(api.routes, openAPIRoute{
+		method:    strings.ToLower(method),
+		path:      path,
+		operation: operation,
+	})
+}
+
+// Handle registers the handler in the mux and documents the route.
+func (api *OpenAPI) Handle(mux *http.ServeMux, pattern string, handler http.Handler, operation OpenAPIOperation) {
+	mux.Handle(pattern, handler)
+	api.Register(pattern, operation)
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                        `json:"openapi"`
+	Info       OpenAPIInfo                                   `json:"info"`
+	Paths      map[string]map[string]*openAPIOperationObject `json:"paths"`
+	Components *openAPIComponents                            `json:"components,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+type openAPIOperationObject struct {
+	OperationID string                        `json:"operationId,omitempty"`
+	Summary     string                        `json:"summary,omitempty"`
+	Description string                        `json:"description,omitempty"`
+	Tags        []string                      `json:"tags,omitempty"`
+	Parameters  []openAPIParameterObject      `json:"parameters,omitempty"`
+	RequestBody *openAPIBodyObject            `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIBodyObject `json:"responses"`
+}
+
+type openAPIParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type openAPIBodyObject struct {
+	Description string                        `json:"description,omitempty"`
+	Required    bool                          `json:"required,omitempty"`
+	Content     map[string]openAPIMediaObject `json:"content,omitempty"`
+}
+
+type openAPIMediaObject struct {
+	Schema *Schema `json:"schema"`
+}
+
+func (api *OpenAPI) document() openAPIDocument {
+	reflector := newSchemaReflector("#/components/schemas/")
+	doc := openAPIDocument{
+		OpenAPI: "3.1.0",
+		Info:    api.info,
+		Paths:   make(map[string]map[string]*openAPIOperationObject),
+	}
+
+	for _, route := range api.routes {
+		operation := route.operation
+		object := &openAPIOperationObject{
+			OperationID: operation.OperationID,
+			Summary:     operation.Summary,
+			Description: operation.Description,
+			Tags:        operation.Tags,
+			Responses:   make(map[string]*openAPIBodyObject),
+		}
+
+		documented := make(map[string]bool)
+		for _, parameter := range operation.Parameters {
+			documented[parameter.In+":"+parameter.Name] = true
+			object.Parameters = append(object.Parameters, openAPIParameterObject{
+				Name:        parameter.Name,
+				In:          parameter.In,
+				Required:    parameter.Required,
+				Description: parameter.Description,
+				Schema:      reflector.reflect(parameter.Type),
+			})
+		}
+
+		for _, wildcard := range pathWildcards(route.path) {
+			if !documented["path:"+wildcard] {
+				object.Parameters = append(object.Parameters, openAPIParameterObject{
+					Name:     wildcard,
+					In:       "path",
+					Required: true,
+					Schema:   &Schema{Type: "string"},
+				})
+			}
+		}
+
+		if body := operation.RequestBody; body != nil {
+			object.RequestBody = &openAPIBodyObject{
+				Description: body.Description,
+				Required:    true,
+				Content: map[string]openAPIMediaObject{
+					body.ContentType: {Schema: reflector.reflect(body.Type)},
+				},
+			}
+		}
+
+		for _, response := range operation.Responses {
+			description := response.Description
+			if description == "" {
+				description = http.StatusText(response.StatusCode)
+			}
+
+			object.Responses[strconv.Itoa(response.StatusCode)] = &openAPIBodyObject{Description: description}
+			if response.Type != nil {
+				object.Responses[strconv.Itoa(response.StatusCode)].Content = map[string]openAPIMediaObject{
+					response.ContentType: {Schema: reflector.reflect(response.Type)},
+				}
+			}
+		}
+
+		if doc.Paths[route.path] == nil {
+			doc.Paths[route.path] = make(map[string]*openAPIOperationObject)
+		}
+
+		doc.Paths[route.path][route.method] = object
+	}
+
+	if len(reflector.defs) > 0 {
+		doc.Components = &openAPIComponents{Schemas: reflector.defs}
+	}
+
+	return doc
+}
+
+func pathWildcards(path string) []string {
+	var wildcards []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			wildcards = append(wildcards, segment[1:len(segment)-1])
+		}
+	}
+
+	return wildcards
+}
+
+// JSON returns the OpenAPI document encoded in JSON.
+func (api *OpenAPI) JSON() ([]byte, error) {
+	return json.MarshalIndent(api.document(), "", "  ")
+}
+
+// ServeHTTP allows OpenAPI to be used by any HTTP "ServeMux" to serve the document.
+func (api *OpenAPI) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	doc, err := api.JSON()
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(doc)
+}
+
+// Run implements [Command] to export the document in JSON to the standard output.
+func (api *OpenAPI) Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	doc, err := api.JSON()
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return ExitFailure
+	}
+
+	stdout.Write(doc)
+	return ExitSuccess
+}
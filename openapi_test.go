@@ -0,0 +1,239 @@
+package propre_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type todoParams struct {
+	ID     string `path:"id" description:"ID of the todo"`
+	Fields string `query:"fields"`
+	Tenant string `header:"X-Tenant,required"`
+}
+
+type paginationParams struct {
+	Page  int `query:"page"`
+	Limit int `query:"limit"`
+}
+
+type tenantParams struct {
+	Tenant string `header:"X-Tenant"`
+}
+
+type listTodosParams struct {
+	paginationParams
+	*tenantParams
+	Tenant string `header:"X-Tenant,required"`
+	Filter string `query:"filter"`
+}
+
+type recursiveParams struct {
+	*recursiveParams
+	ID string `path:"id"`
+}
+
+type todoPayload struct {
+	Title string    `json:"title" description:"title of the todo"`
+	Due   time.Time `json:"due,omitempty"`
+	Tags  []string  `json:"tags,omitempty"`
+}
+
+func (p todoPayload) Validate() error {
+	return nil
+}
+
+type todoView struct {
+	ID      string      `json:"id"`
+	Payload todoPayload `json:"payload"`
+}
+
+func (v todoView) ContentType(context.Context) string {
+	return "application/json"
+}
+
+func (v todoView) Encode(context.Context) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (v todoView) StatusCode(context.Context) int {
+	return http.StatusOK
+}
+
+func newTodoOpenAPI() *propre.OpenAPI {
+	api := propre.NewOpenAPI(propre.OpenAPIInfo{Title: "Todos", Version: "1.0.0"})
+	api.Register("PUT /todos/{id}", propre.OpenAPIOperation{
+		OperationID: "updateTodo",
+		Parameters:  propre.OpenAPIParametersOf[todoParams](),
+		RequestBody: propre.OpenAPIRequestBodyOf[todoPayload]("application/json"),
+		Responses: []propre.OpenAPIResponse{
+			propre.OpenAPIResponseOf(todoView{}, "the updated todo"),
+			propre.OpenAPIResponseOf(propre.NoContent(), ""),
+		},
+	})
+	api.Register("DELETE /todos/{id}/tags/{tag...}", propre.OpenAPIOperation{})
+
+	return api
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc, err := newTodoOpenAPI().JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var decoded struct {
+		OpenAPI string
+		Paths   map[string]map[string]struct {
+			OperationID string
+			Parameters  []struct {
+				Name     string
+				In       string
+				Required bool
+			}
+			RequestBody struct {
+				Content map[string]struct {
+					Schema propre.Schema
+				}
+			}
+			Responses map[string]struct {
+				Description string
+				Content     map[string]struct {
+					Schema propre.Schema
+				}
+			}
+		}
+		Components struct {
+			Schemas map[string]propre.Schema
+		}
+	}
+
+	if err := json.Unmarshal(doc, &decoded); err != nil {
+		t.Fatalf("invalid document: %s", err)
+	}
+
+	if decoded.OpenAPI != "3.1.0" {
+		t.Fatalf("unexpected version %q", decoded.OpenAPI)
+	}
+
+	update := decoded.Paths["/todos/{id}"]["put"]
+	if update.OperationID != "updateTodo" || len(update.Parameters) != 3 {
+		t.Fatalf("unexpected operation %+v", update)
+	}
+
+	if update.Parameters[0].In != "path" || !update.Parameters[0].Required ||
+		update.Parameters[1].Required || !update.Parameters[2].Required {
+		t.Fatalf("unexpected parameters %+v", update.Parameters)
+	}
+
+	if update.RequestBody.Content["application/json"].Schema.Ref != "#/components/schemas/todoPayload" {
+		t.Fatalf("unexpected request body %+v", update.RequestBody)
+	}
+
+	ok := update.Responses["200"]
+	if ok.Description != "the updated todo" || ok.Content["application/json"].Schema.Ref != "#/components/schemas/todoView" {
+		t.Fatalf("unexpected 200 response %+v", ok)
+	}
+
+	noContent, found := update.Responses["204"]
+	if !found || noContent.Description != "No Content" || noContent.Content != nil {
+		t.Fatalf("unexpected 204 response %+v", noContent)
+	}
+
+	payload := decoded.Components.Schemas["todoPayload"]
+	if payload.Properties["due"].Format != "date-time" || payload.Properties["tags"].AnyOf[0].Items.Type != "string" {
+		t.Fatalf("unexpected payload schema %+v", payload)
+	}
+
+	if len(payload.Required) != 1 || payload.Required[0] != "title" || payload.Properties["title"].Description == "" {
+		t.Fatalf("unexpected required fields %v", payload.Required)
+	}
+
+	deleteTag := decoded.Paths["/todos/{id}/tags/{tag}"]["delete"]
+	if len(deleteTag.Parameters) != 2 || deleteTag.Parameters[1].Name != "tag" {
+		t.Fatalf("the path wildcards should be documented, got %+v", deleteTag.Parameters)
+	}
+}
+
+func TestOpenAPIServesTheDocument(t *testing.T) {
+	rw := httptest.NewRecorder()
+	newTodoOpenAPI().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if rw.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected content type %q", rw.Header().Get("Content-Type"))
+	}
+
+	if !strings.HasPrefix(rw.Body.String(), "{\n  \"openapi\": \"3.1.0\"") {
+		t.Fatalf("unexpected document:\n%s", rw.Body.String())
+	}
+}
+
+func TestOpenAPIExportCommand(t *testing.T) {
+	mux := propre.NewCLIMux("app")
+	mux.Handle("openapi", "export the OpenAPI document", newTodoOpenAPI())
+
+	var stdout, stderr bytes.Buffer
+	exitCode := mux.Run(context.Background(), []string{"openapi"}, &stdout, &stderr)
+	if exitCode != propre.ExitSuccess || !strings.HasPrefix(stdout.String(), "{\n  \"openapi\": \"3.1.0\"") {
+		t.Fatalf("unexpected result %d, stdout %q, stderr %q", exitCode, stdout.String(), stderr.String())
+	}
+
+	exitCode = mux.Run(context.Background(), []string{"openapi", "-format", "yaml"}, &stdout, &stderr)
+	if exitCode != propre.ExitUsage {
+		t.Fatalf("unexpected exit code %d for an unknown flag", exitCode)
+	}
+}
+
+func TestOpenAPIParametersOfEmbeddedStructs(t *testing.T) {
+	type testCase struct {
+		parameters []propre.OpenAPIParameter
+		expected   []string
+	}
+
+	testCases := map[string]testCase{
+		"promoted fields": {
+			parameters: propre.OpenAPIParametersOf[listTodosParams](),
+			expected:   []string{"query page false", "query limit false", "header X-Tenant true", "query filter false"},
+		},
+		"recursive embedding": {
+			parameters: propre.OpenAPIParametersOf[recursiveParams](),
+			expected:   []string{"path id true"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var parameters []string
+			for _, parameter := range tc.parameters {
+				parameters = append(parameters, fmt.Sprintf("%s %s %t", parameter.In, parameter.Name, parameter.Required))
+			}
+
+			if !slices.Equal(parameters, tc.expected) {
+				t.Fatalf("unexpected parameters, expected %v, got %v", tc.expected, parameters)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDoesNotImportYAML(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, path := range pkg.Imports {
+		if strings.Contains(path, "yaml") {
+			t.Fatalf("the propre package should not import %s, the YAML encoding belongs to openapiyaml", path)
+		}
+	}
+}
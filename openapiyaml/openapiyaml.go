@@ -0,0 +1,129 @@
+// Package openapiyaml serves and exports the OpenAPI documents generated by
+// [propre.OpenAPI] in YAML. It lives in its own package so that only the
+// applications requiring YAML import a YAML library.
+//
+//	api := propre.NewOpenAPI(propre.OpenAPIInfo{Title: "Todos", Version: "1.0.0"})
+//	mux.Handle("GET /openapi.json", api)
+//	mux.Handle("GET /openapi.yaml", openapiyaml.NewHandler(api))
+//
+//	cli.Handle("openapi", "export the OpenAPI document", openapiyaml.NewCommand(api))
+package openapiyaml
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+// Marshal returns the OpenAPI document encoded in YAML.
+func Marshal(api *propre.OpenAPI) ([]byte, error) {
+	// JSON is valid YAML: decoding it in a node keeps the order of the keys,
+	// then the node is re-encoded in block style.
+	doc, err := api.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(doc, &node); err != nil {
+		return nil, err
+	}
+
+	resetStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), encoder.Close()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// Handler is an [http.Handler] serving an OpenAPI document as YAML if the path
+// of the request ends with ".yaml" or ".yml" or if the Accept header asks for
+// it, as JSON otherwise.
+type Handler struct {
+	api *propre.OpenAPI
+}
+
+// NewHandler builds a Handler serving the given document.
+func NewHandler(api *propre.OpenAPI) *Handler {
+	return &Handler{api: api}
+}
+
+// ServeHTTP allows Handler to be used by any HTTP "ServeMux".
+func (handler *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	asYAML := strings.HasSuffix(req.URL.Path, ".yaml") ||
+		strings.HasSuffix(req.URL.Path, ".yml") ||
+		strings.Contains(req.Header.Get("Accept"), "yaml")
+
+	if !asYAML {
+		handler.api.ServeHTTP(rw, req)
+		return
+	}
+
+	doc, err := Marshal(handler.api)
+	if err != nil {
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/yaml")
+	rw.Write(doc)
+}
+
+// Command is a [propre.Command] exporting an OpenAPI document to the standard
+// output. The format is set with the -format flag, "json" (default) or "yaml".
+type Command struct {
+	api *propre.OpenAPI
+}
+
+// NewCommand builds a Command exporting the given document.
+func NewCommand(api *propre.OpenAPI) *Command {
+	return &Command{api: api}
+}
+
+// Run implements [propre.Command].
+func (command *Command) Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "json", "format of the document, json or yaml")
+	if err := flags.Parse(args); err != nil {
+		return propre.ExitUsage
+	}
+
+	switch *format {
+	case "json":
+		return command.api.Run(ctx, flags.Args(), stdout, stderr)
+	case "yaml", "yml":
+	default:
+		fmt.Fprintf(stderr, "openapi: unknown format %q\n", *format)
+		return propre.ExitUsage
+	}
+
+	doc, err := Marshal(command.api)
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi: %s\n", err)
+		return propre.ExitFailure
+	}
+
+	stdout.Write(doc)
+	return propre.ExitSuccess
+}
@@ -0,0 +1,124 @@
+package openapiyaml_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/openapiyaml"
+)
+
+func newTodoOpenAPI() *propre.OpenAPI {
+	api := propre.NewOpenAPI(propre.OpenAPIInfo{Title: "Todos", Version: "1.0.0"})
+	api.Register("DELETE /todos/{id}", propre.OpenAPIOperation{
+		OperationID: "deleteTodo",
+		Responses:   []propre.OpenAPIResponse{propre.OpenAPIResponseOf(propre.NoContent(), "")},
+	})
+
+	return api
+}
+
+func TestMarshalKeepsTheStatusCodesAsStrings(t *testing.T) {
+	doc, err := openapiyaml.Marshal(newTodoOpenAPI())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !strings.HasPrefix(string(doc), "openapi: 3.1.0\ninfo:\n  title: Todos\n") {
+		t.Fatalf("unexpected document:\n%s", doc)
+	}
+
+	if !strings.Contains(string(doc), `"204":`) {
+		t.Fatalf("the status codes should be quoted:\n%s", doc)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := openapiyaml.NewHandler(newTodoOpenAPI())
+
+	type testCase struct {
+		path                string
+		accept              string
+		expectedContentType string
+		expectedPrefix      string
+	}
+
+	testCases := map[string]testCase{
+		"json": {
+			path:                "/openapi.json",
+			expectedContentType: "application/json",
+			expectedPrefix:      "{\n  \"openapi\": \"3.1.0\"",
+		},
+		"yaml extension": {
+			path:                "/openapi.yaml",
+			expectedContentType: "application/yaml",
+			expectedPrefix:      "openapi: 3.1.0\n",
+		},
+		"yaml accept header": {
+			path:                "/openapi",
+			accept:              "application/yaml",
+			expectedContentType: "application/yaml",
+			expectedPrefix:      "openapi: 3.1.0\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept", tc.accept)
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			if rw.Header().Get("Content-Type") != tc.expectedContentType {
+				t.Fatalf("unexpected content type %q", rw.Header().Get("Content-Type"))
+			}
+
+			if !strings.HasPrefix(rw.Body.String(), tc.expectedPrefix) {
+				t.Fatalf("unexpected document:\n%s", rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	mux := propre.NewCLIMux("app")
+	mux.Handle("openapi", "export the OpenAPI document", openapiyaml.NewCommand(newTodoOpenAPI()))
+
+	type testCase struct {
+		args             []string
+		expectedExitCode int
+		expectedPrefix   string
+	}
+
+	testCases := map[string]testCase{
+		"json by default": {
+			args:             []string{"openapi"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedPrefix:   "{\n  \"openapi\": \"3.1.0\"",
+		},
+		"yaml": {
+			args:             []string{"openapi", "-format", "yaml"},
+			expectedExitCode: propre.ExitSuccess,
+			expectedPrefix:   "openapi: 3.1.0\n",
+		},
+		"unknown format": {
+			args:             []string{"openapi", "-format", "xml"},
+			expectedExitCode: propre.ExitUsage,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			exitCode := mux.Run(context.Background(), tc.args, &stdout, &stderr)
+			if exitCode != tc.expectedExitCode || !strings.HasPrefix(stdout.String(), tc.expectedPrefix) {
+				t.Fatalf("unexpected result %d, stdout %q, stderr %q", exitCode, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
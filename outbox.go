@@ -0,0 +1,308 @@
+package propre
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrOutboxNoUnitOfWork is returned by an [OutboxStore] if the messages are
+	// not appended in a unit of work, see [TransactionalUseCase].
+	ErrOutboxNoUnitOfWork = errors.New("outbox messages must be appended in a unit of work")
+
+	// ErrOutboxPublication is reported by [OutboxRelay] when a message cannot be
+	// published. The message stays in the outbox to be published again.
+	ErrOutboxPublication = errors.New("outbox publication error")
+)
+
+// OutboxMessage is an event stored in the outbox until it is published.
+type OutboxMessage struct {
+	// ID is set by the store, the messages are published in the order of their IDs.
+	ID int64
+	// Aggregate identifies the entity the event belongs to, like "todo-1". The
+	// messages of an aggregate are published in order.
+	Aggregate string
+	// Type is the name of the event, see [EventNamer].
+	Type string
+	// Payload is the encoded event.
+	Payload   []byte
+	CreatedAt time.Time
+}
+
+// Message converts the outbox message to a [Message], to be published with a
+// broker. The ID of the message is the one of the outbox message, so the
+// consumers can detect the duplicates, and the aggregate and the type are set
+// in the headers.
+func (m OutboxMessage) Message() Message {
+	return Message{
+		ID:   strconv.FormatInt(m.ID, 10),
+		Body: m.Payload,
+		Headers: map[string]string{
+			"aggregate": m.Aggregate,
+			"type":      m.Type,
+		},
+	}
+}
+
+// OutboxStore is the interface of the storage of an [Outbox].
+//
+// [SQLOutboxStore] stores the messages in a database/sql table, and
+// [InMemoryOutboxStore] is a fake to test the use cases without a database.
+type OutboxStore interface {
+	// Append stores the messages in the unit of work of the context, so they are
+	// stored if and only if the changes of the use case are committed. It returns
+	// [ErrOutboxNoUnitOfWork] if the context holds no unit of work.
+	Append(ctx context.Context, messages ...OutboxMessage) error
+	// Pending returns at most limit messages not delivered yet, ordered by ID,
+	// without the messages of the excluded aggregates.
+	Pending(ctx context.Context, limit int, excluded ...string) ([]OutboxMessage, error)
+	// MarkDelivered marks the messages as delivered.
+	MarkDelivered(ctx context.Context, ids ...int64) error
+	// DeleteDelivered deletes the messages delivered before the given time, and
+	// returns the number of deleted messages.
+	DeleteDelivered(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxPublisher publishes the messages relayed by an [OutboxRelay], like a
+// message broker client.
+type OutboxPublisher interface {
+	Publish(ctx context.Context, msg OutboxMessage) error
+}
+
+// OutboxPublisherFunc is an adapter to use an ordinary function as an [OutboxPublisher].
+type OutboxPublisherFunc func(ctx context.Context, msg OutboxMessage) error
+
+// Publish calls f(ctx, msg).
+func (f OutboxPublisherFunc) Publish(ctx context.Context, msg OutboxMessage) error {
+	return f(ctx, msg)
+}
+
+// EventNamer can be implemented by the events to set the type of their outbox
+// messages. The default type is the name of the Go type of the event.
+type EventNamer interface {
+	EventName() string
+}
+
+// Outbox encodes the events of the use cases to [OutboxMessage] values and
+// appends them to its store, in the unit of work of the use case. They are
+// published afterwards by an [OutboxRelay], so an event cannot be lost once
+// the changes of the use case are committed.
+type Outbox struct {
+	store  OutboxStore
+	encode func(event any) ([]byte, error)
+}
+
+// OutboxOpts is the alias for the [Outbox] builder options.
+type OutboxOpts func(o *Outbox)
+
+// WithOutboxEncoder is an [Outbox] option to set the encoder of the events.
+// The default is [json.Marshal].
+func WithOutboxEncoder(encode func(event any) ([]byte, error)) OutboxOpts {
+	return func(o *Outbox) {
+		o.encode = encode
+	}
+}
+
+// NewOutbox builds an [Outbox] appending the messages to the given store.
+func NewOutbox(store OutboxStore, opts ...OutboxOpts) *Outbox {
+	o := &Outbox{
+		store:  store,
+		encode: json.Marshal,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Add encodes the events of the aggregate and appends them to the outbox. It
+// must be called with the context given to a [TransactionalUseCase], like in a
+// repository saving the aggregate.
+func (o *Outbox) Add(ctx context.Context, aggregate string, events ...any) error {
+	messages := make([]OutboxMessage, 0, len(events))
+	for _, event := range events {
+		payload, err := o.encode(event)
+		if err != nil {
+			return fmt.Errorf("cannot encode the event %T: %w", event, err)
+		}
+
+		eventType := fmt.Sprintf("%T", event)
+		if namer, ok := event.(EventNamer); ok {
+			eventType = namer.EventName()
+		}
+
+		messages = append(messages, OutboxMessage{
+			Aggregate: aggregate,
+			Type:      eventType,
+			Payload:   payload,
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return o.store.Append(ctx, messages...)
+}
+
+// OutboxRelay publishes the messages of an [OutboxStore] and marks them as
+// delivered. The delivery is at least once: a message is published again if
+// the relay stops before marking it as delivered, so the consumers must
+// handle the duplicates, like with the message ID.
+//
+// The messages of an aggregate are published in order: when a message cannot
+// be published, the next messages of its aggregate wait for it to be published.
+// The delivered messages are deleted once the retention is over.
+type OutboxRelay struct {
+	store        OutboxStore
+	publisher    OutboxPublisher
+	batchSize    int
+	interval     time.Duration
+	retention    time.Duration
+	errorHandler func(ctx context.Context, msg *OutboxMessage, err error)
+}
+
+// OutboxRelayOpts is the alias for the [OutboxRelay] builder options.
+type OutboxRelayOpts func(r *OutboxRelay)
+
+// WithOutboxBatchSize is an [OutboxRelay] option to set the maximum number of
+// messages read at once from the store. The default is 100.
+func WithOutboxBatchSize(size int) OutboxRelayOpts {
+	return func(r *OutboxRelay) {
+		r.batchSize = max(size, 1)
+	}
+}
+
+// WithOutboxInterval is an [OutboxRelay] option to set the delay between two
+// polls of the store once every pending message is relayed. The default is 1s,
+// and it is at least 1ms.
+func WithOutboxInterval(interval time.Duration) OutboxRelayOpts {
+	return func(r *OutboxRelay) {
+		r.interval = max(interval, time.Millisecond)
+	}
+}
+
+// WithOutboxRetention is an [OutboxRelay] option to keep the delivered messages
+// for the given duration before deleting them. By default, they are deleted at
+// the end of the poll they were delivered in.
+func WithOutboxRetention(retention time.Duration) OutboxRelayOpts {
+	return func(r *OutboxRelay) {
+		r.retention = retention
+	}
+}
+
+// WithOutboxErrorHandler is an [OutboxRelay] option to be notified of the
+// errors of [OutboxRelay.Run], like a publication error wrapping
+// [ErrOutboxPublication]. The message is nil for the errors of the store.
+func WithOutboxErrorHandler(handler func(ctx context.Context, msg *OutboxMessage, err error)) OutboxRelayOpts {
+	return func(r *OutboxRelay) {
+		r.errorHandler = handler
+	}
+}
+
+// NewOutboxRelay builds an [OutboxRelay] publishing the messages of the store
+// with the given publisher.
+func NewOutboxRelay(store OutboxStore, publisher OutboxPublisher, opts ...OutboxRelayOpts) *OutboxRelay {
+	r := &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		batchSize: 100,
+		interval:  time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run relays the messages until the context is done, then it returns nil.
+// The errors do not stop the relay, they are notified to the error handler set
+// with [WithOutboxErrorHandler] and the messages are relayed again at the next poll.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *OutboxRelay) poll(ctx context.Context) {
+	if _, err := r.RelayOnce(ctx); err != nil {
+		r.reportError(ctx, nil, err)
+		return
+	}
+
+	if _, err := r.store.DeleteDelivered(ctx, time.Now().Add(-r.retention)); err != nil {
+		r.reportError(ctx, nil, err)
+	}
+}
+
+// RelayOnce publishes the pending messages, batch by batch, and marks the
+// published ones as delivered. It returns the number of published messages,
+// and the error of the store, if any. The publication errors are notified to
+// the error handler set with [WithOutboxErrorHandler].
+//
+// Once a message of an aggregate cannot be published, the next batches exclude
+// its aggregate, so the other aggregates are relayed whatever the number of
+// messages waiting behind it.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	blocked := make(map[string]bool)
+	var excluded []string
+	published := 0
+	for ctx.Err() == nil {
+		messages, err := r.store.Pending(ctx, r.batchSize, excluded...)
+		if err != nil {
+			return published, err
+		}
+
+		delivered := make([]int64, 0, len(messages))
+		for i := range messages {
+			msg := &messages[i]
+			if blocked[msg.Aggregate] {
+				continue
+			}
+
+			if err := r.publisher.Publish(ctx, *msg); err != nil {
+				blocked[msg.Aggregate] = true
+				excluded = append(excluded, msg.Aggregate)
+				r.reportError(ctx, msg, fmt.Errorf("%w caused by %w", ErrOutboxPublication, err))
+				continue
+			}
+
+			delivered = append(delivered, msg.ID)
+		}
+
+		if len(delivered) > 0 {
+			if err := r.store.MarkDelivered(ctx, delivered...); err != nil {
+				return published, err
+			}
+		}
+
+		published += len(delivered)
+		if len(messages) < r.batchSize {
+			break
+		}
+	}
+
+	return published, nil
+}
+
+func (r *OutboxRelay) reportError(ctx context.Context, msg *OutboxMessage, err error) {
+	if r.errorHandler == nil {
+		return
+	}
+
+	r.errorHandler(ctx, msg, err)
+}
@@ -0,0 +1,168 @@
+package propre
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+type inMemoryOutboxEntry struct {
+	message     OutboxMessage
+	deliveredAt time.Time
+}
+
+// InMemoryOutboxStore is an [OutboxStore] keeping the messages in memory. The
+// messages are appended once the [InMemoryUnitOfWork] of the context is committed,
+// see [InMemoryTxManager], so the tests can check that the events of a failing
+// use case are not published.
+type InMemoryOutboxStore struct {
+	mu       sync.Mutex
+	entries  map[int64]*inMemoryOutboxEntry
+	sequence int64
+}
+
+// NewInMemoryOutboxStore builds an empty [InMemoryOutboxStore].
+func NewInMemoryOutboxStore() *InMemoryOutboxStore {
+	return &InMemoryOutboxStore{
+		entries: make(map[int64]*inMemoryOutboxEntry),
+	}
+}
+
+// Append implements [OutboxStore]. It returns [ErrOutboxNoUnitOfWork] if the
+// context holds no [InMemoryUnitOfWork].
+func (s *InMemoryOutboxStore) Append(ctx context.Context, messages ...OutboxMessage) error {
+	uow, ok := UnitOfWorkFromContext(ctx)
+	if !ok {
+		return ErrOutboxNoUnitOfWork
+	}
+
+	inMemoryUOW, ok := uow.(*InMemoryUnitOfWork)
+	if !ok {
+		return ErrOutboxNoUnitOfWork
+	}
+
+	messages = append([]OutboxMessage(nil), messages...)
+	inMemoryUOW.OnCommit(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, msg := range messages {
+			s.sequence++
+			msg.ID = s.sequence
+			s.entries[msg.ID] = &inMemoryOutboxEntry{message: msg}
+		}
+	})
+
+	return nil
+}
+
+// Pending implements [OutboxStore].
+func (s *InMemoryOutboxStore) Pending(ctx context.Context, limit int, excluded ...string) ([]OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []OutboxMessage
+	for _, entry := range s.entries {
+		if entry.deliveredAt.IsZero() && !slices.Contains(excluded, entry.message.Aggregate) {
+			messages = append(messages, entry.message)
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages[:min(limit, len(messages))], nil
+}
+
+// MarkDelivered implements [OutboxStore].
+func (s *InMemoryOutboxStore) MarkDelivered(ctx context.Context, ids ...int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, id := range ids {
+		if entry, ok := s.entries[id]; ok {
+			entry.deliveredAt = now
+		}
+	}
+
+	return nil
+}
+
+// DeleteDelivered implements [OutboxStore].
+func (s *InMemoryOutboxStore) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, entry := range s.entries {
+		if !entry.deliveredAt.IsZero() && entry.deliveredAt.Before(before) {
+			delete(s.entries, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Len returns the number of messages in the store, delivered or not.
+func (s *InMemoryOutboxStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// InMemoryOutboxPublisher is an [OutboxPublisher] recording the published
+// messages. It is meant to test an [OutboxRelay] without a message broker, and
+// it can fail to publish the messages of an aggregate, see [InMemoryOutboxPublisher.Fail].
+type InMemoryOutboxPublisher struct {
+	mu        sync.Mutex
+	published []OutboxMessage
+	failures  map[string]error
+}
+
+// NewInMemoryOutboxPublisher builds an [InMemoryOutboxPublisher].
+func NewInMemoryOutboxPublisher() *InMemoryOutboxPublisher {
+	return &InMemoryOutboxPublisher{
+		failures: make(map[string]error),
+	}
+}
+
+// Fail makes the publisher return the error for the messages of the aggregate,
+// until it is called again with a nil error.
+func (p *InMemoryOutboxPublisher) Fail(aggregate string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		delete(p.failures, aggregate)
+		return
+	}
+
+	p.failures[aggregate] = err
+}
+
+// Publish implements [OutboxPublisher].
+func (p *InMemoryOutboxPublisher) Publish(ctx context.Context, msg OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.failures[msg.Aggregate]; err != nil {
+		return err
+	}
+
+	p.published = append(p.published, msg)
+	return nil
+}
+
+// Published returns the published messages, in order.
+func (p *InMemoryOutboxPublisher) Published() []OutboxMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]OutboxMessage(nil), p.published...)
+}
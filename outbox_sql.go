@@ -0,0 +1,172 @@
+package propre
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// SQLOutboxStore is an [OutboxStore] keeping the messages in a database/sql
+// table. The messages are appended in the transaction of the [SQLUnitOfWork]
+// of the context, see [SQLTxManager].
+//
+// The table must be created beforehand, like with PostgreSQL:
+//
+//	CREATE TABLE propre_outbox (
+//		id           BIGSERIAL PRIMARY KEY,
+//		aggregate    TEXT NOT NULL,
+//		type         TEXT NOT NULL,
+//		payload      BYTEA NOT NULL,
+//		created_at   TIMESTAMPTZ NOT NULL,
+//		delivered_at TIMESTAMPTZ
+//	);
+//
+//	CREATE INDEX propre_outbox_pending ON propre_outbox (id) WHERE delivered_at IS NULL;
+//
+// The pending messages are read without locking the rows, so a single
+// [OutboxRelay] must read the table at a time, like with a leader election or
+// an advisory lock, otherwise the messages are published several times and out
+// of order. Locking the rows with FOR UPDATE SKIP LOCKED would not be enough, as
+// the messages are marked as delivered once published, outside the transaction
+// of the read.
+//
+// The queries bind the limit of the read as a parameter, "LIMIT ?", which is
+// supported by PostgreSQL, MySQL and SQLite. SQL Server and Oracle have no LIMIT
+// clause: implement an [OutboxStore] with their syntax instead.
+type SQLOutboxStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// SQLOutboxStoreOpts is the alias for the [SQLOutboxStore] builder options.
+type SQLOutboxStoreOpts func(s *SQLOutboxStore)
+
+// WithSQLOutboxTable is a [SQLOutboxStore] option to set the name of the table.
+// The default is propre_outbox.
+func WithSQLOutboxTable(table string) SQLOutboxStoreOpts {
+	return func(s *SQLOutboxStore) {
+		s.table = table
+	}
+}
+
+// WithSQLOutboxPlaceholder is a [SQLOutboxStore] option to set the placeholder
+// of the nth parameter of the queries, starting at 1. The default is "?", used
+// by MySQL and SQLite. PostgreSQL requires numbered placeholders:
+//
+//	propre.WithSQLOutboxPlaceholder(func(n int) string {
+//		return "$" + strconv.Itoa(n)
+//	})
+func WithSQLOutboxPlaceholder(placeholder func(n int) string) SQLOutboxStoreOpts {
+	return func(s *SQLOutboxStore) {
+		s.placeholder = placeholder
+	}
+}
+
+// NewSQLOutboxStore builds a [SQLOutboxStore] reading the messages from the database.
+func NewSQLOutboxStore(db *sql.DB, opts ...SQLOutboxStoreOpts) *SQLOutboxStore {
+	s := &SQLOutboxStore{
+		db:    db,
+		table: "propre_outbox",
+		placeholder: func(int) string {
+			return "?"
+		},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Append implements [OutboxStore]. It returns [ErrOutboxNoUnitOfWork] if the
+// context holds no [SQLUnitOfWork].
+func (s *SQLOutboxStore) Append(ctx context.Context, messages ...OutboxMessage) error {
+	tx, ok := SQLTxFromContext(ctx)
+	if !ok {
+		return ErrOutboxNoUnitOfWork
+	}
+
+	query := "INSERT INTO " + s.table + " (aggregate, type, payload, created_at) VALUES (" + s.placeholders(1, 4) + ")"
+	for _, msg := range messages {
+		if _, err := tx.ExecContext(ctx, query, msg.Aggregate, msg.Type, msg.Payload, msg.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pending implements [OutboxStore].
+func (s *SQLOutboxStore) Pending(ctx context.Context, limit int, excluded ...string) ([]OutboxMessage, error) {
+	query := "SELECT id, aggregate, type, payload, created_at FROM " + s.table + " WHERE delivered_at IS NULL"
+	args := make([]any, 0, len(excluded)+1)
+	if len(excluded) > 0 {
+		query += " AND aggregate NOT IN (" + s.placeholders(1, len(excluded)) + ")"
+		for _, aggregate := range excluded {
+			args = append(args, aggregate)
+		}
+	}
+
+	query += " ORDER BY id LIMIT " + s.placeholder(len(args)+1)
+	rows, err := s.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var msg OutboxMessage
+		if err := rows.Scan(&msg.ID, &msg.Aggregate, &msg.Type, &msg.Payload, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// MarkDelivered implements [OutboxStore].
+func (s *SQLOutboxStore) MarkDelivered(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, time.Now())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := "UPDATE " + s.table + " SET delivered_at = " + s.placeholder(1) +
+		" WHERE id IN (" + s.placeholders(2, len(ids)) + ")"
+
+	_, err := s.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// DeleteDelivered implements [OutboxStore].
+func (s *SQLOutboxStore) DeleteDelivered(ctx context.Context, before time.Time) (int64, error) {
+	query := "DELETE FROM " + s.table + " WHERE delivered_at IS NOT NULL AND delivered_at < " + s.placeholder(1)
+	result, err := s.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// placeholders returns count placeholders separated by commas, numbered from first.
+func (s *SQLOutboxStore) placeholders(first, count int) string {
+	placeholders := make([]string, count)
+	for i := range placeholders {
+		placeholders[i] = s.placeholder(first + i)
+	}
+
+	return strings.Join(placeholders, ", ")
+}
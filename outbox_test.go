@@ -0,0 +1,277 @@
+package propre_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func (e todoDeleted) EventName() string { return "todo.deleted" }
+
+type completeTodoWithOutboxUseCase struct {
+	outbox *propre.Outbox
+	err    error
+}
+
+func (u completeTodoWithOutboxUseCase) Handle(ctx context.Context, id string) saveTodoOutput {
+	if err := u.outbox.Add(ctx, id, todoCompleted{ID: id}, todoDeleted{ID: id}); err != nil {
+		return saveTodoOutput{Error: err}
+	}
+
+	return saveTodoOutput{Error: u.err}
+}
+
+func TestOutbox(t *testing.T) {
+	type testCase struct {
+		transactional bool
+		useCaseErr    error
+		expectedErr   error
+		expectedTypes []string
+	}
+
+	testCases := map[string]testCase{
+		"the events are stored once the unit of work is committed": {
+			transactional: true,
+			expectedTypes: []string{"propre_test.todoCompleted", "todo.deleted"},
+		},
+		"the events are dropped with the unit of work": {
+			transactional: true,
+			useCaseErr:    errors.New("use case error"),
+			expectedErr:   errors.New("use case error"),
+		},
+		"the events cannot be stored outside of a unit of work": {
+			expectedErr: propre.ErrOutboxNoUnitOfWork,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			store := propre.NewInMemoryOutboxStore()
+			var useCase propre.UseCaseHandler[string, saveTodoOutput] = completeTodoWithOutboxUseCase{
+				outbox: propre.NewOutbox(store),
+				err:    tc.useCaseErr,
+			}
+
+			if tc.transactional {
+				useCase = propre.NewTransactionalUseCase(useCase, propre.NewInMemoryTxManager(), saveTodoOutputError, saveTodoErrorOutput)
+			}
+
+			output := useCase.Handle(context.Background(), "todo-1")
+			if (output.Error == nil) != (tc.expectedErr == nil) ||
+				(output.Error != nil && output.Error.Error() != tc.expectedErr.Error()) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, output.Error)
+			}
+
+			messages, err := store.Pending(context.Background(), 10)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			var types []string
+			for _, msg := range messages {
+				types = append(types, msg.Type)
+			}
+
+			if !reflect.DeepEqual(types, tc.expectedTypes) {
+				t.Fatalf("expected the types %q, got %q", tc.expectedTypes, types)
+			}
+		})
+	}
+}
+
+// appendOutboxMessages commits a message per aggregate in the store.
+func appendOutboxMessages(t *testing.T, store propre.OutboxStore, aggregates ...string) {
+	t.Helper()
+
+	uow, err := propre.NewInMemoryTxManager().Begin(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for i, aggregate := range aggregates {
+		msg := propre.OutboxMessage{Aggregate: aggregate, Payload: []byte(strconv.Itoa(i + 1))}
+		if err := store.Append(propre.ContextWithUnitOfWork(context.Background(), uow), msg); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if err := uow.Commit(context.Background()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func publishedPayloads(publisher *propre.InMemoryOutboxPublisher) []string {
+	var payloads []string
+	for _, msg := range publisher.Published() {
+		payloads = append(payloads, msg.Aggregate+":"+string(msg.Payload))
+	}
+
+	return payloads
+}
+
+func TestOutboxRelayPublishesTheMessagesOfAnAggregateInOrder(t *testing.T) {
+	store := propre.NewInMemoryOutboxStore()
+	appendOutboxMessages(t, store, "todo-1", "todo-2", "todo-1", "todo-3", "todo-2")
+
+	publisher := propre.NewInMemoryOutboxPublisher()
+	publisher.Fail("todo-2", errors.New("broker error"))
+
+	var reported []error
+	relay := propre.NewOutboxRelay(store, publisher, propre.WithOutboxErrorHandler(
+		func(ctx context.Context, msg *propre.OutboxMessage, err error) {
+			reported = append(reported, err)
+		},
+	))
+
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil || published != 3 {
+		t.Fatalf("expected 3 published messages, got %d and the error %v", published, err)
+	}
+
+	expected := []string{"todo-1:1", "todo-1:3", "todo-3:4"}
+	if payloads := publishedPayloads(publisher); !reflect.DeepEqual(payloads, expected) {
+		t.Fatalf("expected the messages %q, got %q", expected, payloads)
+	}
+
+	msg := publisher.Published()[0].Message()
+	if msg.ID != "1" || msg.Headers["aggregate"] != "todo-1" {
+		t.Fatalf("expected the message 1 of the aggregate todo-1, got %+v", msg)
+	}
+
+	if len(reported) != 1 || !errors.Is(reported[0], propre.ErrOutboxPublication) {
+		t.Fatalf("expected a publication error, got %v", reported)
+	}
+
+	publisher.Fail("todo-2", nil)
+	if published, err := relay.RelayOnce(context.Background()); err != nil || published != 2 {
+		t.Fatalf("expected 2 published messages, got %d and the error %v", published, err)
+	}
+
+	expected = append(expected, "todo-2:2", "todo-2:5")
+	if payloads := publishedPayloads(publisher); !reflect.DeepEqual(payloads, expected) {
+		t.Fatalf("expected the messages %q, got %q", expected, payloads)
+	}
+}
+
+func TestOutboxRelayDoesNotStallOnAFailingAggregate(t *testing.T) {
+	store := propre.NewInMemoryOutboxStore()
+	appendOutboxMessages(t, store, "todo-2", "todo-2", "todo-2", "todo-2", "todo-2", "todo-1", "todo-3")
+
+	publisher := propre.NewInMemoryOutboxPublisher()
+	publisher.Fail("todo-2", errors.New("broker error"))
+
+	relay := propre.NewOutboxRelay(store, publisher, propre.WithOutboxBatchSize(2))
+	published, err := relay.RelayOnce(context.Background())
+	if err != nil || published != 2 {
+		t.Fatalf("expected 2 published messages, got %d and the error %v", published, err)
+	}
+
+	expected := []string{"todo-1:6", "todo-3:7"}
+	if payloads := publishedPayloads(publisher); !reflect.DeepEqual(payloads, expected) {
+		t.Fatalf("expected the messages %q, got %q", expected, payloads)
+	}
+}
+
+func TestOutboxRelayRun(t *testing.T) {
+	store := propre.NewInMemoryOutboxStore()
+	appendOutboxMessages(t, store, "todo-1", "todo-2", "todo-3")
+
+	publisher := propre.NewInMemoryOutboxPublisher()
+	relay := propre.NewOutboxRelay(store, publisher, propre.WithOutboxBatchSize(2), propre.WithOutboxInterval(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- relay.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for store.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if store.Len() != 0 {
+		t.Fatalf("expected the delivered messages to be deleted, got %d messages", store.Len())
+	}
+
+	expected := []string{"todo-1:1", "todo-2:2", "todo-3:3"}
+	if payloads := publishedPayloads(publisher); !reflect.DeepEqual(payloads, expected) {
+		t.Fatalf("expected the messages %q, got %q", expected, payloads)
+	}
+}
+
+func TestSQLOutboxStore(t *testing.T) {
+	db, recorder := openRecordingDB(t)
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	recorder.columns = []string{"id", "aggregate", "type", "payload", "created_at"}
+	recorder.rows = [][]driver.Value{{int64(1), "todo-1", "todo.deleted", []byte(`{}`), createdAt}}
+
+	store := propre.NewSQLOutboxStore(db, propre.WithSQLOutboxPlaceholder(func(n int) string {
+		return "$" + strconv.Itoa(n)
+	}))
+
+	if err := store.Append(context.Background(), propre.OutboxMessage{}); !errors.Is(err, propre.ErrOutboxNoUnitOfWork) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrOutboxNoUnitOfWork, err)
+	}
+
+	useCase := propre.NewTransactionalUseCase[string, saveTodoOutput](
+		completeTodoWithOutboxUseCase{outbox: propre.NewOutbox(store)},
+		propre.NewSQLTxManager(db),
+		saveTodoOutputError,
+		saveTodoErrorOutput,
+	)
+
+	if output := useCase.Handle(context.Background(), "todo-1"); output.Error != nil {
+		t.Fatalf("expected no error, got %v", output.Error)
+	}
+
+	messages, err := store.Pending(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expectedMessages := []propre.OutboxMessage{
+		{ID: 1, Aggregate: "todo-1", Type: "todo.deleted", Payload: []byte(`{}`), CreatedAt: createdAt},
+	}
+
+	if !reflect.DeepEqual(messages, expectedMessages) {
+		t.Fatalf("expected the messages %v, got %v", expectedMessages, messages)
+	}
+
+	if _, err := store.Pending(context.Background(), 10, "todo-2", "todo-3"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := store.MarkDelivered(context.Background(), 1, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if deleted, err := store.DeleteDelivered(context.Background(), time.Now()); err != nil || deleted != 1 {
+		t.Fatalf("expected 1 deleted message, got %d and the error %v", deleted, err)
+	}
+
+	insert := "INSERT INTO propre_outbox (aggregate, type, payload, created_at) VALUES ($1, $2, $3, $4)"
+	expectedStatements := []string{
+		"BEGIN", insert, insert, "COMMIT",
+		"SELECT id, aggregate, type, payload, created_at FROM propre_outbox WHERE delivered_at IS NULL ORDER BY id LIMIT $1",
+		"SELECT id, aggregate, type, payload, created_at FROM propre_outbox WHERE delivered_at IS NULL " +
+			"AND aggregate NOT IN ($1, $2) ORDER BY id LIMIT $3",
+		"UPDATE propre_outbox SET delivered_at = $1 WHERE id IN ($2, $3)",
+		"DELETE FROM propre_outbox WHERE delivered_at IS NOT NULL AND delivered_at < $1",
+	}
+
+	if statements := recorder.Statements(); !reflect.DeepEqual(statements, expectedStatements) {
+		t.Fatalf("expected the statements %q, got %q", expectedStatements, statements)
+	}
+}
@@ -0,0 +1,99 @@
+package propre
+
+import "context"
+
+// UseCaseHandlerFunc is an adapter to use an ordinary function as a [UseCaseHandler].
+// The combinators [Chain], [MapInput], [MapOutput], [Branch] and [When] return
+// a UseCaseHandlerFunc, so a pipeline of use cases is a use case itself and can
+// be given to [NewHTTPHandler] unchanged.
+type UseCaseHandlerFunc[Input, Output any] func(ctx context.Context, input Input) Output
+
+// Handle calls f(ctx, input).
+func (f UseCaseHandlerFunc[Input, Output]) Handle(ctx context.Context, input Input) Output {
+	return f(ctx, input)
+}
+
+// Chain composes two use cases, A→B and C→D, into a use case A→D, like
+// validate → persist. The mapping converts the output of the first use case
+// into the input of the second one, and returns the error carried by the output:
+//
+//	func(ctx context.Context, output ValidateTodoOutput) (PersistTodoInput, error) {
+//		var input PersistTodoInput
+//		if output.Error != nil {
+//			return input, output.Error
+//		}
+//
+//		input.Data.Todo = output.Data.Todo
+//		return input, nil
+//	}
+//
+// The pipeline stops early if the mapping returns an error or if the context is
+// done after the first use case: the second one is not called and the error is
+// converted into the final output by errorOutput.
+// Chains can be nested to compose more than two use cases.
+func Chain[A, B, C, D any](
+	first UseCaseHandler[A, B],
+	mapping func(ctx context.Context, output B) (C, error),
+	second UseCaseHandler[C, D],
+	errorOutput func(err error) D,
+) UseCaseHandlerFunc[A, D] {
+	return func(ctx context.Context, input A) D {
+		next, err := mapping(ctx, first.Handle(ctx, input))
+		if err != nil {
+			return errorOutput(err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return errorOutput(err)
+		}
+
+		return second.Handle(ctx, next)
+	}
+}
+
+// MapInput adapts the input of a use case B→C to build a use case A→C, like
+// an interactor reused by several endpoints with their own input types.
+func MapInput[A, B, C any](mapping func(ctx context.Context, input A) B, useCaseHandler UseCaseHandler[B, C]) UseCaseHandlerFunc[A, C] {
+	return func(ctx context.Context, input A) C {
+		return useCaseHandler.Handle(ctx, mapping(ctx, input))
+	}
+}
+
+// MapOutput adapts the output of a use case A→B to build a use case A→C, like
+// an output reused by several presenters with their own output types.
+// The mapping is called with the errors carried by the output as well.
+func MapOutput[A, B, C any](useCaseHandler UseCaseHandler[A, B], mapping func(ctx context.Context, output B) C) UseCaseHandlerFunc[A, C] {
+	return func(ctx context.Context, input A) C {
+		return mapping(ctx, useCaseHandler.Handle(ctx, input))
+	}
+}
+
+// Branch builds a use case calling then if the condition holds for the input,
+// and otherwise if it does not, like a creation or an update depending on the
+// presence of an ID.
+func Branch[Input, Output any](
+	condition func(ctx context.Context, input Input) bool,
+	then UseCaseHandler[Input, Output],
+	otherwise UseCaseHandler[Input, Output],
+) UseCaseHandlerFunc[Input, Output] {
+	return func(ctx context.Context, input Input) Output {
+		if condition(ctx, input) {
+			return then.Handle(ctx, input)
+		}
+
+		return otherwise.Handle(ctx, input)
+	}
+}
+
+// When builds a use case calling the given one if the condition holds for the
+// input, and returning the input unchanged if it does not, like an optional
+// enrichment step of a pipeline.
+func When[T any](condition func(ctx context.Context, input T) bool, useCaseHandler UseCaseHandler[T, T]) UseCaseHandlerFunc[T, T] {
+	return func(ctx context.Context, input T) T {
+		if !condition(ctx, input) {
+			return input
+		}
+
+		return useCaseHandler.Handle(ctx, input)
+	}
+}
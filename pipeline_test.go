@@ -0,0 +1,226 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type pipelineKey struct{}
+
+type validateTitleOutput struct {
+	Title string
+	Error error
+}
+
+type persistTitleInput struct {
+	Title string
+}
+
+type persistTitleOutput struct {
+	ID    string
+	Error error
+}
+
+type persistTitleUseCase struct {
+	called bool
+	ctx    context.Context
+}
+
+func (u *persistTitleUseCase) Handle(ctx context.Context, input persistTitleInput) persistTitleOutput {
+	u.called = true
+	u.ctx = ctx
+	return persistTitleOutput{ID: "todo-" + input.Title}
+}
+
+var validateTitle = propre.UseCaseHandlerFunc[string, validateTitleOutput](
+	func(ctx context.Context, title string) validateTitleOutput {
+		if title == "" {
+			return validateTitleOutput{Error: errors.New("empty title")}
+		}
+
+		return validateTitleOutput{Title: strings.TrimSpace(title)}
+	},
+)
+
+func validatedTitle(ctx context.Context, output validateTitleOutput) (persistTitleInput, error) {
+	if output.Error != nil {
+		return persistTitleInput{}, output.Error
+	}
+
+	return persistTitleInput{Title: output.Title}, nil
+}
+
+func persistTitleErrorOutput(err error) persistTitleOutput {
+	return persistTitleOutput{Error: err}
+}
+
+func TestChain(t *testing.T) {
+	type testCase struct {
+		ctx            func() context.Context
+		input          string
+		expectedID     string
+		expectedErr    string
+		expectedCalled bool
+	}
+
+	testCases := map[string]testCase{
+		"the output of the first use case is given to the second one": {
+			ctx:            context.Background,
+			input:          " title ",
+			expectedID:     "todo-title",
+			expectedCalled: true,
+		},
+		"an error of the first use case stops the pipeline": {
+			ctx:         context.Background,
+			input:       "",
+			expectedErr: "empty title",
+		},
+		"a canceled context stops the pipeline": {
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			input:       "title",
+			expectedErr: context.Canceled.Error(),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			persist := &persistTitleUseCase{}
+			pipeline := propre.Chain(validateTitle, validatedTitle, persist, persistTitleErrorOutput)
+
+			ctx := context.WithValue(tc.ctx(), pipelineKey{}, "value")
+			output := pipeline.Handle(ctx, tc.input)
+			if output.ID != tc.expectedID {
+				t.Fatalf("expected the ID %q, got %q", tc.expectedID, output.ID)
+			}
+
+			if tc.expectedErr == "" && output.Error != nil {
+				t.Fatalf("expected no error, got %v", output.Error)
+			}
+
+			if tc.expectedErr != "" && (output.Error == nil || output.Error.Error() != tc.expectedErr) {
+				t.Fatalf("expected the error %q, got %v", tc.expectedErr, output.Error)
+			}
+
+			if persist.called != tc.expectedCalled {
+				t.Fatalf("expected the second use case to be called: %v, got %v", tc.expectedCalled, persist.called)
+			}
+
+			if persist.called && persist.ctx.Value(pipelineKey{}) != "value" {
+				t.Fatal("expected the context to be given to the second use case")
+			}
+		})
+	}
+}
+
+func TestMapInputAndMapOutput(t *testing.T) {
+	persist := &persistTitleUseCase{}
+	pipeline := propre.MapOutput(
+		propre.MapInput(func(ctx context.Context, title string) persistTitleInput {
+			return persistTitleInput{Title: strings.ToLower(title)}
+		}, persist),
+		func(ctx context.Context, output persistTitleOutput) string {
+			return output.ID
+		},
+	)
+
+	if id := pipeline.Handle(context.Background(), "TITLE"); id != "todo-title" {
+		t.Fatalf("expected the ID %q, got %q", "todo-title", id)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	upper := propre.UseCaseHandlerFunc[string, string](func(ctx context.Context, input string) string {
+		return strings.ToUpper(input)
+	})
+
+	lower := propre.UseCaseHandlerFunc[string, string](func(ctx context.Context, input string) string {
+		return strings.ToLower(input)
+	})
+
+	isShort := func(ctx context.Context, input string) bool {
+		return len(input) < 4
+	}
+
+	testCases := map[string]testCase{
+		"the first use case is called when the condition holds": {
+			input:    "Abc",
+			expected: "ABC",
+		},
+		"the second use case is called when the condition does not hold": {
+			input:    "Abcdef",
+			expected: "abcdef",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			branch := propre.Branch(isShort, upper, lower)
+			if output := branch.Handle(context.Background(), tc.input); output != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, output)
+			}
+
+			when := propre.When(isShort, upper)
+			expected := tc.input
+			if isShort(context.Background(), tc.input) {
+				expected = strings.ToUpper(tc.input)
+			}
+
+			if output := when.Handle(context.Background(), tc.input); output != expected {
+				t.Fatalf("expected %q, got %q", expected, output)
+			}
+		})
+	}
+}
+
+type titleRequestDecoder struct{}
+
+func (titleRequestDecoder) Decode(req *http.Request) string {
+	return req.URL.Query().Get("title")
+}
+
+type persistTitlePresenter struct{}
+
+func (persistTitlePresenter) Present(ctx context.Context, rw http.ResponseWriter, output persistTitleOutput) {
+	if output.Error != nil {
+		http.Error(rw, output.Error.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.Write([]byte(output.ID))
+}
+
+func TestPipelineInHTTPHandler(t *testing.T) {
+	handler := propre.NewHTTPHandler(
+		titleRequestDecoder{},
+		propre.Chain(validateTitle, validatedTitle, &persistTitleUseCase{}, persistTitleErrorOutput),
+		persistTitlePresenter{},
+	)
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/?title=title", nil))
+	if rw.Code != http.StatusCreated || rw.Body.String() != "todo-title" {
+		t.Fatalf("expected a created response with the ID, got %d %q", rw.Code, rw.Body.String())
+	}
+
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rw.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected the status %d, got %d", http.StatusUnprocessableEntity, rw.Code)
+	}
+}
@@ -0,0 +1,22 @@
+// Package propretest provides utilities to test the components built with propre.
+//
+// The spies record the calls of the three components of a [propre.HTTPHandler],
+// either returning canned values (the fakes) or delegating to real implementations:
+//
+//	decoder := propretest.NewRequestDecoderFake(CreateTodoInput{Title: "title"})
+//	useCase := propretest.NewUseCaseHandlerSpy(NewCreateTodoInteractor(repository))
+//	presenter := propretest.NewPresenterFake[CreateTodoOutput, http.ResponseWriter]()
+//	handler := propre.NewHTTPHandler(decoder, useCase, presenter)
+//
+// The [Request] builder sends an in-process request to any [net/http.Handler],
+// like an HTTPHandler or a router, and the returned [Response] provides fluent
+// assertions:
+//
+//	propretest.NewRequest(t, handler).
+//		Post("/todos").
+//		WithJSONBody(map[string]any{"title": "title"}).
+//		Send().
+//		AssertStatus(http.StatusCreated).
+//		AssertHeader("Content-Type", "application/json").
+//		AssertJSONPath("$.data.title", "title")
+package propretest
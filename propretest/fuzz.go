@@ -0,0 +1,450 @@
+package propretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type fuzzConfig struct {
+	method      string
+	target      string
+	contentType string
+	timeout     time.Duration
+}
+
+// FuzzOpts is the alias for the options of the fuzz targets.
+type FuzzOpts func(c *fuzzConfig)
+
+// WithFuzzRequest is a fuzz option to set the method and the target of the
+// fuzzed requests. The default is POST "/".
+func WithFuzzRequest(method, target string) FuzzOpts {
+	return func(c *fuzzConfig) {
+		c.method = method
+		c.target = target
+	}
+}
+
+// WithFuzzContentType is a fuzz option to set the Content-Type header of the
+// fuzzed requests, which is also the format of the generated seeds: JSON by
+// default, XML if the content type contains "xml".
+func WithFuzzContentType(contentType string) FuzzOpts {
+	return func(c *fuzzConfig) {
+		c.contentType = contentType
+	}
+}
+
+// WithFuzzTimeout is a fuzz option to set the time after which a decoder is
+// reported as hanging. The default is 1 second.
+func WithFuzzTimeout(timeout time.Duration) FuzzOpts {
+	return func(c *fuzzConfig) {
+		c.timeout = timeout
+	}
+}
+
+func newFuzzConfig(opts []FuzzOpts) *fuzzConfig {
+	config := &fuzzConfig{
+		method:      http.MethodPost,
+		target:      "/",
+		contentType: "application/json",
+		timeout:     time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return config
+}
+
+// FuzzRequestDecoder turns a request decoder into a native fuzz target, whose
+// fuzzed argument is the request body. The seed corpus is generated from the
+// shape of the Payload type read by the decoder, see [PayloadSeeds]:
+//
+//	func FuzzCreateTodoDecoder(f *testing.F) {
+//		propretest.FuzzRequestDecoder[CreateTodoPayload](f, &CreateTodoRequestDecoder{}, nil)
+//	}
+//
+// The panics and the hangs of the decoder are reported, as well as the errors
+// returned by the optional invariant, called with each decoded input.
+func FuzzRequestDecoder[Payload, Input any](
+	f *testing.F,
+	decoder propre.RequestDecoder[Input],
+	invariant func(req *http.Request, input Input) error,
+	opts ...FuzzOpts,
+) {
+	config := newFuzzConfig(opts)
+	for _, seed := range PayloadSeeds[Payload](config.contentType) {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		input, ok := runFuzzed(t, config, body, decoder.Decode)
+		if !ok || invariant == nil {
+			return
+		}
+
+		if err := invariant(config.newRequest(body), input); err != nil {
+			t.Fatalf("propretest: invariant violated for the body %q: %s", body, err)
+		}
+	})
+}
+
+// FuzzPayloadExtractor turns a payload extractor into a native fuzz target, see
+// [FuzzRequestDecoder]. Besides the panics and the hangs, it reports:
+//   - the bodies extracted differently by two calls of the extractor,
+//   - the payloads extracted without error which are rejected by their Validate
+//     method or, once encoded in JSON, by the schema of the extractor,
+//   - the payloads extracted without error which, once encoded again in the
+//     format of the content type, are rejected or extracted as a different payload.
+//
+// The encoding omits the empty fields with the omitempty option, so the missing
+// required properties of the encoded payloads are not reported, they are checked
+// in the body by the extractor, and the nil and the empty slices and maps are equal.
+func FuzzPayloadExtractor[Payload propre.Validatable](
+	f *testing.F,
+	extractor *propre.RequestPayloadExtractor[Payload],
+	opts ...FuzzOpts,
+) {
+	type extraction struct {
+		payload Payload
+		err     error
+	}
+
+	extract := func(req *http.Request) extraction {
+		payload, err := extractor.Extract(req)
+		return extraction{payload: payload, err: err}
+	}
+
+	same := func(a, b extraction) bool {
+		if (a.err == nil) != (b.err == nil) || (a.err != nil && a.err.Error() != b.err.Error()) {
+			return false
+		}
+
+		return reflect.DeepEqual(a.payload, b.payload)
+	}
+
+	config := newFuzzConfig(opts)
+	encode := json.Marshal
+	if strings.Contains(config.contentType, "xml") {
+		encode = xml.Marshal
+	}
+
+	invariant := func(req *http.Request, result extraction) error {
+		if again := extract(req); !same(result, again) {
+			return fmt.Errorf("the extraction is not deterministic, got %+v (%v) then %+v (%v)",
+				result.payload, result.err, again.payload, again.err)
+		}
+
+		if result.err != nil {
+			return nil
+		}
+
+		if err := result.payload.Validate(); err != nil {
+			return fmt.Errorf("the extracted payload %+v is not valid: %w", result.payload, err)
+		}
+
+		if schema := extractor.JSONSchema(); schema != nil {
+			if err := schema.ValidateValue(result.payload); err != nil && !onlyMissingRequired(err) {
+				return fmt.Errorf("the extracted payload %+v does not match the schema: %w", result.payload, err)
+			}
+		}
+
+		encoded, err := encode(result.payload)
+		if err != nil {
+			return fmt.Errorf("the payload %+v cannot be encoded again: %w", result.payload, err)
+		}
+
+		decoded := extract(config.newRequest(encoded))
+		if decoded.err != nil {
+			if onlyMissingRequired(decoded.err) {
+				return nil
+			}
+
+			return fmt.Errorf("the payload %+v encoded again as %q is rejected: %w", result.payload, encoded, decoded.err)
+		}
+
+		if !equalPayloads(reflect.ValueOf(result.payload), reflect.ValueOf(decoded.payload)) {
+			return fmt.Errorf("the payload %+v encoded again as %q is extracted as %+v", result.payload, encoded, decoded.payload)
+		}
+
+		return nil
+	}
+
+	FuzzRequestDecoder[Payload](f, decoderFunc[extraction](extract), invariant, opts...)
+}
+
+// onlyMissingRequired tells if the error is a schema validation error reporting
+// missing required properties only, like the empty fields omitted by the encoding.
+func onlyMissingRequired(err error) bool {
+	var validationErr *propre.SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	for _, violation := range validationErr.Violations {
+		if !strings.HasPrefix(violation.Message, "missing required property") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalPayloads is reflect.DeepEqual except that the nil and the empty slices
+// and maps are equal, as the encoding does not tell them apart with omitempty.
+func equalPayloads(a, b reflect.Value) bool {
+	if a.IsValid() != b.IsValid() || a.IsValid() && a.Type() != b.Type() {
+		return false
+	}
+
+	if !a.IsValid() {
+		return true
+	}
+
+	switch a.Kind() {
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		for i := range a.Len() {
+			if !equalPayloads(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Map:
+		if a.Len() != b.Len() {
+			return false
+		}
+
+		for _, key := range a.MapKeys() {
+			if !b.MapIndex(key).IsValid() || !equalPayloads(a.MapIndex(key), b.MapIndex(key)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+
+		return equalPayloads(a.Elem(), b.Elem())
+	case reflect.Struct:
+		for i := range a.NumField() {
+			if !equalPayloads(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return a.IsNil() && b.IsNil()
+	}
+
+	return a.Equal(b)
+}
+
+type decoderFunc[Input any] func(req *http.Request) Input
+
+func (f decoderFunc[Input]) Decode(req *http.Request) Input {
+	return f(req)
+}
+
+func (c *fuzzConfig) newRequest(body []byte) *http.Request {
+	req := httptest.NewRequest(c.method, c.target, bytes.NewReader(body))
+	req.Header.Set("Content-Type", c.contentType)
+
+	return req
+}
+
+type fuzzResult[Input any] struct {
+	input Input
+	panic any
+	stack []byte
+}
+
+// runFuzzed decodes the body in a goroutine to report the panics and the hangs.
+func runFuzzed[Input any](t *testing.T, config *fuzzConfig, body []byte, decode func(*http.Request) Input) (Input, bool) {
+	t.Helper()
+
+	done := make(chan fuzzResult[Input], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fuzzResult[Input]{panic: r, stack: debug.Stack()}
+			}
+		}()
+
+		done <- fuzzResult[Input]{input: decode(config.newRequest(body))}
+	}()
+
+	timer := time.NewTimer(config.timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		if result.panic != nil {
+			t.Fatalf("propretest: the decoder panicked for the body %q: %v\n%s", body, result.panic, result.stack)
+			return result.input, false
+		}
+
+		return result.input, true
+	case <-timer.C:
+		t.Fatalf("propretest: the decoder did not return within %s for the body %q", config.timeout, body)
+		var zero Input
+		return zero, false
+	}
+}
+
+// PayloadSeeds generates structurally plausible request bodies from the shape of
+// the Payload type, in JSON or in XML depending on the content type: a complete
+// payload, the empty payload, the payload without each of its fields, with a
+// field set to unexpected values, truncated, and a few malformed documents.
+// They can seed the corpus of any fuzz target.
+func PayloadSeeds[Payload any](contentType string) [][]byte {
+	t := reflect.TypeOf((*Payload)(nil)).Elem()
+	sample := samplePayload(t, 0)
+
+	if strings.Contains(contentType, "xml") {
+		return xmlSeeds(sample)
+	}
+
+	return jsonSeeds(sample)
+}
+
+func jsonSeeds(sample reflect.Value) [][]byte {
+	complete, err := json.Marshal(sample.Interface())
+	if err != nil {
+		complete = []byte("{}")
+	}
+
+	seeds := [][]byte{
+		complete,
+		complete[:len(complete)/2],
+		[]byte("{}"),
+		[]byte("null"),
+		[]byte("[]"),
+		[]byte(""),
+		[]byte(`{"`),
+	}
+
+	var fields map[string]any
+	if json.Unmarshal(complete, &fields) != nil {
+		return seeds
+	}
+
+	unexpected := []any{nil, "", -1, 1e308, strings.Repeat("a", 1024), []any{}, map[string]any{}, "\u0000\ufffd"}
+	for name := range fields {
+		variant := cloneFields(fields)
+		delete(variant, name)
+		seeds = appendJSONSeed(seeds, variant)
+
+		for _, value := range unexpected {
+			variant := cloneFields(fields)
+			variant[name] = value
+			seeds = appendJSONSeed(seeds, variant)
+		}
+	}
+
+	return seeds
+}
+
+func cloneFields(fields map[string]any) map[string]any {
+	clone := make(map[string]any, len(fields))
+	for name, value := range fields {
+		clone[name] = value
+	}
+
+	return clone
+}
+
+func appendJSONSeed(seeds [][]byte, value any) [][]byte {
+	seed, err := json.Marshal(value)
+	if err != nil {
+		return seeds
+	}
+
+	return append(seeds, seed)
+}
+
+func xmlSeeds(sample reflect.Value) [][]byte {
+	complete, err := xml.Marshal(sample.Interface())
+	if err != nil {
+		complete = []byte("<Payload></Payload>")
+	}
+
+	empty, err := xml.Marshal(reflect.Zero(sample.Type()).Interface())
+	if err != nil {
+		empty = []byte("<Payload></Payload>")
+	}
+
+	return [][]byte{
+		complete,
+		complete[:len(complete)/2],
+		empty,
+		[]byte(""),
+		[]byte("<"),
+		[]byte(`<?xml version="1.0"?><!DOCTYPE a [<!ENTITY b "c">]><a>&b;</a>`),
+	}
+}
+
+// samplePayload builds a value of the type with every field set.
+func samplePayload(t reflect.Type, depth int) reflect.Value {
+	value := reflect.New(t).Elem()
+	if depth > 4 {
+		return value
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		value.Set(samplePayload(t.Elem(), depth+1).Addr())
+	case reflect.Struct:
+		switch t {
+		case reflect.TypeOf(time.Time{}):
+			value.Set(reflect.ValueOf(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return value
+		case reflect.TypeOf(xml.Name{}):
+			return value
+		}
+
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() {
+				value.Field(i).Set(samplePayload(t.Field(i).Type, depth+1))
+			}
+		}
+	case reflect.Slice:
+		value.Set(reflect.Append(reflect.MakeSlice(t, 0, 1), samplePayload(t.Elem(), depth+1)))
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			value.Set(reflect.MakeMap(t))
+			value.SetMapIndex(reflect.ValueOf("key").Convert(t.Key()), samplePayload(t.Elem(), depth+1))
+		}
+	case reflect.String:
+		value.SetString("example")
+	case reflect.Bool:
+		value.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		value.SetFloat(1.5)
+	}
+
+	return value
+}
@@ -0,0 +1,122 @@
+package propretest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/propretest"
+)
+
+type commentPayload struct {
+	XMLName xml.Name `json:"-" xml:"Comment"`
+	Author  string   `json:"author" xml:"Author"`
+	Text    string   `json:"text" xml:"Text" jsonschema:"minLength=1"`
+	Rating  int      `json:"rating" xml:"Rating"`
+	Tags    []string `json:"tags,omitempty" xml:"Tags"`
+}
+
+func (p commentPayload) Validate() error {
+	if p.Rating < 0 || p.Rating > 5 {
+		return errors.New("the rating must be between 0 and 5")
+	}
+
+	return nil
+}
+
+type commentInput struct {
+	Text  string
+	Error error
+}
+
+type commentDecoder struct{}
+
+func (d commentDecoder) Decode(req *http.Request) commentInput {
+	var payload commentPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		return commentInput{Error: err}
+	}
+
+	return commentInput{Text: strings.TrimSpace(payload.Text)}
+}
+
+func FuzzPayloadExtractorJSON(f *testing.F) {
+	extractor := propre.NewRequestPayloadExtractor(propre.JSONDecoder, propre.WithReflectedJSONSchema[commentPayload]())
+	propretest.FuzzPayloadExtractor(f, extractor)
+}
+
+// The tags are required by the schema but omitted when empty by the encoding.
+const commentSchema = `{
+	"type": "object",
+	"required": ["author", "text", "rating", "tags"],
+	"properties": {
+		"text": {"type": "string", "minLength": 1},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func FuzzPayloadExtractorExternalSchema(f *testing.F) {
+	schema, err := propre.ParseJSONSchema([]byte(commentSchema))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	extractor := propre.NewRequestPayloadExtractor(propre.JSONDecoder, propre.WithJSONSchema[commentPayload](schema))
+	f.Add([]byte(`{"author":"bob","text":"hi","rating":1,"tags":[]}`))
+	propretest.FuzzPayloadExtractor(f, extractor)
+}
+
+func FuzzPayloadExtractorXML(f *testing.F) {
+	extractor := propre.NewRequestPayloadExtractor[commentPayload](propre.XMLDecoder)
+	propretest.FuzzPayloadExtractor(f, extractor, propretest.WithFuzzContentType("application/xml"))
+}
+
+func FuzzRequestDecoder(f *testing.F) {
+	propretest.FuzzRequestDecoder[commentPayload](f, commentDecoder{}, func(req *http.Request, input commentInput) error {
+		if input.Error == nil && input.Text != strings.TrimSpace(input.Text) {
+			return fmt.Errorf("the text %q is not trimmed", input.Text)
+		}
+
+		return nil
+	}, propretest.WithFuzzRequest(http.MethodPut, "/comments/1"))
+}
+
+func TestPayloadSeeds(t *testing.T) {
+	seeds := propretest.PayloadSeeds[commentPayload]("application/json")
+
+	var complete map[string]any
+	if err := json.Unmarshal(seeds[0], &complete); err != nil {
+		t.Fatalf("the first seed should be a complete payload: %s", err)
+	}
+
+	for _, field := range []string{"author", "text", "rating", "tags"} {
+		if _, ok := complete[field]; !ok {
+			t.Fatalf("the complete payload should have the field %q: %s", field, seeds[0])
+		}
+	}
+
+	withoutText := 0
+	for _, seed := range seeds {
+		var payload map[string]any
+		if json.Unmarshal(seed, &payload) == nil && payload != nil {
+			if _, ok := payload["text"]; !ok {
+				withoutText++
+			}
+		}
+	}
+
+	if withoutText == 0 {
+		t.Fatal("a seed should miss the text field")
+	}
+
+	xmlSeeds := propretest.PayloadSeeds[commentPayload]("application/xml")
+	if !bytes.HasPrefix(xmlSeeds[0], []byte("<Comment><Author>example</Author>")) {
+		t.Fatalf("unexpected XML seed %s", xmlSeeds[0])
+	}
+}
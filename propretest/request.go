@@ -0,0 +1,296 @@
+package propretest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+// Request builds an in-process request sent to an [http.Handler].
+// The errors are reported to the test, which fails.
+type Request struct {
+	t       testing.TB
+	handler http.Handler
+	method  string
+	target  string
+	header  http.Header
+	body    []byte
+	ctx     context.Context
+}
+
+// NewRequest builds a GET request to "/" sent to the given handler.
+func NewRequest(t testing.TB, handler http.Handler) *Request {
+	return &Request{
+		t:       t,
+		handler: handler,
+		method:  http.MethodGet,
+		target:  "/",
+		header:  http.Header{},
+		ctx:     context.Background(),
+	}
+}
+
+// Method sets the method and the target of the request, like "/todos?page=2".
+func (r *Request) Method(method, target string) *Request {
+	r.method = method
+	r.target = target
+
+	return r
+}
+
+// Get sets a GET request to the target.
+func (r *Request) Get(target string) *Request {
+	return r.Method(http.MethodGet, target)
+}
+
+// Post sets a POST request to the target.
+func (r *Request) Post(target string) *Request {
+	return r.Method(http.MethodPost, target)
+}
+
+// Put sets a PUT request to the target.
+func (r *Request) Put(target string) *Request {
+	return r.Method(http.MethodPut, target)
+}
+
+// Patch sets a PATCH request to the target.
+func (r *Request) Patch(target string) *Request {
+	return r.Method(http.MethodPatch, target)
+}
+
+// Delete sets a DELETE request to the target.
+func (r *Request) Delete(target string) *Request {
+	return r.Method(http.MethodDelete, target)
+}
+
+// WithHeader sets a header of the request.
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithBody sets the body of the request.
+func (r *Request) WithBody(body string) *Request {
+	r.body = []byte(body)
+	return r
+}
+
+// WithJSONBody sets the body of the request to the JSON encoding of the value,
+// and its Content-Type header to "application/json".
+func (r *Request) WithJSONBody(value any) *Request {
+	r.t.Helper()
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		r.t.Fatalf("propretest: cannot encode the JSON body: %s", err)
+	}
+
+	r.body = body
+	return r.WithHeader("Content-Type", "application/json")
+}
+
+// WithContext sets the context of the request.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// WithPrincipal stores the principal in the context of the request, as done by
+// [propre.AuthenticationHandler].
+func (r *Request) WithPrincipal(principal propre.Principal) *Request {
+	r.ctx = propre.ContextWithPrincipal(r.ctx, principal)
+	return r
+}
+
+// Send sends the request to the handler and returns the response.
+func (r *Request) Send() *Response {
+	req := httptest.NewRequest(r.method, r.target, bytes.NewReader(r.body)).WithContext(r.ctx)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+
+	rw := httptest.NewRecorder()
+	r.handler.ServeHTTP(rw, req)
+
+	return &Response{t: r.t, recorder: rw}
+}
+
+// Response is the response of a [Request], with fluent assertions. A failed
+// assertion marks the test as failed and continues.
+type Response struct {
+	t        testing.TB
+	recorder *httptest.ResponseRecorder
+	json     any
+	decoded  bool
+}
+
+// Recorder returns the recorder of the response.
+func (r *Response) Recorder() *httptest.ResponseRecorder {
+	return r.recorder
+}
+
+// StatusCode returns the status code of the response.
+func (r *Response) StatusCode() int {
+	return r.recorder.Code
+}
+
+// Body returns the body of the response.
+func (r *Response) Body() string {
+	return r.recorder.Body.String()
+}
+
+// DecodeJSON decodes the JSON body of the response in the value.
+func (r *Response) DecodeJSON(value any) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.recorder.Body.Bytes(), value); err != nil {
+		r.t.Errorf("propretest: cannot decode the JSON body: %s\nbody: %s", err, r.Body())
+	}
+
+	return r
+}
+
+// AssertStatus checks the status code of the response.
+func (r *Response) AssertStatus(expected int) *Response {
+	r.t.Helper()
+
+	if r.recorder.Code != expected {
+		r.t.Errorf("propretest: expected the status code %d, got %d\nbody: %s", expected, r.recorder.Code, r.Body())
+	}
+
+	return r
+}
+
+// AssertHeader checks the value of a header of the response.
+func (r *Response) AssertHeader(key, expected string) *Response {
+	r.t.Helper()
+
+	if got := r.recorder.Header().Get(key); got != expected {
+		r.t.Errorf("propretest: expected the header %s to be %q, got %q", key, expected, got)
+	}
+
+	return r
+}
+
+// AssertBody checks the body of the response.
+func (r *Response) AssertBody(expected string) *Response {
+	r.t.Helper()
+
+	if r.Body() != expected {
+		r.t.Errorf("propretest: expected the body %q, got %q", expected, r.Body())
+	}
+
+	return r
+}
+
+// AssertBodyContains checks that the body of the response contains a substring.
+func (r *Response) AssertBodyContains(expected string) *Response {
+	r.t.Helper()
+
+	if !strings.Contains(r.Body(), expected) {
+		r.t.Errorf("propretest: expected the body to contain %q, got %q", expected, r.Body())
+	}
+
+	return r
+}
+
+// AssertJSONPath checks the value at a path of the JSON body. The path is a
+// dot-separated list of keys and array indexes, optionally starting with "$",
+// like "$.data.items[0].title" or "data.items.0.title". The expected value is
+// compared to the body value once encoded in JSON, so 42 matches the JSON number 42.
+func (r *Response) AssertJSONPath(path string, expected any) *Response {
+	r.t.Helper()
+
+	got, ok := r.lookupJSONPath(path)
+	if !ok {
+		return r
+	}
+
+	normalized, err := normalizeJSON(expected)
+	if err != nil {
+		r.t.Errorf("propretest: cannot encode the expected value of %s: %s", path, err)
+		return r
+	}
+
+	if !reflect.DeepEqual(got, normalized) {
+		r.t.Errorf("propretest: expected %s to be %v, got %v", path, normalized, got)
+	}
+
+	return r
+}
+
+// AssertJSONPathExists checks that a path of the JSON body exists, see [Response.AssertJSONPath].
+func (r *Response) AssertJSONPathExists(path string) *Response {
+	r.t.Helper()
+
+	r.lookupJSONPath(path)
+	return r
+}
+
+func (r *Response) lookupJSONPath(path string) (any, bool) {
+	r.t.Helper()
+
+	if !r.decoded {
+		r.decoded = true
+		if err := json.Unmarshal(r.recorder.Body.Bytes(), &r.json); err != nil {
+			r.t.Errorf("propretest: the body is not valid JSON: %s\nbody: %s", err, r.Body())
+			return nil, false
+		}
+	}
+
+	value, err := lookupJSONPath(r.json, path)
+	if err != nil {
+		r.t.Errorf("propretest: %s\nbody: %s", err, r.Body())
+		return nil, false
+	}
+
+	return value, true
+}
+
+func lookupJSONPath(value any, path string) (any, error) {
+	current := ""
+	for _, segment := range jsonPathSegments(path) {
+		current += "." + segment
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[segment]
+			if !ok {
+				return nil, fmt.Errorf("no value at $%s", current)
+			}
+
+			value = child
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("no value at $%s", current)
+			}
+
+			value = node[i]
+		default:
+			return nil, fmt.Errorf("no value at $%s", current)
+		}
+	}
+
+	return value, nil
+}
+
+func normalizeJSON(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+
+	return normalized, err
+}
@@ -0,0 +1,98 @@
+package propretest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/propretest"
+)
+
+// recordingT records the failures instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func todoRouter() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /todos", func(rw http.ResponseWriter, req *http.Request) {
+		var body map[string]any
+		json.NewDecoder(req.Body).Decode(&body)
+
+		principal, _ := propre.PrincipalFromContext(req.Context())
+
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusCreated)
+		json.NewEncoder(rw).Encode(map[string]any{
+			"data": map[string]any{
+				"id":    42,
+				"title": body["title"],
+				"tags":  []string{"home", req.Header.Get("X-Tag")},
+				"owner": principal.ID,
+			},
+		})
+	})
+
+	return mux
+}
+
+func TestRequestAssertions(t *testing.T) {
+	propretest.NewRequest(t, todoRouter()).
+		Post("/todos").
+		WithJSONBody(map[string]any{"title": "title"}).
+		WithHeader("X-Tag", "work").
+		WithPrincipal(propre.Principal{ID: "user-1"}).
+		Send().
+		AssertStatus(http.StatusCreated).
+		AssertHeader("Content-Type", "application/json").
+		AssertJSONPath("$.data.id", 42).
+		AssertJSONPath("$.data.title", "title").
+		AssertJSONPath("data.tags", []string{"home", "work"}).
+		AssertJSONPath("$.data.tags[1]", "work").
+		AssertJSONPath("$.data.owner", "user-1").
+		AssertJSONPathExists("$.data").
+		AssertBodyContains(`"title":"title"`)
+}
+
+func TestRequestAssertionsReportTheFailures(t *testing.T) {
+	recorder := &recordingT{TB: t}
+
+	propretest.NewRequest(recorder, todoRouter()).
+		Post("/todos").
+		WithBody(`{"title":"title"}`).
+		Send().
+		AssertStatus(http.StatusOK).
+		AssertHeader("Content-Type", "text/plain").
+		AssertJSONPath("$.data.title", "other").
+		AssertJSONPath("$.data.tags[5]", "work").
+		AssertJSONPathExists("$.missing").
+		AssertBody("")
+
+	expected := []string{
+		"propretest: expected the status code 200, got 201",
+		`propretest: expected the header Content-Type to be "text/plain", got "application/json"`,
+		"propretest: expected $.data.title to be other, got title",
+		"propretest: no value at $.data.tags.5",
+		"propretest: no value at $.missing",
+		"propretest: expected the body",
+	}
+
+	if len(recorder.errors) != len(expected) {
+		t.Fatalf("unexpected failures %q", recorder.errors)
+	}
+
+	for i, prefix := range expected {
+		if len(recorder.errors[i]) < len(prefix) || recorder.errors[i][:len(prefix)] != prefix {
+			t.Fatalf("unexpected failure %d, expected the prefix %q, got %q", i, prefix, recorder.errors[i])
+		}
+	}
+}
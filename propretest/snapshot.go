@@ -0,0 +1,243 @@
+package propretest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+// Masked replaces the masked values in the snapshots.
+const Masked = "<masked>"
+
+var updateSnapshots = flag.Bool("propretest.update", false, "rewrite the golden files of the snapshots")
+
+type snapshotConfig struct {
+	ignoredHeaders map[string]bool
+	maskedHeaders  map[string]bool
+	maskedPaths    []string
+	maskedPatterns []*regexp.Regexp
+}
+
+// SnapshotOpts is the alias for the options of the snapshot assertions.
+type SnapshotOpts func(c *snapshotConfig)
+
+// WithIgnoredHeaders is a snapshot option to leave headers out of the snapshot.
+// The Date and Content-Length headers are always ignored, the latter changing
+// with the masked values.
+func WithIgnoredHeaders(headers ...string) SnapshotOpts {
+	return func(c *snapshotConfig) {
+		for _, header := range headers {
+			c.ignoredHeaders[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
+// WithMaskedHeaders is a snapshot option to mask the values of volatile headers,
+// like a request ID. The header must be present but its value is not compared.
+func WithMaskedHeaders(headers ...string) SnapshotOpts {
+	return func(c *snapshotConfig) {
+		for _, header := range headers {
+			c.maskedHeaders[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+}
+
+// WithMaskedJSONPaths is a snapshot option to mask volatile values of a JSON body,
+// like timestamps and IDs. The paths use the syntax of [Response.AssertJSONPath],
+// and "*" matches every element of an array or an object, like "$.items[*].id".
+func WithMaskedJSONPaths(paths ...string) SnapshotOpts {
+	return func(c *snapshotConfig) {
+		c.maskedPaths = append(c.maskedPaths, paths...)
+	}
+}
+
+// WithMaskedPatterns is a snapshot option to mask the parts of the canonical body
+// matching the patterns, typically for the bodies which are not JSON.
+func WithMaskedPatterns(patterns ...*regexp.Regexp) SnapshotOpts {
+	return func(c *snapshotConfig) {
+		c.maskedPatterns = append(c.maskedPatterns, patterns...)
+	}
+}
+
+// AssertSnapshot compares a recorded response to the golden file
+// testdata/<name>.golden. The snapshot holds the status code, the sorted headers
+// and the body, indented with sorted keys if it is JSON.
+//
+// Run the tests with the -propretest.update flag to write the golden files.
+func AssertSnapshot(t testing.TB, rw *httptest.ResponseRecorder, name string, opts ...SnapshotOpts) {
+	t.Helper()
+
+	config := &snapshotConfig{
+		ignoredHeaders: map[string]bool{"Date": true, "Content-Length": true},
+		maskedHeaders:  make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	snapshot := config.snapshot(rw)
+	golden := filepath.Join("testdata", name+".golden")
+
+	if *updateSnapshots {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("propretest: cannot create the golden file directory: %s", err)
+		}
+
+		if err := os.WriteFile(golden, snapshot, 0o644); err != nil {
+			t.Fatalf("propretest: cannot write the golden file: %s", err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Errorf("propretest: cannot read the golden file, run the tests with -propretest.update to create it: %s", err)
+		return
+	}
+
+	if !bytes.Equal(expected, snapshot) {
+		t.Errorf("propretest: the response does not match %s\n--- expected\n%s\n--- got\n%s", golden, expected, snapshot)
+	}
+}
+
+// AssertPresenterSnapshot presents the output with an HTTP presenter and compares
+// the response to a golden file, see [AssertSnapshot].
+func AssertPresenterSnapshot[Output any](
+	t testing.TB,
+	presenter propre.Presenter[Output, http.ResponseWriter],
+	output Output,
+	name string,
+	opts ...SnapshotOpts,
+) {
+	t.Helper()
+
+	rw := httptest.NewRecorder()
+	presenter.Present(context.Background(), rw, output)
+	AssertSnapshot(t, rw, name, opts...)
+}
+
+// AssertViewSnapshot sends the view model with the [propre.HTTPResponse] and compares
+// the response to a golden file, see [AssertSnapshot].
+func AssertViewSnapshot[View propre.HTTPSendable](
+	t testing.TB,
+	response *propre.HTTPResponse[View],
+	view View,
+	name string,
+	opts ...SnapshotOpts,
+) {
+	t.Helper()
+
+	rw := httptest.NewRecorder()
+	response.Send(context.Background(), rw, view)
+	AssertSnapshot(t, rw, name, opts...)
+}
+
+// AssertSnapshot compares the response to a golden file, see [AssertSnapshot].
+func (r *Response) AssertSnapshot(name string, opts ...SnapshotOpts) *Response {
+	r.t.Helper()
+
+	AssertSnapshot(r.t, r.recorder, name, opts...)
+	return r
+}
+
+func (c *snapshotConfig) snapshot(rw *httptest.ResponseRecorder) []byte {
+	var snapshot bytes.Buffer
+	fmt.Fprintf(&snapshot, "HTTP %d\n", rw.Code)
+
+	header := rw.Result().Header
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		if !c.ignoredHeaders[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if c.maskedHeaders[key] {
+				value = Masked
+			}
+
+			fmt.Fprintf(&snapshot, "%s: %s\n", key, value)
+		}
+	}
+
+	snapshot.WriteString("\n")
+	snapshot.Write(c.canonicalBody(rw.Body.Bytes()))
+
+	return snapshot.Bytes()
+}
+
+func (c *snapshotConfig) canonicalBody(body []byte) []byte {
+	var value any
+	if len(body) > 0 && json.Unmarshal(body, &value) == nil {
+		for _, path := range c.maskedPaths {
+			value = maskJSONPath(value, jsonPathSegments(path))
+		}
+
+		var indented bytes.Buffer
+		encoder := json.NewEncoder(&indented)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if encoder.Encode(value) == nil {
+			body = indented.Bytes()
+		}
+	}
+
+	for _, pattern := range c.maskedPatterns {
+		body = pattern.ReplaceAll(body, []byte(Masked))
+	}
+
+	return body
+}
+
+func jsonPathSegments(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	if path == "" {
+		return nil
+	}
+
+	return strings.Split(path, ".")
+}
+
+func maskJSONPath(value any, segments []string) any {
+	if len(segments) == 0 {
+		return Masked
+	}
+
+	segment, remaining := segments[0], segments[1:]
+	switch node := value.(type) {
+	case map[string]any:
+		for key, child := range node {
+			if segment == "*" || segment == key {
+				node[key] = maskJSONPath(child, remaining)
+			}
+		}
+	case []any:
+		for i, child := range node {
+			if segment == "*" || segment == strconv.Itoa(i) {
+				node[i] = maskJSONPath(child, remaining)
+			}
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,93 @@
+package propretest_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/propretest"
+)
+
+type todoView struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	Tags      []string  `json:"tags"`
+}
+
+func (v todoView) ContentType(context.Context) string {
+	return "application/json"
+}
+
+func (v todoView) Encode(context.Context) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (v todoView) StatusCode(context.Context) int {
+	return http.StatusCreated
+}
+
+type todoListPresenter struct{}
+
+func (p todoListPresenter) Present(ctx context.Context, rw http.ResponseWriter, output []todoView) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("X-Request-Id", time.Now().String())
+	json.NewEncoder(rw).Encode(map[string]any{"items": output, "count": len(output)})
+}
+
+func TestAssertViewSnapshot(t *testing.T) {
+	response := propre.NewHTTPResponse[todoView](
+		propre.WithHTTPResponseHeaders[todoView](http.Header{"Cache-Control": []string{"no-store"}}),
+	)
+
+	view := todoView{ID: "a1b2", Title: "New todo", CreatedAt: time.Now(), Tags: []string{"home"}}
+	propretest.AssertViewSnapshot(t, response, view, "todo_view",
+		propretest.WithMaskedJSONPaths("$.id", "$.created_at"),
+	)
+}
+
+func TestAssertPresenterSnapshot(t *testing.T) {
+	output := []todoView{
+		{ID: "1", Title: "first", CreatedAt: time.Now()},
+		{ID: "2", Title: "second", CreatedAt: time.Now(), Tags: []string{"work"}},
+	}
+
+	propretest.AssertPresenterSnapshot[[]todoView](t, todoListPresenter{}, output, "todo_list",
+		propretest.WithMaskedHeaders("X-Request-Id"),
+		propretest.WithMaskedJSONPaths("$.items[*].created_at"),
+	)
+}
+
+func TestResponseAssertSnapshot(t *testing.T) {
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain")
+		rw.Write([]byte("generated at " + time.Now().Format(time.RFC3339) + "\n"))
+	})
+
+	propretest.NewRequest(t, handler).
+		Send().
+		AssertStatus(http.StatusOK).
+		AssertSnapshot("plain_text",
+			propretest.WithIgnoredHeaders("Content-Type"),
+			propretest.WithMaskedPatterns(regexp.MustCompile(`\d{4}-\d{2}-\d{2}T[^\s]+`)),
+		)
+}
+
+func TestAssertSnapshotReportsTheMismatches(t *testing.T) {
+	if flag.Lookup("propretest.update").Value.String() == "true" {
+		t.Skip("the golden files are being updated")
+	}
+
+	recorder := &recordingT{TB: t}
+	response := propre.NewHTTPResponse[todoView]()
+	propretest.AssertViewSnapshot(recorder, response, todoView{ID: "other"}, "todo_view")
+
+	if len(recorder.errors) != 1 {
+		t.Fatalf("the mismatch should be reported, got %q", recorder.errors)
+	}
+}
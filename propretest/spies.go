@@ -0,0 +1,177 @@
+package propretest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+// RequestDecoderSpy is a [propre.RequestDecoder] recording the decoded requests
+// and the inputs it returned. It is safe for concurrent use.
+type RequestDecoderSpy[Input any] struct {
+	mu       sync.Mutex
+	decode   func(req *http.Request) Input
+	requests []*http.Request
+	inputs   []Input
+}
+
+// NewRequestDecoderFake builds a [RequestDecoderSpy] returning the given input
+// for every request.
+func NewRequestDecoderFake[Input any](input Input) *RequestDecoderSpy[Input] {
+	return &RequestDecoderSpy[Input]{
+		decode: func(*http.Request) Input {
+			return input
+		},
+	}
+}
+
+// NewRequestDecoderSpy builds a [RequestDecoderSpy] delegating to the given decoder.
+func NewRequestDecoderSpy[Input any](decoder propre.RequestDecoder[Input]) *RequestDecoderSpy[Input] {
+	return &RequestDecoderSpy[Input]{decode: decoder.Decode}
+}
+
+// Decode implements [propre.RequestDecoder].
+func (s *RequestDecoderSpy[Input]) Decode(req *http.Request) Input {
+	input := s.decode(req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	s.inputs = append(s.inputs, input)
+
+	return input
+}
+
+// Calls returns the number of decoded requests.
+func (s *RequestDecoderSpy[Input]) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.requests)
+}
+
+// Requests returns the decoded requests in the order of the calls.
+func (s *RequestDecoderSpy[Input]) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*http.Request(nil), s.requests...)
+}
+
+// Inputs returns the inputs returned by the decoder in the order of the calls.
+func (s *RequestDecoderSpy[Input]) Inputs() []Input {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Input(nil), s.inputs...)
+}
+
+// UseCaseHandlerSpy is a [propre.UseCaseHandler] recording the inputs it received
+// and the outputs it returned. It is safe for concurrent use.
+type UseCaseHandlerSpy[Input, Output any] struct {
+	mu      sync.Mutex
+	handle  func(ctx context.Context, input Input) Output
+	inputs  []Input
+	outputs []Output
+}
+
+// NewUseCaseHandlerFake builds a [UseCaseHandlerSpy] returning the given output
+// for every input.
+func NewUseCaseHandlerFake[Input, Output any](output Output) *UseCaseHandlerSpy[Input, Output] {
+	return &UseCaseHandlerSpy[Input, Output]{
+		handle: func(context.Context, Input) Output {
+			return output
+		},
+	}
+}
+
+// NewUseCaseHandlerSpy builds a [UseCaseHandlerSpy] delegating to the given use case handler.
+func NewUseCaseHandlerSpy[Input, Output any](
+	useCaseHandler propre.UseCaseHandler[Input, Output],
+) *UseCaseHandlerSpy[Input, Output] {
+	return &UseCaseHandlerSpy[Input, Output]{handle: useCaseHandler.Handle}
+}
+
+// Handle implements [propre.UseCaseHandler].
+func (s *UseCaseHandlerSpy[Input, Output]) Handle(ctx context.Context, input Input) Output {
+	output := s.handle(ctx, input)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inputs = append(s.inputs, input)
+	s.outputs = append(s.outputs, output)
+
+	return output
+}
+
+// Calls returns the number of handled inputs.
+func (s *UseCaseHandlerSpy[Input, Output]) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.inputs)
+}
+
+// Inputs returns the handled inputs in the order of the calls.
+func (s *UseCaseHandlerSpy[Input, Output]) Inputs() []Input {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Input(nil), s.inputs...)
+}
+
+// Outputs returns the outputs returned by the use case handler in the order of the calls.
+func (s *UseCaseHandlerSpy[Input, Output]) Outputs() []Output {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Output(nil), s.outputs...)
+}
+
+// PresenterSpy is a [propre.Presenter] recording the presented outputs.
+// It is safe for concurrent use.
+type PresenterSpy[Output any, Writer io.Writer] struct {
+	mu      sync.Mutex
+	present func(ctx context.Context, w Writer, output Output)
+	outputs []Output
+}
+
+// NewPresenterFake builds a [PresenterSpy] which writes nothing.
+func NewPresenterFake[Output any, Writer io.Writer]() *PresenterSpy[Output, Writer] {
+	return &PresenterSpy[Output, Writer]{
+		present: func(context.Context, Writer, Output) {},
+	}
+}
+
+// NewPresenterSpy builds a [PresenterSpy] delegating to the given presenter.
+func NewPresenterSpy[Output any, Writer io.Writer](presenter propre.Presenter[Output, Writer]) *PresenterSpy[Output, Writer] {
+	return &PresenterSpy[Output, Writer]{present: presenter.Present}
+}
+
+// Present implements [propre.Presenter].
+func (s *PresenterSpy[Output, Writer]) Present(ctx context.Context, w Writer, output Output) {
+	s.mu.Lock()
+	s.outputs = append(s.outputs, output)
+	s.mu.Unlock()
+
+	s.present(ctx, w, output)
+}
+
+// Calls returns the number of presented outputs.
+func (s *PresenterSpy[Output, Writer]) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.outputs)
+}
+
+// Outputs returns the presented outputs in the order of the calls.
+func (s *PresenterSpy[Output, Writer]) Outputs() []Output {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Output(nil), s.outputs...)
+}
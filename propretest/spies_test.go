@@ -0,0 +1,69 @@
+package propretest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+	"github.com/cyb3rd4d/propre/propretest"
+)
+
+type upperCaseUseCase struct{}
+
+func (u upperCaseUseCase) Handle(ctx context.Context, input string) string {
+	return strings.ToUpper(input)
+}
+
+type textPresenter struct{}
+
+func (p textPresenter) Present(ctx context.Context, rw http.ResponseWriter, output string) {
+	rw.Write([]byte(output))
+}
+
+func TestSpiesRecordTheCallsOfAnHTTPHandler(t *testing.T) {
+	decoder := propretest.NewRequestDecoderFake("some input")
+	useCase := propretest.NewUseCaseHandlerSpy[string, string](upperCaseUseCase{})
+	presenter := propretest.NewPresenterSpy[string, http.ResponseWriter](textPresenter{})
+
+	handler := propre.NewHTTPHandler(decoder, useCase, presenter)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	if decoder.Calls() != 1 || decoder.Requests()[0] != req || decoder.Inputs()[0] != "some input" {
+		t.Fatalf("unexpected decoder calls %v", decoder.Inputs())
+	}
+
+	if useCase.Calls() != 1 || useCase.Inputs()[0] != "some input" || useCase.Outputs()[0] != "SOME INPUT" {
+		t.Fatalf("unexpected use case calls %v %v", useCase.Inputs(), useCase.Outputs())
+	}
+
+	if presenter.Calls() != 1 || presenter.Outputs()[0] != "SOME INPUT" {
+		t.Fatalf("unexpected presenter calls %v", presenter.Outputs())
+	}
+
+	if rw.Body.String() != "SOME INPUT" {
+		t.Fatalf("the spies should delegate to the real implementations, got %q", rw.Body.String())
+	}
+}
+
+func TestFakesReturnCannedValues(t *testing.T) {
+	decoder := propretest.NewRequestDecoderSpy[string](propretest.NewRequestDecoderFake("input"))
+	useCase := propretest.NewUseCaseHandlerFake[string]("output")
+	presenter := propretest.NewPresenterFake[string, http.ResponseWriter]()
+
+	handler := propre.NewHTTPHandler(decoder, useCase, presenter)
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if useCase.Inputs()[0] != "input" || presenter.Outputs()[0] != "output" {
+		t.Fatalf("unexpected calls %v %v", useCase.Inputs(), presenter.Outputs())
+	}
+
+	if rw.Body.Len() != 0 {
+		t.Fatalf("the fake presenter should write nothing, got %q", rw.Body.String())
+	}
+}
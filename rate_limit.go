@@ -0,0 +1,166 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrRateLimitExceeded is presented by [RateLimitHandler] when a request is rejected.
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+	// ErrRateLimitStore wraps the errors of a [RateLimiter] presented by [RateLimitHandler].
+	ErrRateLimitStore = errors.New("rate limit store error")
+)
+
+// RateLimitKeyFunc returns the key a request is limited by, like its IP address
+// or its principal. An empty key means the request is not limited.
+type RateLimitKeyFunc func(req *http.Request) string
+
+// RateLimitByIP limits the requests by the IP address of the client, read from
+// the remote address of the request. Behind a proxy, use [RateLimitByHeader]
+// with a header set by the proxy instead.
+func RateLimitByIP() RateLimitKeyFunc {
+	return func(req *http.Request) string {
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			return req.RemoteAddr
+		}
+
+		return host
+	}
+}
+
+// RateLimitByHeader limits the requests by the value of the given header,
+// like an API key or a tenant header.
+func RateLimitByHeader(header string) RateLimitKeyFunc {
+	return func(req *http.Request) string {
+		return req.Header.Get(header)
+	}
+}
+
+// RateLimitByPrincipal limits the requests by the ID of the principal stored in
+// the context by an [AuthenticationHandler], which must wrap the [RateLimitHandler].
+// The anonymous requests are not limited.
+func RateLimitByPrincipal() RateLimitKeyFunc {
+	return func(req *http.Request) string {
+		principal, _ := PrincipalFromContext(req.Context())
+		return principal.ID
+	}
+}
+
+// RateLimitByTenant limits the requests by the tenant of the principal stored in
+// the context by an [AuthenticationHandler], which must wrap the [RateLimitHandler],
+// so the principals of a tenant share its quota. The requests without tenant are
+// not limited.
+func RateLimitByTenant() RateLimitKeyFunc {
+	return func(req *http.Request) string {
+		principal, _ := PrincipalFromContext(req.Context())
+		return principal.Tenant
+	}
+}
+
+// RateLimitHandler rejects the requests exceeding a rate limit before they reach
+// the wrapped [http.Handler], like an [HTTPHandler]. Wrap each route with its own
+// RateLimitHandler to set per-route limits.
+//
+// Every response has the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers. The rejected requests are presented with [ErrRateLimitExceeded] and the
+// Retry-After header is set.
+type RateLimitHandler struct {
+	next           http.Handler
+	limiter        RateLimiter
+	key            RateLimitKeyFunc
+	prefix         string
+	errorPresenter Presenter[error, http.ResponseWriter]
+}
+
+// RateLimitHandlerOpts is the alias for the [RateLimitHandler] builder options.
+type RateLimitHandlerOpts func(h *RateLimitHandler)
+
+// WithRateLimitPrefix is a [RateLimitHandler] option to prefix the keys, so two
+// routes sharing a store and a key function have their own quotas.
+func WithRateLimitPrefix(prefix string) RateLimitHandlerOpts {
+	return func(h *RateLimitHandler) {
+		h.prefix = prefix
+	}
+}
+
+// WithRateLimitErrorPresenter is a [RateLimitHandler] option to customize the
+// responses to the rejected requests. The default presenter sends a plain text
+// response with the status code 429 for [ErrRateLimitExceeded] and 500 otherwise.
+func WithRateLimitErrorPresenter(presenter Presenter[error, http.ResponseWriter]) RateLimitHandlerOpts {
+	return func(h *RateLimitHandler) {
+		h.errorPresenter = presenter
+	}
+}
+
+// NewRateLimitHandler builds a RateLimitHandler wrapping the given handler.
+// [RateLimitHandlerOpts] can be passed to customize the keys and the error responses.
+func NewRateLimitHandler(
+	next http.Handler,
+	limiter RateLimiter,
+	key RateLimitKeyFunc,
+	opts ...RateLimitHandlerOpts,
+) *RateLimitHandler {
+	handler := &RateLimitHandler{
+		next:           next,
+		limiter:        limiter,
+		key:            key,
+		errorPresenter: rateLimitErrorPresenter{},
+	}
+
+	for _, opt := range opts {
+		opt(handler)
+	}
+
+	return handler
+}
+
+// ServeHTTP allows RateLimitHandler to be used by any HTTP "ServeMux".
+func (handler *RateLimitHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	key := handler.key(req)
+	if key == "" {
+		handler.next.ServeHTTP(rw, req)
+		return
+	}
+
+	decision, err := handler.limiter.Allow(req.Context(), handler.prefix+key)
+	if err != nil {
+		handler.errorPresenter.Present(req.Context(), rw, fmt.Errorf("%w caused by %w", ErrRateLimitStore, err))
+		return
+	}
+
+	rw.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	rw.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	rw.Header().Set("RateLimit-Reset", durationToSeconds(decision.Reset))
+
+	if !decision.Allowed {
+		rw.Header().Set("Retry-After", durationToSeconds(decision.RetryAfter))
+		handler.errorPresenter.Present(req.Context(), rw, ErrRateLimitExceeded)
+		return
+	}
+
+	handler.next.ServeHTTP(rw, req)
+}
+
+func durationToSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+type rateLimitErrorPresenter struct{}
+
+func (p rateLimitErrorPresenter) Present(ctx context.Context, rw http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, ErrRateLimitExceeded) {
+		status = http.StatusTooManyRequests
+	}
+
+	http.Error(rw, http.StatusText(status), status)
+}
@@ -0,0 +1,88 @@
+package propre
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitState is the state of a key of a rate limiter, shared by the
+// algorithms:
+//   - for a token bucket, Value is the number of tokens left at the time of the
+//     last refill Timestamp,
+//   - for a sliding window, Value is the number of requests of the current window
+//     starting at Timestamp, and Previous the number of requests of the previous one.
+type RateLimitState struct {
+	Value     float64
+	Previous  float64
+	Timestamp time.Time
+}
+
+// RateLimitStore is the interface of the storage of the rate limiters state.
+type RateLimitStore interface {
+	// Update atomically replaces the state of the key with the result of update.
+	// The found argument is false if the key has no state yet, or if it has expired.
+	// The new state expires after the given TTL.
+	Update(
+		ctx context.Context,
+		key string,
+		ttl time.Duration,
+		update func(state RateLimitState, found bool) RateLimitState,
+	) error
+}
+
+type rateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// InMemoryRateLimitStore is a [RateLimitStore] keeping the states in memory,
+// suitable for a single instance. The expired states are removed periodically
+// when the store is updated.
+type InMemoryRateLimitStore struct {
+	mu          sync.Mutex
+	entries     map[string]rateLimitEntry
+	lastCleanup time.Time
+}
+
+// NewInMemoryRateLimitStore builds an empty [InMemoryRateLimitStore].
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{
+		entries:     make(map[string]rateLimitEntry),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Update implements [RateLimitStore].
+func (s *InMemoryRateLimitStore) Update(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	update func(state RateLimitState, found bool) RateLimitState,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastCleanup) > time.Minute {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+
+		s.lastCleanup = now
+	}
+
+	entry, found := s.entries[key]
+	if found && !now.Before(entry.expiresAt) {
+		found = false
+	}
+
+	s.entries[key] = rateLimitEntry{
+		state:     update(entry.state, found),
+		expiresAt: now.Add(ttl),
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type failingRateLimitStore struct{}
+
+func (s failingRateLimitStore) Update(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	update func(state propre.RateLimitState, found bool) propre.RateLimitState,
+) error {
+	return errors.New("store unavailable")
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestRateLimitHandlerRejectsTheRequestsOverTheLimit(t *testing.T) {
+	limiter, _ := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), propre.RateLimit{
+		Requests: 2,
+		Period:   time.Minute,
+	})
+
+	handler := propre.NewRateLimitHandler(okHandler(), limiter, propre.RateLimitByIP())
+
+	expectedStatuses := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	expectedRemaining := []string{"1", "0", "0"}
+	for i, expectedStatus := range expectedStatuses {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+
+		if rw.Code != expectedStatus {
+			t.Fatalf("wrong status code for request %d, expected %d, got %d", i, expectedStatus, rw.Code)
+		}
+
+		if rw.Header().Get("RateLimit-Limit") != "2" || rw.Header().Get("RateLimit-Remaining") != expectedRemaining[i] {
+			t.Fatalf("unexpected rate limit headers for request %d: %v", i, rw.Header())
+		}
+
+		if rw.Header().Get("RateLimit-Reset") == "" {
+			t.Fatalf("missing RateLimit-Reset header for request %d", i)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	if rw.Header().Get("Retry-After") != "30" {
+		t.Fatalf("unexpected Retry-After header %q", rw.Header().Get("Retry-After"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.2:1234"
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	if rw.Code != http.StatusOK {
+		t.Fatalf("another IP should not be limited, got %d", rw.Code)
+	}
+}
+
+func TestRateLimitHandlerDoesNotLimitRequestsWithoutKey(t *testing.T) {
+	limiter, _ := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), propre.RateLimit{
+		Requests: 1,
+		Period:   time.Minute,
+	})
+
+	handler := propre.NewRateLimitHandler(okHandler(), limiter, propre.RateLimitByHeader("x-api-key"))
+
+	for range 3 {
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rw.Code != http.StatusOK {
+			t.Fatalf("wrong status code, expected %d, got %d", http.StatusOK, rw.Code)
+		}
+	}
+}
+
+func TestRateLimitHandlerPrefixesTheKeys(t *testing.T) {
+	store := propre.NewInMemoryRateLimitStore()
+	limit := propre.RateLimit{Requests: 1, Period: time.Minute}
+
+	limiter, _ := propre.NewSlidingWindowLimiter(store, limit)
+
+	first := propre.NewRateLimitHandler(okHandler(), limiter,
+		propre.RateLimitByHeader("x-api-key"), propre.WithRateLimitPrefix("first:"))
+	second := propre.NewRateLimitHandler(okHandler(), limiter,
+		propre.RateLimitByHeader("x-api-key"), propre.WithRateLimitPrefix("second:"))
+
+	for _, handler := range []http.Handler{first, second} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("x-api-key", "some-key")
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+
+		if rw.Code != http.StatusOK {
+			t.Fatalf("each route should have its own quota, got %d", rw.Code)
+		}
+	}
+}
+
+func TestRateLimitHandlerPresentsStoreErrors(t *testing.T) {
+	limiter, _ := propre.NewTokenBucketLimiter(failingRateLimitStore{}, propre.RateLimit{Requests: 1, Period: time.Minute})
+	handler := propre.NewRateLimitHandler(okHandler(), limiter, propre.RateLimitByIP())
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rw.Code != http.StatusInternalServerError {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusInternalServerError, rw.Code)
+	}
+}
+
+func TestRateLimitHandlerLimitsByPrincipal(t *testing.T) {
+	type testCase struct {
+		key      propre.RateLimitKeyFunc
+		other    propre.Principal
+		expected int
+	}
+
+	testCases := map[string]testCase{
+		"by principal": {
+			key:      propre.RateLimitByPrincipal(),
+			other:    propre.Principal{ID: "bob", Tenant: "acme"},
+			expected: http.StatusOK,
+		},
+		"by tenant": {
+			key:      propre.RateLimitByTenant(),
+			other:    propre.Principal{ID: "bob", Tenant: "acme"},
+			expected: http.StatusTooManyRequests,
+		},
+		"anonymous": {
+			key:      propre.RateLimitByPrincipal(),
+			expected: http.StatusOK,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			limiter, _ := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), propre.RateLimit{
+				Requests: 1,
+				Period:   time.Minute,
+			})
+
+			handler := propre.NewRateLimitHandler(okHandler(), limiter, tc.key)
+
+			var rw *httptest.ResponseRecorder
+			for _, principal := range []propre.Principal{{ID: "alice", Tenant: "acme"}, tc.other} {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				if principal.ID != "" {
+					req = req.WithContext(propre.ContextWithPrincipal(req.Context(), principal))
+				}
+
+				rw = httptest.NewRecorder()
+				handler.ServeHTTP(rw, req)
+			}
+
+			if rw.Code != tc.expected {
+				t.Fatalf("wrong status code, expected %d, got %d", tc.expected, rw.Code)
+			}
+		})
+	}
+}
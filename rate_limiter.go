@@ -0,0 +1,255 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalidRateLimit is returned by the constructors of the rate limiters, and by
+// their Allow method for the limits returned by a [RateLimitFunc], if the number
+// of requests or the period of a limit is not positive.
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// RateLimit is the number of requests allowed per period.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+	// Burst is the capacity of a token bucket, it defaults to Requests.
+	// It is ignored by the sliding window.
+	Burst int
+}
+
+// validate returns an error if the limit cannot be enforced, as a zero period or
+// a zero number of requests would make the limiters divide by zero.
+func (limit RateLimit) validate() error {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		return fmt.Errorf("%w of %d requests per %s, both must be positive", ErrInvalidRateLimit, limit.Requests, limit.Period)
+	}
+
+	return nil
+}
+
+// RateLimitFunc returns the limit of a key, like the limit of the plan of a
+// tenant. The context is the one of the request, holding its principal if any,
+// see [PrincipalFromContext], and the key is prefixed, see [WithRateLimitPrefix].
+// A zero RateLimit falls back on the limit given to the rate limiter.
+type RateLimitFunc func(ctx context.Context, key string) (RateLimit, error)
+
+// rateLimiter holds the state and the limits shared by the rate limiters.
+type rateLimiter struct {
+	store     RateLimitStore
+	limit     RateLimit
+	limitFunc RateLimitFunc
+}
+
+// RateLimiterOpts is the alias for the [TokenBucketLimiter] and [SlidingWindowLimiter]
+// builder options.
+type RateLimiterOpts func(l *rateLimiter)
+
+// WithRateLimitFunc is a rate limiter option to look up the limit of each key
+// with the given function, instead of enforcing the same limit for every key.
+// The errors of the function are returned by the Allow method of the limiter.
+func WithRateLimitFunc(limitFunc RateLimitFunc) RateLimiterOpts {
+	return func(l *rateLimiter) {
+		l.limitFunc = limitFunc
+	}
+}
+
+func newRateLimiter(store RateLimitStore, limit RateLimit, opts []RateLimiterOpts) (rateLimiter, error) {
+	l := rateLimiter{store: store, limit: limit}
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return l, limit.validate()
+}
+
+// limitOf returns the limit of the key.
+func (l *rateLimiter) limitOf(ctx context.Context, key string) (RateLimit, error) {
+	if l.limitFunc == nil {
+		return l.limit, nil
+	}
+
+	limit, err := l.limitFunc(ctx, key)
+	if err != nil {
+		return RateLimit{}, err
+	}
+
+	if limit == (RateLimit{}) {
+		return l.limit, nil
+	}
+
+	return limit, limit.validate()
+}
+
+// RateLimitDecision is the result of a [RateLimiter] for a request.
+type RateLimitDecision struct {
+	Allowed bool
+	// Limit is the number of requests allowed in a period.
+	Limit int
+	// Remaining is the number of requests still allowed.
+	Remaining int
+	// Reset is the time before the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time before a rejected request is allowed again.
+	RetryAfter time.Duration
+}
+
+// RateLimiter is the interface of the rate limiting algorithms.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitDecision, error)
+}
+
+// TokenBucketLimiter is a [RateLimiter] implementing the token bucket algorithm:
+// a bucket of Burst tokens is refilled at the rate of Requests per Period, and each
+// request takes a token. It allows bursts while enforcing an average rate.
+type TokenBucketLimiter struct {
+	rateLimiter
+}
+
+// NewTokenBucketLimiter builds a [TokenBucketLimiter] storing its state in the given store.
+// It returns [ErrInvalidRateLimit] if the number of requests or the period of the
+// limit is not positive. [RateLimiterOpts] can be passed to look up the limit of each key.
+func NewTokenBucketLimiter(store RateLimitStore, limit RateLimit, opts ...RateLimiterOpts) (*TokenBucketLimiter, error) {
+	l, err := newRateLimiter(store, limit, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenBucketLimiter{rateLimiter: l}, nil
+}
+
+// Allow implements [RateLimiter].
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string) (RateLimitDecision, error) {
+	limit, err := l.limitOf(ctx, key)
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+
+	if limit.Burst <= 0 {
+		limit.Burst = limit.Requests
+	}
+
+	now := time.Now()
+	capacity := float64(limit.Burst)
+	rate := float64(limit.Requests) / limit.Period.Seconds()
+	fillDuration := time.Duration(capacity / rate * float64(time.Second))
+
+	decision := RateLimitDecision{Limit: limit.Burst}
+	err = l.store.Update(ctx, key, fillDuration, func(state RateLimitState, found bool) RateLimitState {
+		tokens := capacity
+		if found {
+			elapsed := now.Sub(state.Timestamp).Seconds()
+			tokens = math.Min(capacity, state.Value+max(elapsed, 0)*rate)
+		}
+
+		if tokens >= 1 {
+			decision.Allowed = true
+			tokens--
+		} else {
+			decision.RetryAfter = secondsToDuration((1 - tokens) / rate)
+		}
+
+		decision.Remaining = int(math.Floor(tokens))
+		decision.Reset = secondsToDuration((capacity - tokens) / rate)
+
+		return RateLimitState{Value: tokens, Timestamp: now}
+	})
+
+	return decision, err
+}
+
+// SlidingWindowLimiter is a [RateLimiter] implementing the sliding window counter
+// algorithm: the requests of the previous window are weighted by the overlap with
+// a window ending now, so the limit is enforced without the bursts allowed at the
+// edges of fixed windows.
+type SlidingWindowLimiter struct {
+	rateLimiter
+}
+
+// NewSlidingWindowLimiter builds a [SlidingWindowLimiter] storing its state in the given store.
+// It returns [ErrInvalidRateLimit] if the number of requests or the period of the
+// limit is not positive. [RateLimiterOpts] can be passed to look up the limit of each key.
+func NewSlidingWindowLimiter(store RateLimitStore, limit RateLimit, opts ...RateLimiterOpts) (*SlidingWindowLimiter, error) {
+	l, err := newRateLimiter(store, limit, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SlidingWindowLimiter{rateLimiter: l}, nil
+}
+
+// Allow implements [RateLimiter].
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key string) (RateLimitDecision, error) {
+	rateLimit, err := l.limitOf(ctx, key)
+	if err != nil {
+		return RateLimitDecision{}, err
+	}
+
+	now := time.Now()
+	period := rateLimit.Period
+	limit := float64(rateLimit.Requests)
+
+	decision := RateLimitDecision{Limit: rateLimit.Requests}
+	err = l.store.Update(ctx, key, 2*period, func(state RateLimitState, found bool) RateLimitState {
+		windowStart := now.Truncate(period)
+		if !found {
+			state = RateLimitState{Timestamp: windowStart}
+		}
+
+		switch elapsedWindows := now.Sub(state.Timestamp) / period; {
+		case elapsedWindows == 1:
+			state = RateLimitState{Previous: state.Value, Timestamp: windowStart}
+		case elapsedWindows > 1:
+			state = RateLimitState{Timestamp: windowStart}
+		}
+
+		elapsed := now.Sub(state.Timestamp)
+		weight := 1 - float64(elapsed)/float64(period)
+		count := state.Previous*weight + state.Value
+
+		if count < limit {
+			decision.Allowed = true
+			state.Value++
+			count++
+		} else {
+			decision.RetryAfter = slidingWindowRetryAfter(state, limit, period, elapsed)
+		}
+
+		decision.Remaining = max(int(math.Floor(limit-count)), 0)
+		decision.Reset = period - elapsed
+		if state.Value > 0 {
+			decision.Reset += period
+		}
+
+		return state
+	})
+
+	return decision, err
+}
+
+// slidingWindowRetryAfter returns the time before the weighted count falls
+// below the limit.
+func slidingWindowRetryAfter(state RateLimitState, limit float64, period, elapsed time.Duration) time.Duration {
+	untilNextWindow := period - elapsed
+	if state.Value >= limit {
+		// In the next window the current count becomes the previous one:
+		// current * (1 - t / period) < limit
+		return untilNextWindow + time.Duration(float64(period)*(1-limit/state.Value)) + time.Millisecond
+	}
+
+	if state.Previous == 0 {
+		return untilNextWindow
+	}
+
+	// previous * (1 - (elapsed + t) / period) + current < limit
+	t := time.Duration(float64(period)*(1-(limit-state.Value)/state.Previous)) - elapsed + time.Millisecond
+	return min(max(t, time.Millisecond), untilNextWindow)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
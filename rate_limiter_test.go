@@ -0,0 +1,178 @@
+package propre_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+func TestTokenBucketLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter, err := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), propre.RateLimit{
+		Requests: 10,
+		Period:   time.Second,
+		Burst:    3,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := range 3 {
+		decision, err := limiter.Allow(ctx, "some-key")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if !decision.Allowed || decision.Limit != 3 || decision.Remaining != 2-i {
+			t.Fatalf("unexpected decision for request %d: %+v", i, decision)
+		}
+	}
+
+	decision, _ := limiter.Allow(ctx, "some-key")
+	if decision.Allowed {
+		t.Fatal("the bucket should be empty")
+	}
+
+	if decision.RetryAfter <= 0 || decision.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("unexpected retry after %s", decision.RetryAfter)
+	}
+
+	decision, _ = limiter.Allow(ctx, "other-key")
+	if !decision.Allowed {
+		t.Fatal("each key should have its own bucket")
+	}
+
+	time.Sleep(decision.RetryAfter + 100*time.Millisecond)
+
+	decision, _ = limiter.Allow(ctx, "some-key")
+	if !decision.Allowed {
+		t.Fatal("the bucket should be refilled")
+	}
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	ctx := context.Background()
+	period := 200 * time.Millisecond
+	limiter, err := propre.NewSlidingWindowLimiter(propre.NewInMemoryRateLimitStore(), propre.RateLimit{
+		Requests: 4,
+		Period:   period,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Start at the beginning of a window to avoid crossing its edge.
+	time.Sleep(time.Until(time.Now().Truncate(period).Add(period)))
+
+	for i := range 4 {
+		decision, err := limiter.Allow(ctx, "some-key")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if !decision.Allowed || decision.Limit != 4 || decision.Remaining != 3-i {
+			t.Fatalf("unexpected decision for request %d: %+v", i, decision)
+		}
+	}
+
+	decision, _ := limiter.Allow(ctx, "some-key")
+	if decision.Allowed {
+		t.Fatal("the limit should be reached")
+	}
+
+	if decision.RetryAfter <= period/2 || decision.RetryAfter > 2*period {
+		t.Fatalf("unexpected retry after %s", decision.RetryAfter)
+	}
+
+	// At the beginning of the next window the previous requests are still
+	// weighted, so the quota is not fully restored.
+	time.Sleep(time.Until(time.Now().Truncate(period).Add(period)))
+
+	allowed := 0
+	for range 4 {
+		decision, _ = limiter.Allow(ctx, "some-key")
+		if decision.Allowed {
+			allowed++
+		}
+	}
+
+	if allowed == 4 {
+		t.Fatal("the previous window should still be weighted")
+	}
+
+	time.Sleep(decision.RetryAfter)
+	decision, _ = limiter.Allow(ctx, "some-key")
+	if !decision.Allowed {
+		t.Fatalf("the request should be allowed after the retry delay: %+v", decision)
+	}
+}
+
+func TestRateLimitersRejectInvalidLimits(t *testing.T) {
+	type testCase struct {
+		limit propre.RateLimit
+	}
+
+	testCases := map[string]testCase{
+		"zero period":       {limit: propre.RateLimit{Requests: 10}},
+		"negative period":   {limit: propre.RateLimit{Requests: 10, Period: -time.Second}},
+		"zero requests":     {limit: propre.RateLimit{Period: time.Second}},
+		"negative requests": {limit: propre.RateLimit{Requests: -1, Period: time.Second}},
+	}
+
+	constructors := map[string]func(propre.RateLimit) error{
+		"token bucket": func(limit propre.RateLimit) error {
+			_, err := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), limit)
+			return err
+		},
+		"sliding window": func(limit propre.RateLimit) error {
+			_, err := propre.NewSlidingWindowLimiter(propre.NewInMemoryRateLimitStore(), limit)
+			return err
+		},
+	}
+
+	for name, tc := range testCases {
+		for limiter, construct := range constructors {
+			t.Run(limiter+" with "+name, func(t *testing.T) {
+				if err := construct(tc.limit); !errors.Is(err, propre.ErrInvalidRateLimit) {
+					t.Fatalf("expected the error %v, got %v", propre.ErrInvalidRateLimit, err)
+				}
+			})
+		}
+	}
+}
+
+func TestRateLimitersLookUpTheLimitOfEachKey(t *testing.T) {
+	ctx := context.Background()
+	limits := map[string]propre.RateLimit{
+		"premium": {Requests: 3, Period: time.Minute},
+		"broken":  {Requests: 3},
+	}
+
+	limitFunc := propre.WithRateLimitFunc(func(ctx context.Context, key string) (propre.RateLimit, error) {
+		return limits[key], nil
+	})
+
+	limit := propre.RateLimit{Requests: 1, Period: time.Minute}
+	tokenBucket, _ := propre.NewTokenBucketLimiter(propre.NewInMemoryRateLimitStore(), limit, limitFunc)
+	slidingWindow, _ := propre.NewSlidingWindowLimiter(propre.NewInMemoryRateLimitStore(), limit, limitFunc)
+
+	for name, limiter := range map[string]propre.RateLimiter{"token bucket": tokenBucket, "sliding window": slidingWindow} {
+		t.Run(name, func(t *testing.T) {
+			for key, expectedLimit := range map[string]int{"premium": 3, "free": 1} {
+				decision, err := limiter.Allow(ctx, key)
+				if err != nil || decision.Limit != expectedLimit {
+					t.Fatalf("expected a limit of %d for %s, got %+v and %v", expectedLimit, key, decision, err)
+				}
+			}
+
+			if _, err := limiter.Allow(ctx, "broken"); !errors.Is(err, propre.ErrInvalidRateLimit) {
+				t.Fatalf("expected the error %v, got %v", propre.ErrInvalidRateLimit, err)
+			}
+		})
+	}
+}
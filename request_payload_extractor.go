@@ -1,6 +1,7 @@
 package propre
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -39,6 +40,29 @@ type Validatable interface {
 // request body.
 type RequestPayloadExtractor[Payload Validatable] struct {
 	decoder func(io.Reader) PayloadDecoder
+	schema  *Schema
+}
+
+// RequestPayloadExtractorOpts is the alias for the [RequestPayloadExtractor] builder options.
+type RequestPayloadExtractorOpts[Payload Validatable] func(e *RequestPayloadExtractor[Payload])
+
+// WithJSONSchema is a [RequestPayloadExtractor] option to validate the decoded
+// payloads against a JSON Schema document, like a schema parsed with [ParseJSONSchema].
+// The body is validated before being decoded in the payload, see [RequestPayloadExtractor.Extract],
+// and a [*SchemaValidationError] is returned before calling its Validate method.
+func WithJSONSchema[Payload Validatable](schema *Schema) RequestPayloadExtractorOpts[Payload] {
+	return func(e *RequestPayloadExtractor[Payload]) {
+		e.schema = schema
+	}
+}
+
+// WithReflectedJSONSchema is a [RequestPayloadExtractor] option to validate the
+// decoded payloads against the schema reflected from the Payload type by
+// [JSONSchemaOf], see [WithJSONSchema].
+func WithReflectedJSONSchema[Payload Validatable]() RequestPayloadExtractorOpts[Payload] {
+	return func(e *RequestPayloadExtractor[Payload]) {
+		e.schema = JSONSchemaOf[Payload]()
+	}
 }
 
 // NewRequestPayloadExtractor builds a new [RequestPayloadExtractor].
@@ -47,24 +71,67 @@ type RequestPayloadExtractor[Payload Validatable] struct {
 //
 // [JSONDecoder] and [XMLDecoder] are two functions that return standard decoders,
 // but you can create your own for your specific needs.
+// [RequestPayloadExtractorOpts] can be passed to validate the payloads against a JSON Schema.
 func NewRequestPayloadExtractor[Payload Validatable](
 	decoder func(io.Reader) PayloadDecoder,
+	opts ...RequestPayloadExtractorOpts[Payload],
 ) *RequestPayloadExtractor[Payload] {
-	return &RequestPayloadExtractor[Payload]{
+	extractor := &RequestPayloadExtractor[Payload]{
 		decoder: decoder,
 	}
+
+	for _, opt := range opts {
+		opt(extractor)
+	}
+
+	return extractor
+}
+
+// JSONSchema returns the schema the payloads are validated against, nil if
+// the extractor has no schema.
+func (extractor *RequestPayloadExtractor[Payload]) JSONSchema() *Schema {
+	return extractor.schema
 }
 
 // Extract takes a request as an argument and extracts its body into the given Payload type.
+// If the extractor has a JSON Schema, the body decoded in an any is validated
+// against it first, so the required and the unknown properties are checked as
+// sent by the client. If the decoder cannot decode the body in an any, like
+// the XML one, the decoded payload is validated once encoded in JSON instead.
 // If the decoder fails an error [ErrRequestPayloadExtraction] wraps the decoder error.
 // Then the method Validate of the Payload type is called and its error is returned.
 func (extractor *RequestPayloadExtractor[Payload]) Extract(req *http.Request) (Payload, error) {
 	var payload Payload
-	decoder := extractor.decoder(req.Body)
-	err := decoder.Decode(&payload)
+	if extractor.schema == nil {
+		if err := extractor.decoder(req.Body).Decode(&payload); err != nil {
+			return payload, fmt.Errorf("%w caused by %s", ErrRequestPayloadExtraction, err)
+		}
+
+		return payload, payload.Validate()
+	}
+
+	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		return payload, fmt.Errorf("%w caused by %s", ErrRequestPayloadExtraction, err)
 	}
 
+	var instance any
+	validated := extractor.decoder(bytes.NewReader(body)).Decode(&instance) == nil
+	if validated {
+		if err := extractor.schema.ValidateInstance(instance); err != nil {
+			return payload, err
+		}
+	}
+
+	if err := extractor.decoder(bytes.NewReader(body)).Decode(&payload); err != nil {
+		return payload, fmt.Errorf("%w caused by %s", ErrRequestPayloadExtraction, err)
+	}
+
+	if !validated {
+		if err := extractor.schema.ValidateValue(payload); err != nil {
+			return payload, err
+		}
+	}
+
 	return payload, payload.Validate()
 }
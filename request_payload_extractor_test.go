@@ -130,3 +130,77 @@ func TestExtractRequestPayload(t *testing.T) {
 		})
 	}
 }
+
+type schemaPayload struct {
+	Title string   `json:"title" jsonschema:"minLength=3"`
+	Tags  []string `json:"tags"`
+}
+
+func (p schemaPayload) Validate() error {
+	return errInvalidPayload
+}
+
+func TestExtractRequestPayloadWithJSONSchema(t *testing.T) {
+	externalSchema, err := propre.ParseJSONSchema([]byte(
+		`{"type":"object","properties":{"title":{"maxLength":5}},"additionalProperties":false}`,
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	type schemaTestCase struct {
+		body          string
+		opts          []propre.RequestPayloadExtractorOpts[schemaPayload]
+		expectedError error
+	}
+
+	testCases := map[string]schemaTestCase{
+		"the reflected schema is checked before Validate": {
+			body:          `{"title":"a"}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithReflectedJSONSchema[schemaPayload]()},
+			expectedError: propre.ErrSchemaValidation,
+		},
+		"an external schema is checked before Validate": {
+			body:          `{"title":"a long title"}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithJSONSchema[schemaPayload](externalSchema)},
+			expectedError: propre.ErrSchemaValidation,
+		},
+		"the required properties are checked on the body": {
+			body:          `{"tags":["a"]}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithReflectedJSONSchema[schemaPayload]()},
+			expectedError: propre.ErrSchemaValidation,
+		},
+		"the omitted slices are not required": {
+			body:          `{"title":"title"}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithReflectedJSONSchema[schemaPayload]()},
+			expectedError: errInvalidPayload,
+		},
+		"the unknown properties are checked on the body": {
+			body:          `{"title":"title","unknown":true}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithJSONSchema[schemaPayload](externalSchema)},
+			expectedError: propre.ErrSchemaValidation,
+		},
+		"Validate is called if the payload matches the schema": {
+			body:          `{"title":"title"}`,
+			opts:          []propre.RequestPayloadExtractorOpts[schemaPayload]{propre.WithJSONSchema[schemaPayload](externalSchema)},
+			expectedError: errInvalidPayload,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			extractor := propre.NewRequestPayloadExtractor(propre.JSONDecoder, tc.opts...)
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			_, err := extractor.Extract(req)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("unexpected error, expected %v, got %v", tc.expectedError, err)
+			}
+		})
+	}
+
+	extractor := propre.NewRequestPayloadExtractor(propre.JSONDecoder, propre.WithReflectedJSONSchema[schemaPayload]())
+	if extractor.JSONSchema() == nil || extractor.JSONSchema().Properties["title"] == nil {
+		t.Fatalf("the schema should be shared, got %+v", extractor.JSONSchema())
+	}
+}
@@ -2,10 +2,22 @@ package propre
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 )
 
 var (
+	// ErrViewEncoding is reported by [HTTPResponse] when the Encode method of
+	// a view model fails.
+	ErrViewEncoding = errors.New("view encoding error")
+
+	// ErrResponseWrite is reported by [HTTPResponse] when the response body
+	// cannot be written, for example because the client is disconnected.
+	ErrResponseWrite = errors.New("response write error")
+
 	defaultInternalError = []byte("internal error")
 )
 
@@ -24,6 +36,23 @@ type HTTPSendable interface {
 type HTTPResponse[View HTTPSendable] struct {
 	headers              http.Header
 	genericInternalError []byte
+	internalErrorView    HTTPSendable
+	errorReporter        ErrorReporter
+}
+
+// ErrorReporter is notified by [HTTPResponse] of the errors it cannot return
+// to the caller. The view argument is the type of the view model being sent.
+// The error wraps either [ErrViewEncoding] or [ErrResponseWrite].
+type ErrorReporter interface {
+	Report(ctx context.Context, view reflect.Type, err error)
+}
+
+// ErrorReporterFunc is an adapter to use an ordinary function as an [ErrorReporter].
+type ErrorReporterFunc func(ctx context.Context, view reflect.Type, err error)
+
+// Report calls f(ctx, view, err).
+func (f ErrorReporterFunc) Report(ctx context.Context, view reflect.Type, err error) {
+	f(ctx, view, err)
 }
 
 // HTTPResponseOpts is the alias for the [HTTPResponse] builder options.
@@ -46,8 +75,27 @@ func WithGenericInternalError[View HTTPSendable](payload []byte) HTTPResponseOpt
 	}
 }
 
+// WithInternalErrorView is an [HTTPResponse] option to define a custom view
+// model for internal errors. Unlike [WithGenericInternalError], the view model
+// provides its own content type and status code.
+// If both options are set, this one takes precedence.
+func WithInternalErrorView[View HTTPSendable](view HTTPSendable) HTTPResponseOpts[View] {
+	return func(r *HTTPResponse[View]) {
+		r.internalErrorView = view
+	}
+}
+
+// WithErrorReporter is an [HTTPResponse] option to be notified of the view
+// encoding and response write errors.
+func WithErrorReporter[View HTTPSendable](reporter ErrorReporter) HTTPResponseOpts[View] {
+	return func(r *HTTPResponse[View]) {
+		r.errorReporter = reporter
+	}
+}
+
 // NewHTTPResponse returns an [HTTPResponse]. [HTTPResponseOpts] can be passed
-// to customize the common response headers and the default internal error payload.
+// to customize the common response headers, the default internal error payload
+// and the error reporter.
 func NewHTTPResponse[View HTTPSendable](opts ...HTTPResponseOpts[View]) *HTTPResponse[View] {
 	response := &HTTPResponse[View]{}
 	for _, opt := range opts {
@@ -60,29 +108,120 @@ func NewHTTPResponse[View HTTPSendable](opts ...HTTPResponseOpts[View]) *HTTPRes
 // Send returns the response to the client.
 // It first sets the content type and the common headers if some have been defined
 // and the status code, and the payload is encoded and is sent through the [http.ResponseWriter].
+//
+// If the view model implements [HTTPBodyless], or if its status code does not permit
+// a body (1xx, 204 and 304), neither the content type nor the body is sent.
+// The Content-Length header is always set when a body is permitted, so the
+// response to a HEAD request announces the same length as a GET one.
+//
+// If the encoding fails, the internal error payload is sent instead, without the
+// content type and the headers of the view. Encoding and write errors are notified
+// to the [ErrorReporter] set with [WithErrorReporter].
 func (r *HTTPResponse[View]) Send(ctx context.Context, rw http.ResponseWriter, data View) {
-	rw.Header().Set("content-type", data.ContentType(ctx))
+	statusCode := data.StatusCode(ctx)
+	bodyless := isBodyless(ctx, data, statusCode)
+	if !bodyless {
+		rw.Header().Set("content-type", data.ContentType(ctx))
+	}
 
-	if len(r.headers) > 0 {
-		for header, values := range r.headers {
-			for _, headerValue := range values {
-				rw.Header().Set(header, headerValue)
-			}
+	setHeaders(rw, r.headers)
+
+	var viewHeaders http.Header
+	if headerer, ok := any(data).(HTTPHeaderer); ok {
+		viewHeaders = headerer.Headers(ctx)
+		setHeaders(rw, viewHeaders)
+	}
+
+	if bodyless {
+		if bodyAllowedForStatus(statusCode) {
+			rw.Header().Set("content-length", "0")
 		}
+
+		rw.WriteHeader(statusCode)
+		return
 	}
 
 	encoded, err := data.Encode(ctx)
 	if err != nil {
-		rw.WriteHeader(http.StatusInternalServerError)
-		internalError := defaultInternalError
-		if r.genericInternalError != nil {
-			internalError = r.genericInternalError
+		r.report(ctx, data, fmt.Errorf("%w caused by %w", ErrViewEncoding, err))
+		r.sendInternalError(ctx, rw, data, viewHeaders)
+		return
+	}
+
+	r.write(ctx, rw, data, statusCode, encoded)
+}
+
+func (r *HTTPResponse[View]) sendInternalError(ctx context.Context, rw http.ResponseWriter, data View, viewHeaders http.Header) {
+	// The headers of the view describe a body which is not sent, the common
+	// headers it may have overridden are restored.
+	rw.Header().Del("content-type")
+	for header := range viewHeaders {
+		rw.Header().Del(header)
+	}
+
+	setHeaders(rw, r.headers)
+
+	if r.internalErrorView != nil {
+		encoded, err := r.internalErrorView.Encode(ctx)
+		if err == nil {
+			rw.Header().Set("content-type", r.internalErrorView.ContentType(ctx))
+			if headerer, ok := r.internalErrorView.(HTTPHeaderer); ok {
+				setHeaders(rw, headerer.Headers(ctx))
+			}
+
+			r.write(ctx, rw, r.internalErrorView, r.internalErrorView.StatusCode(ctx), encoded)
+			return
 		}
 
-		rw.Write(internalError)
+		r.report(ctx, r.internalErrorView, fmt.Errorf("%w caused by %w", ErrViewEncoding, err))
+	}
+
+	internalError := defaultInternalError
+	if r.genericInternalError != nil {
+		internalError = r.genericInternalError
+	} else {
+		rw.Header().Set("content-type", "text/plain; charset=utf-8")
+	}
+
+	r.write(ctx, rw, data, http.StatusInternalServerError, internalError)
+}
+
+func setHeaders(rw http.ResponseWriter, headers http.Header) {
+	for header, values := range headers {
+		for _, headerValue := range values {
+			rw.Header().Set(header, headerValue)
+		}
+	}
+}
+
+func (r *HTTPResponse[View]) write(
+	ctx context.Context,
+	rw http.ResponseWriter,
+	data HTTPSendable,
+	statusCode int,
+	body []byte,
+) {
+	rw.Header().Set("content-length", strconv.Itoa(len(body)))
+	rw.WriteHeader(statusCode)
+	_, err := rw.Write(body)
+	if err != nil {
+		r.report(ctx, data, fmt.Errorf("%w caused by %w", ErrResponseWrite, err))
+	}
+}
+
+func (r *HTTPResponse[View]) report(ctx context.Context, data HTTPSendable, err error) {
+	if r.errorReporter == nil {
 		return
 	}
 
-	rw.WriteHeader(data.StatusCode(ctx))
-	rw.Write(encoded)
+	r.errorReporter.Report(ctx, reflect.TypeOf(data), err)
+}
+
+func isBodyless(ctx context.Context, data HTTPSendable, statusCode int) bool {
+	if !bodyAllowedForStatus(statusCode) {
+		return true
+	}
+
+	bodyless, ok := data.(HTTPBodyless)
+	return ok && bodyless.Bodyless(ctx)
 }
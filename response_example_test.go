@@ -114,3 +114,22 @@ func ExampleHTTPResponse_custom_error() {
 	// custom internal error
 	// 500
 }
+
+// A presenter can send a bodyless response with the built-in view models.
+// Here the view type parameter is [propre.HTTPSendable] so the same response
+// can send either a redirection or a regular view model.
+func ExampleHTTPResponse_redirect() {
+	ctx := context.Background()
+	response := propre.NewHTTPResponse[propre.HTTPSendable]()
+
+	rw := httptest.NewRecorder()
+	response.Send(ctx, rw, propre.SeeOther("/todos/42"))
+	result := rw.Result()
+
+	fmt.Println(result.StatusCode)
+	fmt.Println(result.Header.Get("location"))
+
+	// Output:
+	// 303
+	// /todos/42
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/cyb3rd4d/propre"
@@ -158,3 +159,197 @@ func TestResponse(t *testing.T) {
 		})
 	}
 }
+
+type reportedError struct {
+	view reflect.Type
+	err  error
+}
+
+type errorReporterSpy struct {
+	reported []reportedError
+}
+
+func (s *errorReporterSpy) Report(ctx context.Context, view reflect.Type, err error) {
+	s.reported = append(s.reported, reportedError{view: view, err: err})
+}
+
+type internalErrorViewModel struct {
+	encodingError bool
+}
+
+func (v internalErrorViewModel) ContentType(ctx context.Context) string {
+	return "application/problem+json"
+}
+
+func (v internalErrorViewModel) Encode(ctx context.Context) ([]byte, error) {
+	if v.encodingError {
+		return nil, errors.New("internal error view encoding error")
+	}
+
+	return []byte(`{"title":"internal error"}`), nil
+}
+
+func (v internalErrorViewModel) StatusCode(ctx context.Context) int {
+	return http.StatusServiceUnavailable
+}
+
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (rw failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("client disconnected")
+}
+
+func TestResponseReportsEncodingErrors(t *testing.T) {
+	reporter := new(errorReporterSpy)
+	response := propre.NewHTTPResponse(
+		propre.WithErrorReporter[payload[okViewModel]](reporter),
+	)
+
+	rw := httptest.NewRecorder()
+	response.Send(context.Background(), rw, payload[okViewModel]{encodingError: true})
+
+	if rw.Code != http.StatusInternalServerError {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusInternalServerError, rw.Code)
+	}
+
+	if len(reporter.reported) != 1 {
+		t.Fatalf("expected 1 reported error, got %d", len(reporter.reported))
+	}
+
+	if !errors.Is(reporter.reported[0].err, propre.ErrViewEncoding) {
+		t.Fatalf("unexpected reported error: %s", reporter.reported[0].err)
+	}
+
+	expectedView := reflect.TypeOf(payload[okViewModel]{})
+	if reporter.reported[0].view != expectedView {
+		t.Fatalf("wrong reported view type, expected %s, got %s", expectedView, reporter.reported[0].view)
+	}
+}
+
+func TestResponseReportsWriteErrors(t *testing.T) {
+	reporter := new(errorReporterSpy)
+	response := propre.NewHTTPResponse(
+		propre.WithErrorReporter[payload[okViewModel]](reporter),
+	)
+
+	rw := failingResponseWriter{httptest.NewRecorder()}
+	response.Send(context.Background(), rw, payload[okViewModel]{OK: &okViewModel{Data: "some data"}})
+
+	if len(reporter.reported) != 1 {
+		t.Fatalf("expected 1 reported error, got %d", len(reporter.reported))
+	}
+
+	if !errors.Is(reporter.reported[0].err, propre.ErrResponseWrite) {
+		t.Fatalf("unexpected reported error: %s", reporter.reported[0].err)
+	}
+}
+
+func TestResponseWithInternalErrorView(t *testing.T) {
+	response := propre.NewHTTPResponse(
+		propre.WithInternalErrorView[payload[okViewModel]](internalErrorViewModel{}),
+		propre.WithGenericInternalError[payload[okViewModel]]([]byte("ignored")),
+	)
+
+	rw := httptest.NewRecorder()
+	response.Send(context.Background(), rw, payload[okViewModel]{encodingError: true})
+
+	if rw.Code != http.StatusServiceUnavailable {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusServiceUnavailable, rw.Code)
+	}
+
+	if contentType := rw.Header().Get("content-type"); contentType != "application/problem+json" {
+		t.Fatalf("wrong content-type header, expected %q, got %q", "application/problem+json", contentType)
+	}
+
+	if body := rw.Body.String(); body != `{"title":"internal error"}` {
+		t.Fatalf("unexpected body %s", body)
+	}
+}
+
+func TestResponseFallsBackToTheGenericInternalErrorIfTheInternalErrorViewFails(t *testing.T) {
+	reporter := new(errorReporterSpy)
+	response := propre.NewHTTPResponse(
+		propre.WithInternalErrorView[payload[okViewModel]](internalErrorViewModel{encodingError: true}),
+		propre.WithErrorReporter[payload[okViewModel]](reporter),
+	)
+
+	rw := httptest.NewRecorder()
+	response.Send(context.Background(), rw, payload[okViewModel]{encodingError: true})
+
+	if rw.Code != http.StatusInternalServerError {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusInternalServerError, rw.Code)
+	}
+
+	if body := rw.Body.String(); body != "internal error" {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	if len(reporter.reported) != 2 {
+		t.Fatalf("expected 2 reported errors, got %d", len(reporter.reported))
+	}
+
+	expectedView := reflect.TypeOf(internalErrorViewModel{})
+	if reporter.reported[1].view != expectedView {
+		t.Fatalf("wrong reported view type, expected %s, got %s", expectedView, reporter.reported[1].view)
+	}
+}
+
+type headeredViewModel struct {
+	payload[okViewModel]
+}
+
+func (v headeredViewModel) Headers(ctx context.Context) http.Header {
+	return http.Header{"Location": {"/todos/1"}, "X-Common": {"overridden"}}
+}
+
+func TestResponseDropsTheHeadersOfTheViewOnInternalErrors(t *testing.T) {
+	type testCase struct {
+		opts                []propre.HTTPResponseOpts[headeredViewModel]
+		expectedHTTPStatus  int
+		expectedContentType string
+	}
+
+	testCases := map[string]testCase{
+		"default internal error": {
+			expectedHTTPStatus:  http.StatusInternalServerError,
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		"internal error view": {
+			opts: []propre.HTTPResponseOpts[headeredViewModel]{
+				propre.WithInternalErrorView[headeredViewModel](internalErrorViewModel{}),
+			},
+			expectedHTTPStatus:  http.StatusServiceUnavailable,
+			expectedContentType: "application/problem+json",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := append([]propre.HTTPResponseOpts[headeredViewModel]{
+				propre.WithHTTPResponseHeaders[headeredViewModel](http.Header{"X-Common": {"common"}}),
+			}, tc.opts...)
+
+			rw := httptest.NewRecorder()
+			view := headeredViewModel{payload: payload[okViewModel]{encodingError: true}}
+			propre.NewHTTPResponse(opts...).Send(context.Background(), rw, view)
+
+			if rw.Code != tc.expectedHTTPStatus {
+				t.Fatalf("wrong status code, expected %d, got %d", tc.expectedHTTPStatus, rw.Code)
+			}
+
+			if contentType := rw.Header().Get("content-type"); contentType != tc.expectedContentType {
+				t.Fatalf("wrong content-type header, expected %q, got %q", tc.expectedContentType, contentType)
+			}
+
+			if location := rw.Header().Get("location"); location != "" {
+				t.Fatalf("the headers of the view should be dropped, got the location %q", location)
+			}
+
+			if common := rw.Header().Get("x-common"); common != "common" {
+				t.Fatalf("the common headers should be kept, got %q", common)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package propre
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HTTPBodyless is an optional interface a view model can implement to tell
+// [HTTPResponse] that the response has no body. When Bodyless returns true,
+// the view model is not encoded and no content type is set.
+type HTTPBodyless interface {
+	Bodyless(context.Context) bool
+}
+
+// HTTPHeaderer is an optional interface a view model can implement to add its
+// own headers to the response, like the Location header of a redirection.
+// These headers are set after the common headers of [HTTPResponse].
+type HTTPHeaderer interface {
+	Headers(context.Context) http.Header
+}
+
+// HTTPRedirect is a built-in view model sending a redirection to the client.
+// It has no body and sets the Location header with the target URL.
+//
+// Use [MovedPermanently], [Found], [SeeOther], [TemporaryRedirect] or
+// [PermanentRedirect] to build it.
+type HTTPRedirect struct {
+	Status   int
+	Location string
+}
+
+// MovedPermanently returns an [HTTPRedirect] with a 301 status code.
+func MovedPermanently(location string) HTTPRedirect {
+	return HTTPRedirect{Status: http.StatusMovedPermanently, Location: location}
+}
+
+// Found returns an [HTTPRedirect] with a 302 status code.
+func Found(location string) HTTPRedirect {
+	return HTTPRedirect{Status: http.StatusFound, Location: location}
+}
+
+// SeeOther returns an [HTTPRedirect] with a 303 status code.
+func SeeOther(location string) HTTPRedirect {
+	return HTTPRedirect{Status: http.StatusSeeOther, Location: location}
+}
+
+// TemporaryRedirect returns an [HTTPRedirect] with a 307 status code.
+func TemporaryRedirect(location string) HTTPRedirect {
+	return HTTPRedirect{Status: http.StatusTemporaryRedirect, Location: location}
+}
+
+// PermanentRedirect returns an [HTTPRedirect] with a 308 status code.
+func PermanentRedirect(location string) HTTPRedirect {
+	return HTTPRedirect{Status: http.StatusPermanentRedirect, Location: location}
+}
+
+// ContentType implements [HTTPSendable], a redirection has no content type.
+func (r HTTPRedirect) ContentType(context.Context) string {
+	return ""
+}
+
+// Encode implements [HTTPSendable], a redirection has no body.
+func (r HTTPRedirect) Encode(context.Context) ([]byte, error) {
+	return nil, nil
+}
+
+// StatusCode implements [HTTPSendable].
+func (r HTTPRedirect) StatusCode(context.Context) int {
+	return r.Status
+}
+
+// Bodyless implements [HTTPBodyless].
+func (r HTTPRedirect) Bodyless(context.Context) bool {
+	return true
+}
+
+// Headers implements [HTTPHeaderer] to set the Location header.
+func (r HTTPRedirect) Headers(context.Context) http.Header {
+	return http.Header{"Location": []string{r.Location}}
+}
+
+// HTTPEmpty is a built-in view model sending a response without body, like
+// a 204 No Content or a 201 Created with a Location header.
+type HTTPEmpty struct {
+	Status int
+	Header http.Header
+}
+
+// NoContent returns an [HTTPEmpty] with a 204 status code.
+func NoContent() HTTPEmpty {
+	return HTTPEmpty{Status: http.StatusNoContent}
+}
+
+// Created returns an [HTTPEmpty] with a 201 status code and the Location header
+// set to the URL of the created resource.
+func Created(location string) HTTPEmpty {
+	return HTTPEmpty{
+		Status: http.StatusCreated,
+		Header: http.Header{"Location": []string{location}},
+	}
+}
+
+// Accepted returns an [HTTPEmpty] with a 202 status code and the Location header
+// set to the URL of the resource to follow the processing of the request.
+func Accepted(location string) HTTPEmpty {
+	return HTTPEmpty{
+		Status: http.StatusAccepted,
+		Header: http.Header{"Location": []string{location}},
+	}
+}
+
+// ContentType implements [HTTPSendable], an empty response has no content type.
+func (e HTTPEmpty) ContentType(context.Context) string {
+	return ""
+}
+
+// Encode implements [HTTPSendable], an empty response has no body.
+func (e HTTPEmpty) Encode(context.Context) ([]byte, error) {
+	return nil, nil
+}
+
+// StatusCode implements [HTTPSendable].
+func (e HTTPEmpty) StatusCode(context.Context) int {
+	return e.Status
+}
+
+// Bodyless implements [HTTPBodyless].
+func (e HTTPEmpty) Bodyless(context.Context) bool {
+	return true
+}
+
+// Headers implements [HTTPHeaderer].
+func (e HTTPEmpty) Headers(context.Context) http.Header {
+	return e.Header
+}
+
+// MultiStatusItem is the result of a single operation of a [MultiStatusView].
+type MultiStatusItem struct {
+	Status int `json:"status"`
+	Body   any `json:"body,omitempty"`
+}
+
+// MultiStatusView is a built-in view model sending a 207 Multi-Status JSON
+// response, with one item per operation, like the sub-requests of a [BatchHandler].
+// The response body is an object with an "items" array.
+type MultiStatusView struct {
+	Items []MultiStatusItem `json:"items"`
+}
+
+// ContentType implements [HTTPSendable].
+func (v MultiStatusView) ContentType(context.Context) string {
+	return "application/json"
+}
+
+// Encode implements [HTTPSendable].
+func (v MultiStatusView) Encode(context.Context) ([]byte, error) {
+	if v.Items == nil {
+		v.Items = []MultiStatusItem{}
+	}
+
+	return json.Marshal(v)
+}
+
+// StatusCode implements [HTTPSendable].
+func (v MultiStatusView) StatusCode(context.Context) int {
+	return http.StatusMultiStatus
+}
+
+// bodyAllowedForStatus reports whether the given status code permits a body,
+// as described in RFC 9110.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
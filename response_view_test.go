@@ -0,0 +1,142 @@
+package propre_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type notModifiedViewModel struct{}
+
+func (v notModifiedViewModel) ContentType(ctx context.Context) string {
+	return "application/json"
+}
+
+func (v notModifiedViewModel) Encode(ctx context.Context) ([]byte, error) {
+	panic("a 304 response must not be encoded")
+}
+
+func (v notModifiedViewModel) StatusCode(ctx context.Context) int {
+	return http.StatusNotModified
+}
+
+type bodylessResponseTestCase struct {
+	view                  propre.HTTPSendable
+	expectedHTTPStatus    int
+	expectedLocation      string
+	expectedContentLength string
+}
+
+func TestResponseWithBodylessViews(t *testing.T) {
+	testCases := map[string]bodylessResponseTestCase{
+		"moved permanently": {
+			view:                  propre.MovedPermanently("/moved"),
+			expectedHTTPStatus:    http.StatusMovedPermanently,
+			expectedLocation:      "/moved",
+			expectedContentLength: "0",
+		},
+		"found": {
+			view:                  propre.Found("/found"),
+			expectedHTTPStatus:    http.StatusFound,
+			expectedLocation:      "/found",
+			expectedContentLength: "0",
+		},
+		"see other": {
+			view:                  propre.SeeOther("/see-other"),
+			expectedHTTPStatus:    http.StatusSeeOther,
+			expectedLocation:      "/see-other",
+			expectedContentLength: "0",
+		},
+		"temporary redirect": {
+			view:                  propre.TemporaryRedirect("/temporary"),
+			expectedHTTPStatus:    http.StatusTemporaryRedirect,
+			expectedLocation:      "/temporary",
+			expectedContentLength: "0",
+		},
+		"permanent redirect": {
+			view:                  propre.PermanentRedirect("/permanent"),
+			expectedHTTPStatus:    http.StatusPermanentRedirect,
+			expectedLocation:      "/permanent",
+			expectedContentLength: "0",
+		},
+		"created": {
+			view:                  propre.Created("/todos/42"),
+			expectedHTTPStatus:    http.StatusCreated,
+			expectedLocation:      "/todos/42",
+			expectedContentLength: "0",
+		},
+		"no content": {
+			view:               propre.NoContent(),
+			expectedHTTPStatus: http.StatusNoContent,
+		},
+		"custom view with a status forbidding a body": {
+			view:               notModifiedViewModel{},
+			expectedHTTPStatus: http.StatusNotModified,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			response := propre.NewHTTPResponse[propre.HTTPSendable]()
+			rw := httptest.NewRecorder()
+			response.Send(context.Background(), rw, testCase.view)
+
+			result := rw.Result()
+			if result.StatusCode != testCase.expectedHTTPStatus {
+				t.Fatalf("wrong status code, expected %d, got %d", testCase.expectedHTTPStatus, result.StatusCode)
+			}
+
+			if location := result.Header.Get("location"); location != testCase.expectedLocation {
+				t.Fatalf("wrong location header, expected %q, got %q", testCase.expectedLocation, location)
+			}
+
+			if contentType := result.Header.Get("content-type"); contentType != "" {
+				t.Fatalf("unexpected content-type header %q", contentType)
+			}
+
+			if contentLength := result.Header.Get("content-length"); contentLength != testCase.expectedContentLength {
+				t.Fatalf("wrong content-length header, expected %q, got %q", testCase.expectedContentLength, contentLength)
+			}
+
+			body, err := io.ReadAll(result.Body)
+			if err != nil {
+				t.Fatalf("could not read the response body: %s", err)
+			}
+
+			if len(body) != 0 {
+				t.Fatalf("unexpected body %q", string(body))
+			}
+		})
+	}
+}
+
+func TestResponseToHEADRequestAnnouncesTheBodyLength(t *testing.T) {
+	response := propre.NewHTTPResponse[successViewModel]()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		response.Send(req.Context(), rw, successViewModel{Data: "some data"})
+	}))
+	defer server.Close()
+
+	result, err := http.Head(server.URL)
+	if err != nil {
+		t.Fatalf("HEAD request failed: %s", err)
+	}
+	defer result.Body.Close()
+
+	if result.StatusCode != http.StatusOK {
+		t.Fatalf("wrong status code, expected %d, got %d", http.StatusOK, result.StatusCode)
+	}
+
+	expectedContentLength := int64(len(`{"data":"some data"}`))
+	if result.ContentLength != expectedContentLength {
+		t.Fatalf("wrong content length, expected %d, got %d", expectedContentLength, result.ContentLength)
+	}
+
+	if contentType := result.Header.Get("content-type"); contentType != "application/json" {
+		t.Fatalf("wrong content-type header, expected %q, got %q", "application/json", contentType)
+	}
+}
@@ -0,0 +1,401 @@
+package propre
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDialect is the URI of the JSON Schema draft 2020-12, set in the
+// $schema keyword of the schemas built by [JSONSchemaOf].
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12). It is the schema object of the
+// OpenAPI 3.1 documents built by [OpenAPI], and it validates the payloads of
+// a [RequestPayloadExtractor] with [WithJSONSchema].
+//
+// A schema decoded from JSON can use a boolean schema or an array of types,
+// they are converted to the equivalent keywords.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Not                  *Schema            `json:"not,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// ParseJSONSchema decodes a JSON Schema document, like a schema shared by a
+// partner team.
+func ParseJSONSchema(document []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(document, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	return &schema, nil
+}
+
+// UnmarshalJSON decodes a schema, converting the boolean schemas and the
+// arrays of types.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Not: &Schema{}}
+		return nil
+	}
+
+	type plainSchema Schema
+	var raw struct {
+		plainSchema
+		Type json.RawMessage `json:"type,omitempty"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Schema(raw.plainSchema)
+	if len(raw.Type) == 0 {
+		return nil
+	}
+
+	if raw.Type[0] != '[' {
+		return json.Unmarshal(raw.Type, &s.Type)
+	}
+
+	var types []string
+	if err := json.Unmarshal(raw.Type, &types); err != nil {
+		return err
+	}
+
+	if len(types) == 1 {
+		s.Type = types[0]
+		return nil
+	}
+
+	anyType := &Schema{}
+	for _, t := range types {
+		anyType.AnyOf = append(anyType.AnyOf, &Schema{Type: t})
+	}
+
+	s.AllOf = append(s.AllOf, anyType)
+	return nil
+}
+
+// JSONSchemaOf reflects the JSON Schema of a Go type following the encoding/json
+// rules, the named struct types are defined in $defs. The fields are required
+// unless they have the "omitempty" option or are pointers, slices or maps, which
+// are nullable.
+// The following tags
+// add keywords to a field:
+//   - `description:"..."` sets its description,
+//   - `jsonschema:"..."` sets a comma-separated list of keywords, like
+//     `jsonschema:"minLength=1,maxLength=50,pattern=^[a-z]+$"`. The supported keywords
+//     are minimum, maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+//     minItems, maxItems, pattern, format and enum, whose values are separated by "|".
+//
+// It panics if a jsonschema tag is invalid.
+func JSONSchemaOf[T any]() *Schema {
+	reflector := newSchemaReflector("#/$defs/")
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var schema *Schema
+	if t.Kind() == reflect.Struct {
+		schema = reflector.reflectStruct(t)
+	} else {
+		schema = reflector.reflect(t)
+	}
+
+	schema.Schema = JSONSchemaDialect
+	if len(reflector.defs) > 0 {
+		schema.Defs = reflector.defs
+	}
+
+	return schema
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	schemaNameCleaner = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// schemaReflector builds the schemas of Go types following the encoding/json
+// rules. The named struct types are stored in defs and referenced with refPrefix.
+type schemaReflector struct {
+	refPrefix string
+	defs      map[string]*Schema
+	names     map[reflect.Type]string
+}
+
+func newSchemaReflector(refPrefix string) *schemaReflector {
+	return &schemaReflector{
+		refPrefix: refPrefix,
+		defs:      make(map[string]*Schema),
+		names:     make(map[reflect.Type]string),
+	}
+}
+
+func (r *schemaReflector) reflect(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// like encoding/json, a json.Marshaler takes precedence over a TextMarshaler,
+	// its encoding is unknown so any value is allowed
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType, reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}
+	case reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: r.reflect(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.reflect(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.reflectStruct(t)
+		}
+
+		return &Schema{Ref: r.refPrefix + r.define(t)}
+	}
+
+	return &Schema{}
+}
+
+// define stores the schema of a named struct type in defs and returns its name.
+func (r *schemaReflector) define(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	base := strings.Trim(schemaNameCleaner.ReplaceAllString(t.Name(), "_"), "_")
+	name := base
+	for i := 2; r.defs[name] != nil; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	r.names[t] = name
+	r.defs[name] = &Schema{}
+	*r.defs[name] = *r.reflectStruct(t)
+
+	return name
+}
+
+func (r *schemaReflector) reflectStruct(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	r.reflectFields(t, schema)
+
+	return schema
+}
+
+func (r *schemaReflector) reflectFields(t reflect.Type, schema *Schema) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, omitEmpty, asString, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+
+			if fieldType.Kind() == reflect.Struct {
+				r.reflectFields(fieldType, schema)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		property := r.reflect(field.Type)
+		if asString {
+			property = &Schema{Type: "string"}
+		}
+
+		if property.Ref != "" && (field.Tag.Get("description") != "" || field.Tag.Get("jsonschema") != "") {
+			property = &Schema{Ref: property.Ref}
+		}
+
+		property.Description = field.Tag.Get("description")
+		if tag := field.Tag.Get("jsonschema"); tag != "" {
+			if err := applySchemaTag(property, tag); err != nil {
+				panic(fmt.Sprintf("propre: invalid jsonschema tag of the field %s.%s: %s", t, field.Name, err))
+			}
+		}
+
+		nullable := isNilable(field.Type)
+		if nullable {
+			property = &Schema{AnyOf: []*Schema{property, {Type: "null"}}}
+		}
+
+		schema.Properties[name] = property
+		if !omitEmpty && !nullable {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// isNilable tells if the zero value of a field type is encoded as null: the
+// pointers, the slices and the maps.
+func isNilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+
+	return false
+}
+
+func applySchemaTag(schema *Schema, tag string) error {
+	for _, keyword := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(keyword, "=")
+		var err error
+		switch name {
+		case "minimum":
+			schema.Minimum, err = parseSchemaNumber(value)
+		case "maximum":
+			schema.Maximum, err = parseSchemaNumber(value)
+		case "exclusiveMinimum":
+			schema.ExclusiveMinimum, err = parseSchemaNumber(value)
+		case "exclusiveMaximum":
+			schema.ExclusiveMaximum, err = parseSchemaNumber(value)
+		case "minLength":
+			schema.MinLength, err = parseSchemaInt(value)
+		case "maxLength":
+			schema.MaxLength, err = parseSchemaInt(value)
+		case "minItems":
+			schema.MinItems, err = parseSchemaInt(value)
+		case "maxItems":
+			schema.MaxItems, err = parseSchemaInt(value)
+		case "pattern":
+			schema.Pattern = value
+			_, err = regexp.Compile(value)
+		case "format":
+			schema.Format = value
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				if schema.Type != "integer" && schema.Type != "number" {
+					schema.Enum = append(schema.Enum, v)
+					continue
+				}
+
+				var n *float64
+				if n, err = parseSchemaNumber(v); err != nil {
+					break
+				}
+
+				schema.Enum = append(schema.Enum, *n)
+			}
+		default:
+			err = fmt.Errorf("unknown keyword %q", name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseSchemaNumber(value string) (*float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	return &n, err
+}
+
+func parseSchemaInt(value string) (*int, error) {
+	n, err := strconv.Atoi(value)
+	return &n, err
+}
+
+// jsonFieldName returns the name of a struct field encoded by encoding/json and
+// its options. It returns skip for the unexported and ignored fields.
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, asString, skip bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false, false, true
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false, true
+	}
+
+	name, options, _ := strings.Cut(tag, ",")
+	for _, option := range strings.Split(options, ",") {
+		switch option {
+		case "omitempty", "omitzero":
+			omitEmpty = true
+		case "string":
+			asString = true
+		}
+	}
+
+	if !field.IsExported() && (name != "" || field.Type.Kind() != reflect.Struct) {
+		return "", false, false, true
+	}
+
+	return name, omitEmpty, asString, false
+}
@@ -0,0 +1,140 @@
+package propre_test
+
+import (
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type addressPayload struct {
+	City string `json:"city" jsonschema:"minLength=1"`
+}
+
+type signupPayload struct {
+	Email    string            `json:"email" jsonschema:"format=email,maxLength=100"`
+	Age      int               `json:"age,omitempty" jsonschema:"minimum=18"`
+	Plan     string            `json:"plan" jsonschema:"enum=free|pro"`
+	Address  addressPayload    `json:"address" description:"postal address"`
+	Previous *addressPayload   `json:"previous"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	internal string
+}
+
+func (p signupPayload) Validate() error {
+	return nil
+}
+
+func TestJSONSchemaOf(t *testing.T) {
+	schema := propre.JSONSchemaOf[signupPayload]()
+
+	doc, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+		`"properties":{` +
+		`"address":{"$ref":"#/$defs/addressPayload","description":"postal address"},` +
+		`"age":{"type":"integer","format":"int64","minimum":18},` +
+		`"email":{"type":"string","format":"email","maxLength":100},` +
+		`"labels":{"anyOf":[{"type":"object","additionalProperties":{"type":"string"}},{"type":"null"}]},` +
+		`"plan":{"type":"string","enum":["free","pro"]},` +
+		`"previous":{"anyOf":[{"$ref":"#/$defs/addressPayload"},{"type":"null"}]}},` +
+		`"required":["email","plan","address"],` +
+		`"$defs":{"addressPayload":{"type":"object","properties":{"city":{"type":"string","minLength":1}},"required":["city"]}}}`
+
+	if string(doc) != expected {
+		t.Fatalf("unexpected schema, expected:\n%s\ngot:\n%s", expected, doc)
+	}
+}
+
+type userID struct {
+	value string
+}
+
+func (id userID) MarshalText() ([]byte, error) {
+	return []byte(id.value), nil
+}
+
+type flexibleAmount struct {
+	value float64
+}
+
+func (a flexibleAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.value)
+}
+
+type marshalersPayload struct {
+	ID     userID         `json:"id"`
+	Owner  *userID        `json:"owner"`
+	IP     netip.Addr     `json:"ip"`
+	Amount flexibleAmount `json:"amount"`
+}
+
+func TestJSONSchemaOfMarshalers(t *testing.T) {
+	doc, err := json.Marshal(propre.JSONSchemaOf[marshalersPayload]())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+		`"properties":{` +
+		`"amount":{},` +
+		`"id":{"type":"string"},` +
+		`"ip":{"type":"string"},` +
+		`"owner":{"anyOf":[{"type":"string"},{"type":"null"}]}},` +
+		`"required":["id","ip","amount"]}`
+
+	if string(doc) != expected {
+		t.Fatalf("unexpected schema, expected:\n%s\ngot:\n%s", expected, doc)
+	}
+}
+
+func TestParseJSONSchema(t *testing.T) {
+	type testCase struct {
+		document    string
+		instance    any
+		expectValid bool
+	}
+
+	testCases := map[string]testCase{
+		"an array of types accepts each type": {
+			document:    `{"type":["string","null"]}`,
+			instance:    nil,
+			expectValid: true,
+		},
+		"an array of types rejects the other types": {
+			document: `{"type":["string","null"]}`,
+			instance: 42.0,
+		},
+		"a false schema rejects the additional properties": {
+			document: `{"type":"object","properties":{"a":true},"additionalProperties":false}`,
+			instance: map[string]any{"a": 1.0, "b": 2.0},
+		},
+		"a true schema accepts anything": {
+			document:    `{"type":"object","properties":{"a":true},"additionalProperties":false}`,
+			instance:    map[string]any{"a": []any{"x"}},
+			expectValid: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			schema, err := propre.ParseJSONSchema([]byte(tc.document))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			err = schema.ValidateInstance(tc.instance)
+			if (err == nil) != tc.expectValid {
+				t.Fatalf("unexpected validation result: %v", err)
+			}
+		})
+	}
+
+	if _, err := propre.ParseJSONSchema([]byte(`{"type":`)); err == nil {
+		t.Fatal("an invalid document should be rejected")
+	}
+}
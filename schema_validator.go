@@ -0,0 +1,328 @@
+package propre
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrSchemaValidation is wrapped by [SchemaValidationError].
+	ErrSchemaValidation = errors.New("schema validation error")
+)
+
+// SchemaViolation is a value which does not match its schema. Path is the JSON
+// pointer of the value, like "/items/0/title".
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// SchemaValidationError is returned when a value does not match a [Schema].
+// It lists all the violations and wraps [ErrSchemaValidation].
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Path + ": " + violation.Message
+	}
+
+	return fmt.Sprintf("%s: %s", ErrSchemaValidation, strings.Join(messages, ", "))
+}
+
+func (e *SchemaValidationError) Unwrap() error {
+	return ErrSchemaValidation
+}
+
+// ValidateValue encodes a Go value in JSON, then validates it with ValidateInstance.
+func (s *Schema) ValidateValue(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w caused by %w", ErrSchemaValidation, err)
+	}
+
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		return fmt.Errorf("%w caused by %w", ErrSchemaValidation, err)
+	}
+
+	return s.ValidateInstance(instance)
+}
+
+// ValidateInstance validates a JSON value decoded by encoding/json in an any,
+// that is a map[string]any, a []any, a string, a float64 or a [json.Number] if
+// the decoder uses numbers, a bool or nil. It returns
+// a [*SchemaValidationError] if the value does not match the schema.
+//
+// The $ref keyword supports the references to the root schema "#" and to its
+// definitions "#/$defs/name". The format keyword is an annotation, as in the
+// default vocabulary of the draft 2020-12, it is not validated.
+func (s *Schema) ValidateInstance(instance any) error {
+	v := &schemaValidator{root: s, patterns: make(map[string]*regexp.Regexp)}
+	v.validate(s, instance, "")
+	if len(v.violations) == 0 {
+		return nil
+	}
+
+	return &SchemaValidationError{Violations: v.violations}
+}
+
+type schemaValidator struct {
+	root       *Schema
+	patterns   map[string]*regexp.Regexp
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// matches reports whether the instance matches the schema without recording
+// the violations, for the applicators like anyOf.
+func (v *schemaValidator) matches(schema *Schema, instance any, path string) bool {
+	sub := &schemaValidator{root: v.root, patterns: v.patterns}
+	sub.validate(schema, instance, path)
+
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(schema *Schema, instance any, path string) {
+	if number, ok := instance.(json.Number); ok {
+		if value, err := number.Float64(); err == nil {
+			instance = value
+		}
+	}
+
+	if schema.Ref != "" {
+		target, ok := v.resolve(schema.Ref)
+		if !ok {
+			v.fail(path, "unresolvable reference %q", schema.Ref)
+			return
+		}
+
+		v.validate(target, instance, path)
+	}
+
+	if schema.Type != "" && !matchesSchemaType(schema.Type, instance) {
+		v.fail(path, "expected %s, got %s", schema.Type, jsonTypeName(instance))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !containsJSONValue(schema.Enum, instance) {
+		v.fail(path, "value not in the enumeration %v", schema.Enum)
+	}
+
+	if schema.Const != nil && !reflect.DeepEqual(schema.Const, instance) {
+		v.fail(path, "expected the constant %v", schema.Const)
+	}
+
+	switch value := instance.(type) {
+	case float64:
+		v.validateNumber(schema, value, path)
+	case string:
+		v.validateString(schema, value, path)
+	case []any:
+		v.validateArray(schema, value, path)
+	case map[string]any:
+		v.validateObject(schema, value, path)
+	}
+
+	for _, sub := range schema.AllOf {
+		v.validate(sub, instance, path)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, sub := range schema.AnyOf {
+			if v.matches(sub, instance, path) {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			v.fail(path, "value does not match any schema of anyOf")
+		}
+	}
+
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, sub := range schema.OneOf {
+			if v.matches(sub, instance, path) {
+				matched++
+			}
+		}
+
+		if matched != 1 {
+			v.fail(path, "value matches %d schemas of oneOf instead of 1", matched)
+		}
+	}
+
+	if schema.Not != nil && v.matches(schema.Not, instance, path) {
+		v.fail(path, "value matches a schema it must not match")
+	}
+}
+
+func (v *schemaValidator) resolve(ref string) (*Schema, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+
+	name, found := strings.CutPrefix(ref, "#/$defs/")
+	if !found {
+		return nil, false
+	}
+
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	target, ok := v.root.Defs[name]
+
+	return target, ok && target != nil
+}
+
+func (v *schemaValidator) validateNumber(schema *Schema, value float64, path string) {
+	if schema.Minimum != nil && value < *schema.Minimum {
+		v.fail(path, "must be greater than or equal to %v", *schema.Minimum)
+	}
+
+	if schema.Maximum != nil && value > *schema.Maximum {
+		v.fail(path, "must be less than or equal to %v", *schema.Maximum)
+	}
+
+	if schema.ExclusiveMinimum != nil && value <= *schema.ExclusiveMinimum {
+		v.fail(path, "must be greater than %v", *schema.ExclusiveMinimum)
+	}
+
+	if schema.ExclusiveMaximum != nil && value >= *schema.ExclusiveMaximum {
+		v.fail(path, "must be less than %v", *schema.ExclusiveMaximum)
+	}
+}
+
+func (v *schemaValidator) validateString(schema *Schema, value string, path string) {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(path, "must be at least %d characters long", *schema.MinLength)
+	}
+
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(path, "must be at most %d characters long", *schema.MaxLength)
+	}
+
+	if schema.Pattern == "" {
+		return
+	}
+
+	pattern, ok := v.patterns[schema.Pattern]
+	if !ok {
+		var err error
+		if pattern, err = regexp.Compile(schema.Pattern); err != nil {
+			v.fail(path, "invalid pattern %q", schema.Pattern)
+			return
+		}
+
+		v.patterns[schema.Pattern] = pattern
+	}
+
+	if !pattern.MatchString(value) {
+		v.fail(path, "must match the pattern %q", schema.Pattern)
+	}
+}
+
+func (v *schemaValidator) validateArray(schema *Schema, value []any, path string) {
+	if schema.MinItems != nil && len(value) < *schema.MinItems {
+		v.fail(path, "must have at least %d items", *schema.MinItems)
+	}
+
+	if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+		v.fail(path, "must have at most %d items", *schema.MaxItems)
+	}
+
+	if schema.Items == nil {
+		return
+	}
+
+	for i, item := range value {
+		v.validate(schema.Items, item, fmt.Sprintf("%s/%d", path, i))
+	}
+}
+
+func (v *schemaValidator) validateObject(schema *Schema, value map[string]any, path string) {
+	for _, name := range schema.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "/" + strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+		if property, ok := schema.Properties[name]; ok {
+			v.validate(property, value[name], propertyPath)
+			continue
+		}
+
+		if schema.AdditionalProperties != nil {
+			v.validate(schema.AdditionalProperties, value[name], propertyPath)
+		}
+	}
+}
+
+func matchesSchemaType(schemaType string, instance any) bool {
+	switch schemaType {
+	case "integer":
+		n, ok := instance.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := instance.(float64)
+		return ok
+	}
+
+	return schemaType == jsonTypeName(instance)
+}
+
+func jsonTypeName(instance any) string {
+	switch instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", instance)
+}
+
+func containsJSONValue(values []any, instance any) bool {
+	for _, value := range values {
+		if reflect.DeepEqual(value, instance) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+package propre_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+const orderSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["id", "lines"],
+	"properties": {
+		"id": {"type": "string", "pattern": "^ord-[0-9]+$"},
+		"lines": {"type": "array", "minItems": 1, "items": {"$ref": "#/$defs/line"}},
+		"discount": {"oneOf": [{"type": "null"}, {"type": "number", "exclusiveMinimum": 0, "maximum": 50}]},
+		"status": {"enum": ["draft", "placed"]}
+	},
+	"$defs": {
+		"line": {
+			"type": "object",
+			"required": ["sku", "quantity"],
+			"properties": {
+				"sku": {"type": "string", "minLength": 3, "maxLength": 8},
+				"quantity": {"type": "integer", "minimum": 1}
+			},
+			"not": {"required": ["free"]}
+		}
+	}
+}`
+
+func TestSchemaValidateInstance(t *testing.T) {
+	type testCase struct {
+		instance           any
+		expectedViolations []propre.SchemaViolation
+	}
+
+	validLine := map[string]any{"sku": "abc", "quantity": 2.0}
+
+	testCases := map[string]testCase{
+		"valid instance": {
+			instance: map[string]any{"id": "ord-1", "lines": []any{validLine}, "discount": 10.0, "status": "draft"},
+		},
+		"missing required properties": {
+			instance: map[string]any{},
+			expectedViolations: []propre.SchemaViolation{
+				{Path: "/", Message: `missing required property "id"`},
+				{Path: "/", Message: `missing required property "lines"`},
+			},
+		},
+		"nested violations": {
+			instance: map[string]any{
+				"id":    "order-1",
+				"lines": []any{validLine, map[string]any{"sku": "ab", "quantity": 1.5, "free": true}},
+			},
+			expectedViolations: []propre.SchemaViolation{
+				{Path: "/id", Message: `must match the pattern "^ord-[0-9]+$"`},
+				{Path: "/lines/1/quantity", Message: "expected integer, got number"},
+				{Path: "/lines/1/sku", Message: "must be at least 3 characters long"},
+				{Path: "/lines/1", Message: "value matches a schema it must not match"},
+			},
+		},
+		"combinators and enumerations": {
+			instance: map[string]any{"id": "ord-1", "lines": []any{}, "discount": 0.0, "status": "sent"},
+			expectedViolations: []propre.SchemaViolation{
+				{Path: "/discount", Message: "value matches 0 schemas of oneOf instead of 1"},
+				{Path: "/lines", Message: "must have at least 1 items"},
+				{Path: "/status", Message: "value not in the enumeration [draft placed]"},
+			},
+		},
+		"JSON numbers": {
+			instance: map[string]any{
+				"id":       "ord-1",
+				"lines":    []any{validLine, map[string]any{"sku": "abc", "quantity": json.Number("1.5")}},
+				"discount": json.Number("10"),
+			},
+			expectedViolations: []propre.SchemaViolation{
+				{Path: "/lines/1/quantity", Message: "expected integer, got number"},
+			},
+		},
+		"wrong type": {
+			instance:           []any{},
+			expectedViolations: []propre.SchemaViolation{{Path: "/", Message: "expected object, got array"}},
+		},
+	}
+
+	schema, err := propre.ParseJSONSchema([]byte(orderSchema))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := schema.ValidateInstance(tc.instance)
+			if tc.expectedViolations == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				return
+			}
+
+			var validationErr *propre.SchemaValidationError
+			if !errors.As(err, &validationErr) || !errors.Is(err, propre.ErrSchemaValidation) {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if len(validationErr.Violations) != len(tc.expectedViolations) {
+				t.Fatalf("unexpected violations, expected %v, got %v", tc.expectedViolations, validationErr.Violations)
+			}
+
+			for i, violation := range validationErr.Violations {
+				if violation != tc.expectedViolations[i] {
+					t.Fatalf("unexpected violation %d, expected %v, got %v", i, tc.expectedViolations[i], violation)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaValidateValue(t *testing.T) {
+	schema := propre.JSONSchemaOf[signupPayload]()
+
+	valid := signupPayload{Email: "john@example.com", Plan: "pro", Address: addressPayload{City: "Paris"}}
+	if err := schema.ValidateValue(valid); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	invalid := signupPayload{Email: "john@example.com", Age: 16, Plan: "gold", Address: addressPayload{City: "Paris"}}
+	err := schema.ValidateValue(invalid)
+
+	var validationErr *propre.SchemaValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Violations) != 2 {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
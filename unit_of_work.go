@@ -0,0 +1,246 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrUnitOfWorkBegin is returned by a [TransactionalUseCase] if its
+	// [TxManager] cannot begin a unit of work.
+	ErrUnitOfWorkBegin = errors.New("cannot begin the unit of work")
+
+	// ErrUnitOfWorkCommit is returned by a [TransactionalUseCase] if the unit of
+	// work cannot be committed.
+	ErrUnitOfWorkCommit = errors.New("cannot commit the unit of work")
+
+	// ErrUnitOfWorkRollback is returned by a [TransactionalUseCase] along with the
+	// error of the output if the unit of work cannot be rolled back.
+	ErrUnitOfWorkRollback = errors.New("cannot roll back the unit of work")
+
+	// ErrSavepointUnsupported is returned by a [TransactionalUseCase] using
+	// savepoints if the unit of work in progress does not implement [SavepointUnitOfWork].
+	ErrSavepointUnsupported = errors.New("the unit of work does not support savepoints")
+)
+
+// UnitOfWork is a transaction in progress, like a database transaction.
+// A [TransactionalUseCase] commits it if the use case succeeds and rolls it
+// back otherwise.
+type UnitOfWork interface {
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// SavepointUnitOfWork is a [UnitOfWork] supporting nested units of work, see
+// [WithSavepoints]. Committing the nested unit of work releases the savepoint,
+// rolling it back cancels the changes made since the savepoint only.
+type SavepointUnitOfWork interface {
+	UnitOfWork
+	Savepoint(ctx context.Context) (UnitOfWork, error)
+}
+
+// TxManager begins the units of work of a [TransactionalUseCase].
+//
+// [SQLTxManager] begins database/sql transactions, and [InMemoryTxManager] is a
+// fake to test the use cases without a database.
+type TxManager interface {
+	Begin(ctx context.Context) (UnitOfWork, error)
+}
+
+type unitOfWorkContextKey struct{}
+
+// ContextWithUnitOfWork returns a copy of the context holding the given unit of work.
+func ContextWithUnitOfWork(ctx context.Context, uow UnitOfWork) context.Context {
+	return context.WithValue(ctx, unitOfWorkContextKey{}, uow)
+}
+
+// UnitOfWorkFromContext returns the unit of work in progress stored in the
+// context, if any. Repositories call it with the context given to the use case
+// to take part in its unit of work, see [SQLQuerierFromContext].
+func UnitOfWorkFromContext(ctx context.Context) (UnitOfWork, bool) {
+	uow, ok := ctx.Value(unitOfWorkContextKey{}).(UnitOfWork)
+	return uow, ok
+}
+
+type afterCommitContextKey struct{}
+
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+func (h *afterCommitHooks) add(hooks ...func(ctx context.Context)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, hooks...)
+}
+
+func (h *afterCommitHooks) take() []func(ctx context.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hooks := h.hooks
+	h.hooks = nil
+
+	return hooks
+}
+
+// AfterCommit registers a function called once the unit of work of the
+// [TransactionalUseCase] in progress is committed, in the order of the
+// registrations. The functions registered in a savepoint are called once the
+// outermost unit of work is committed, and dropped if the savepoint is rolled back.
+//
+// It returns false without registering the function if the context does not
+// come from a TransactionalUseCase, so the caller can call it right away.
+func AfterCommit(ctx context.Context, hook func(ctx context.Context)) bool {
+	hooks, ok := ctx.Value(afterCommitContextKey{}).(*afterCommitHooks)
+	if !ok {
+		return false
+	}
+
+	hooks.add(hook)
+	return true
+}
+
+// TransactionalUseCase is a [UseCaseHandler] running the wrapped use case
+// handler in a unit of work. The unit of work is stored in the context given to
+// the use case, so the repositories share it, see [UnitOfWorkFromContext].
+//
+// The outputError function returns the error held by the output: the unit of
+// work is committed if it is nil and rolled back otherwise. It is rolled back
+// as well if the use case panics, and the panic goes on. The errors of the unit
+// of work itself are converted to an output by the errorOutput function.
+//
+// When the context already holds a unit of work, like in a use case calling
+// another transactional use case, the nested use case joins it and the outermost
+// use case commits or rolls back every change. With [WithSavepoints], the nested
+// use case runs in a savepoint instead, so its changes can be rolled back alone.
+type TransactionalUseCase[Input, Output any] struct {
+	useCaseHandler UseCaseHandler[Input, Output]
+	txManager      TxManager
+	outputError    func(output Output) error
+	errorOutput    func(err error) Output
+	savepoints     bool
+}
+
+// TransactionalUseCaseOpts is the alias for the [TransactionalUseCase] builder options.
+type TransactionalUseCaseOpts[Input, Output any] func(u *TransactionalUseCase[Input, Output])
+
+// WithSavepoints is a [TransactionalUseCase] option to run the use case in a
+// savepoint of the unit of work in progress, if any, instead of joining it.
+// The unit of work must implement [SavepointUnitOfWork], otherwise the output
+// holds [ErrSavepointUnsupported] and the use case is not called.
+func WithSavepoints[Input, Output any]() TransactionalUseCaseOpts[Input, Output] {
+	return func(u *TransactionalUseCase[Input, Output]) {
+		u.savepoints = true
+	}
+}
+
+// NewTransactionalUseCase builds a [TransactionalUseCase] beginning the units of
+// work with the given manager.
+func NewTransactionalUseCase[Input, Output any](
+	useCaseHandler UseCaseHandler[Input, Output],
+	txManager TxManager,
+	outputError func(output Output) error,
+	errorOutput func(err error) Output,
+	opts ...TransactionalUseCaseOpts[Input, Output],
+) *TransactionalUseCase[Input, Output] {
+	u := &TransactionalUseCase[Input, Output]{
+		useCaseHandler: useCaseHandler,
+		txManager:      txManager,
+		outputError:    outputError,
+		errorOutput:    errorOutput,
+	}
+
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
+}
+
+// Handle implements [UseCaseHandler].
+func (u *TransactionalUseCase[Input, Output]) Handle(ctx context.Context, input Input) Output {
+	uow, err := u.begin(ctx)
+	if err != nil {
+		return u.errorOutput(err)
+	}
+
+	if uow == nil {
+		return u.useCaseHandler.Handle(ctx, input)
+	}
+
+	settled := false
+	defer func() {
+		// the use case panicked, the panic goes on once the unit of work is rolled back
+		if !settled {
+			uow.Rollback(context.WithoutCancel(ctx))
+		}
+	}()
+
+	output := u.run(ctx, uow, input)
+	settled = true
+
+	return output
+}
+
+func (u *TransactionalUseCase[Input, Output]) run(ctx context.Context, uow UnitOfWork, input Input) Output {
+	hooks := &afterCommitHooks{}
+	useCaseCtx := context.WithValue(ContextWithUnitOfWork(ctx, uow), afterCommitContextKey{}, hooks)
+	output := u.useCaseHandler.Handle(useCaseCtx, input)
+	if outputErr := u.outputError(output); outputErr != nil {
+		if err := uow.Rollback(context.WithoutCancel(ctx)); err != nil {
+			return u.errorOutput(errors.Join(outputErr, fmt.Errorf("%w caused by %w", ErrUnitOfWorkRollback, err)))
+		}
+
+		return output
+	}
+
+	if err := uow.Commit(ctx); err != nil {
+		uow.Rollback(context.WithoutCancel(ctx))
+		return u.errorOutput(fmt.Errorf("%w caused by %w", ErrUnitOfWorkCommit, err))
+	}
+
+	// the hooks of a savepoint wait for the commit of the unit of work it belongs to
+	if parent, ok := ctx.Value(afterCommitContextKey{}).(*afterCommitHooks); ok {
+		parent.add(hooks.take()...)
+		return output
+	}
+
+	for _, hook := range hooks.take() {
+		hook(ctx)
+	}
+
+	return output
+}
+
+// begin returns the unit of work of the use case, or nil if it joins the unit
+// of work in progress.
+func (u *TransactionalUseCase[Input, Output]) begin(ctx context.Context) (UnitOfWork, error) {
+	current, ok := UnitOfWorkFromContext(ctx)
+	if !ok {
+		uow, err := u.txManager.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w caused by %w", ErrUnitOfWorkBegin, err)
+		}
+
+		return uow, nil
+	}
+
+	if !u.savepoints {
+		return nil, nil
+	}
+
+	savepoints, ok := current.(SavepointUnitOfWork)
+	if !ok {
+		return nil, ErrSavepointUnsupported
+	}
+
+	uow, err := savepoints.Savepoint(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w caused by %w", ErrUnitOfWorkBegin, err)
+	}
+
+	return uow, nil
+}
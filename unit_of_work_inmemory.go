@@ -0,0 +1,198 @@
+package propre
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnitOfWorkDone is returned by an [InMemoryUnitOfWork] already committed or
+// rolled back.
+var ErrUnitOfWorkDone = errors.New("unit of work already committed or rolled back")
+
+// UnitOfWorkState is the state of an [InMemoryUnitOfWork].
+type UnitOfWorkState int
+
+const (
+	// UnitOfWorkPending is the state of a unit of work in progress.
+	UnitOfWorkPending UnitOfWorkState = iota
+	// UnitOfWorkCommitted is the state of a committed unit of work, or of a
+	// released savepoint.
+	UnitOfWorkCommitted
+	// UnitOfWorkRolledBack is the state of a rolled back unit of work.
+	UnitOfWorkRolledBack
+)
+
+// InMemoryTxManager is a [TxManager] beginning [InMemoryUnitOfWork] values.
+// It is meant to test the transactional use cases without a database: every
+// unit of work is recorded to assert if it has been committed or rolled back,
+// and the in-memory repositories can register hooks to apply or undo their
+// changes, see [InMemoryUnitOfWork.OnCommit] and [InMemoryUnitOfWork.OnRollback].
+type InMemoryTxManager struct {
+	mu          sync.Mutex
+	unitsOfWork []*InMemoryUnitOfWork
+	beginErr    error
+	commitErr   error
+}
+
+// InMemoryTxManagerOpts is the alias for the [InMemoryTxManager] builder options.
+type InMemoryTxManagerOpts func(m *InMemoryTxManager)
+
+// WithInMemoryBeginError is an [InMemoryTxManager] option to fail to begin the
+// units of work with the given error.
+func WithInMemoryBeginError(err error) InMemoryTxManagerOpts {
+	return func(m *InMemoryTxManager) {
+		m.beginErr = err
+	}
+}
+
+// WithInMemoryCommitError is an [InMemoryTxManager] option to fail to commit the
+// units of work with the given error. The savepoints are released.
+func WithInMemoryCommitError(err error) InMemoryTxManagerOpts {
+	return func(m *InMemoryTxManager) {
+		m.commitErr = err
+	}
+}
+
+// NewInMemoryTxManager builds an [InMemoryTxManager].
+func NewInMemoryTxManager(opts ...InMemoryTxManagerOpts) *InMemoryTxManager {
+	m := &InMemoryTxManager{}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Begin implements [TxManager], it returns an [*InMemoryUnitOfWork].
+func (m *InMemoryTxManager) Begin(ctx context.Context) (UnitOfWork, error) {
+	if m.beginErr != nil {
+		return nil, m.beginErr
+	}
+
+	uow := &InMemoryUnitOfWork{manager: m}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unitsOfWork = append(m.unitsOfWork, uow)
+
+	return uow, nil
+}
+
+// UnitsOfWork returns the units of work begun so far, in order, without their savepoints.
+func (m *InMemoryTxManager) UnitsOfWork() []*InMemoryUnitOfWork {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*InMemoryUnitOfWork(nil), m.unitsOfWork...)
+}
+
+// InMemoryUnitOfWork is the [SavepointUnitOfWork] of an [InMemoryTxManager].
+type InMemoryUnitOfWork struct {
+	manager    *InMemoryTxManager
+	parent     *InMemoryUnitOfWork
+	state      UnitOfWorkState
+	savepoints []*InMemoryUnitOfWork
+	onCommit   []func()
+	onRollback []func()
+}
+
+// OnCommit registers a function called when the unit of work is committed.
+// The functions registered on a savepoint are called when the unit of work the
+// savepoint belongs to is committed.
+func (u *InMemoryUnitOfWork) OnCommit(f func()) {
+	u.manager.mu.Lock()
+	defer u.manager.mu.Unlock()
+	u.onCommit = append(u.onCommit, f)
+}
+
+// OnRollback registers a function called when the unit of work is rolled back,
+// in the reverse order of the registrations. The functions registered on a
+// released savepoint are called when the unit of work it belongs to is rolled back.
+func (u *InMemoryUnitOfWork) OnRollback(f func()) {
+	u.manager.mu.Lock()
+	defer u.manager.mu.Unlock()
+	u.onRollback = append(u.onRollback, f)
+}
+
+// State returns the state of the unit of work.
+func (u *InMemoryUnitOfWork) State() UnitOfWorkState {
+	u.manager.mu.Lock()
+	defer u.manager.mu.Unlock()
+
+	return u.state
+}
+
+// Savepoints returns the savepoints created in the unit of work, in order.
+func (u *InMemoryUnitOfWork) Savepoints() []*InMemoryUnitOfWork {
+	u.manager.mu.Lock()
+	defer u.manager.mu.Unlock()
+
+	return append([]*InMemoryUnitOfWork(nil), u.savepoints...)
+}
+
+// Commit implements [UnitOfWork].
+func (u *InMemoryUnitOfWork) Commit(ctx context.Context) error {
+	u.manager.mu.Lock()
+	if u.state != UnitOfWorkPending {
+		u.manager.mu.Unlock()
+		return ErrUnitOfWorkDone
+	}
+
+	if u.parent != nil {
+		u.state = UnitOfWorkCommitted
+		u.parent.onCommit = append(u.parent.onCommit, u.onCommit...)
+		u.parent.onRollback = append(u.parent.onRollback, u.onRollback...)
+		u.manager.mu.Unlock()
+		return nil
+	}
+
+	if u.manager.commitErr != nil {
+		u.manager.mu.Unlock()
+		return u.manager.commitErr
+	}
+
+	u.state = UnitOfWorkCommitted
+	hooks := u.onCommit
+	u.manager.mu.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+
+	return nil
+}
+
+// Rollback implements [UnitOfWork].
+func (u *InMemoryUnitOfWork) Rollback(ctx context.Context) error {
+	u.manager.mu.Lock()
+	if u.state != UnitOfWorkPending {
+		u.manager.mu.Unlock()
+		return ErrUnitOfWorkDone
+	}
+
+	u.state = UnitOfWorkRolledBack
+	hooks := u.onRollback
+	u.manager.mu.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+
+	return nil
+}
+
+// Savepoint implements [SavepointUnitOfWork], it returns an [*InMemoryUnitOfWork].
+func (u *InMemoryUnitOfWork) Savepoint(ctx context.Context) (UnitOfWork, error) {
+	u.manager.mu.Lock()
+	defer u.manager.mu.Unlock()
+
+	if u.state != UnitOfWorkPending {
+		return nil, ErrUnitOfWorkDone
+	}
+
+	savepoint := &InMemoryUnitOfWork{manager: u.manager, parent: u}
+	u.savepoints = append(u.savepoints, savepoint)
+
+	return savepoint, nil
+}
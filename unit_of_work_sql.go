@@ -0,0 +1,128 @@
+package propre
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync/atomic"
+)
+
+// SQLQuerier is the part of [*sql.DB] and [*sql.Tx] used by the repositories,
+// see [SQLQuerierFromContext].
+type SQLQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// SQLTxManager is a [TxManager] beginning database/sql transactions.
+type SQLTxManager struct {
+	db        *sql.DB
+	txOptions *sql.TxOptions
+}
+
+// SQLTxManagerOpts is the alias for the [SQLTxManager] builder options.
+type SQLTxManagerOpts func(m *SQLTxManager)
+
+// WithSQLTxOptions is a [SQLTxManager] option to begin the transactions with
+// the given isolation level and read-only mode.
+func WithSQLTxOptions(txOptions *sql.TxOptions) SQLTxManagerOpts {
+	return func(m *SQLTxManager) {
+		m.txOptions = txOptions
+	}
+}
+
+// NewSQLTxManager builds a [SQLTxManager] beginning the transactions on the database.
+func NewSQLTxManager(db *sql.DB, opts ...SQLTxManagerOpts) *SQLTxManager {
+	m := &SQLTxManager{db: db}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Begin implements [TxManager], it returns a [*SQLUnitOfWork].
+func (m *SQLTxManager) Begin(ctx context.Context) (UnitOfWork, error) {
+	tx, err := m.db.BeginTx(ctx, m.txOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLUnitOfWork{tx: tx, savepoints: new(atomic.Int64)}, nil
+}
+
+// SQLUnitOfWork is the [SavepointUnitOfWork] of a [SQLTxManager]. The savepoints
+// are created with the SAVEPOINT statement, supported by PostgreSQL, MySQL and SQLite.
+type SQLUnitOfWork struct {
+	tx         *sql.Tx
+	savepoint  string
+	savepoints *atomic.Int64
+}
+
+// Tx returns the transaction of the unit of work, shared by its savepoints.
+func (u *SQLUnitOfWork) Tx() *sql.Tx {
+	return u.tx
+}
+
+// Commit commits the transaction, or releases the savepoint.
+func (u *SQLUnitOfWork) Commit(ctx context.Context) error {
+	if u.savepoint == "" {
+		return u.tx.Commit()
+	}
+
+	_, err := u.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+u.savepoint)
+	return err
+}
+
+// Rollback rolls back the transaction, or the changes made since the savepoint.
+func (u *SQLUnitOfWork) Rollback(ctx context.Context) error {
+	if u.savepoint == "" {
+		return u.tx.Rollback()
+	}
+
+	_, err := u.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+u.savepoint)
+	return err
+}
+
+// Savepoint implements [SavepointUnitOfWork].
+func (u *SQLUnitOfWork) Savepoint(ctx context.Context) (UnitOfWork, error) {
+	name := "propre_" + strconv.FormatInt(u.savepoints.Add(1), 10)
+	if _, err := u.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return nil, err
+	}
+
+	return &SQLUnitOfWork{tx: u.tx, savepoint: name, savepoints: u.savepoints}, nil
+}
+
+// SQLTxFromContext returns the transaction of the [SQLUnitOfWork] stored in
+// the context, if any.
+func SQLTxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	uow, ok := UnitOfWorkFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+
+	sqlUOW, ok := uow.(*SQLUnitOfWork)
+	if !ok {
+		return nil, false
+	}
+
+	return sqlUOW.tx, true
+}
+
+// SQLQuerierFromContext returns the transaction of the unit of work stored in
+// the context, or the database outside of a unit of work. The repositories call
+// it to run their queries in the unit of work of the use case, if any:
+//
+//	func (r *TodoRepository) Save(ctx context.Context, todo Todo) error {
+//		_, err := propre.SQLQuerierFromContext(ctx, r.db).ExecContext(ctx, "INSERT INTO todos ...")
+//		return err
+//	}
+func SQLQuerierFromContext(ctx context.Context, db *sql.DB) SQLQuerier {
+	if tx, ok := SQLTxFromContext(ctx); ok {
+		return tx
+	}
+
+	return db
+}
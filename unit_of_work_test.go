@@ -0,0 +1,473 @@
+package propre_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cyb3rd4d/propre"
+)
+
+type saveTodoOutput struct {
+	Error error
+}
+
+func saveTodoOutputError(output saveTodoOutput) error {
+	return output.Error
+}
+
+func saveTodoErrorOutput(err error) saveTodoOutput {
+	return saveTodoOutput{Error: err}
+}
+
+// todoRepository is an in-memory repository taking part in the unit of work
+// of the context.
+type todoRepository struct {
+	mu    sync.Mutex
+	todos []string
+}
+
+func (r *todoRepository) Save(ctx context.Context, todo string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.todos = append(r.todos, todo)
+
+	if uow, ok := propre.UnitOfWorkFromContext(ctx); ok {
+		uow.(*propre.InMemoryUnitOfWork).OnRollback(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.todos = r.todos[:len(r.todos)-1]
+		})
+	}
+}
+
+func (r *todoRepository) Todos() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.todos...)
+}
+
+type saveTodoUseCase struct {
+	repository *todoRepository
+	err        error
+	panics     bool
+}
+
+func (u *saveTodoUseCase) Handle(ctx context.Context, todo string) saveTodoOutput {
+	u.repository.Save(ctx, todo)
+	if u.panics {
+		panic("boom")
+	}
+
+	return saveTodoOutput{Error: u.err}
+}
+
+func TestTransactionalUseCase(t *testing.T) {
+	type testCase struct {
+		opts          []propre.InMemoryTxManagerOpts
+		useCaseErr    error
+		expectedErr   error
+		expectedState propre.UnitOfWorkState
+		expectedTodos []string
+	}
+
+	errUseCase := errors.New("use case error")
+	errDatabase := errors.New("database error")
+
+	testCases := map[string]testCase{
+		"the unit of work is committed if the output has no error": {
+			expectedState: propre.UnitOfWorkCommitted,
+			expectedTodos: []string{"todo"},
+		},
+		"the unit of work is rolled back if the output has an error": {
+			useCaseErr:    errUseCase,
+			expectedErr:   errUseCase,
+			expectedState: propre.UnitOfWorkRolledBack,
+		},
+		"a commit error is returned in the output": {
+			opts:          []propre.InMemoryTxManagerOpts{propre.WithInMemoryCommitError(errDatabase)},
+			expectedErr:   propre.ErrUnitOfWorkCommit,
+			expectedState: propre.UnitOfWorkRolledBack,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			txManager := propre.NewInMemoryTxManager(tc.opts...)
+			repository := &todoRepository{}
+			useCase := propre.NewTransactionalUseCase[string, saveTodoOutput](
+				&saveTodoUseCase{repository: repository, err: tc.useCaseErr},
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+			)
+
+			output := useCase.Handle(context.Background(), "todo")
+			if !errors.Is(output.Error, tc.expectedErr) || (tc.expectedErr == nil && output.Error != nil) {
+				t.Fatalf("expected the error %v, got %v", tc.expectedErr, output.Error)
+			}
+
+			unitsOfWork := txManager.UnitsOfWork()
+			if len(unitsOfWork) != 1 {
+				t.Fatalf("expected 1 unit of work, got %d", len(unitsOfWork))
+			}
+
+			if state := unitsOfWork[0].State(); state != tc.expectedState {
+				t.Fatalf("expected the state %d, got %d", tc.expectedState, state)
+			}
+
+			if todos := repository.Todos(); !reflect.DeepEqual(todos, tc.expectedTodos) {
+				t.Fatalf("expected the todos %v, got %v", tc.expectedTodos, todos)
+			}
+		})
+	}
+}
+
+func TestTransactionalUseCaseBeginError(t *testing.T) {
+	useCase := &saveTodoUseCase{repository: &todoRepository{}}
+	output := propre.NewTransactionalUseCase[string, saveTodoOutput](
+		useCase,
+		propre.NewInMemoryTxManager(propre.WithInMemoryBeginError(errors.New("database error"))),
+		saveTodoOutputError,
+		saveTodoErrorOutput,
+	).Handle(context.Background(), "todo")
+
+	if !errors.Is(output.Error, propre.ErrUnitOfWorkBegin) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrUnitOfWorkBegin, output.Error)
+	}
+
+	if todos := useCase.repository.Todos(); len(todos) != 0 {
+		t.Fatalf("expected the use case not to be called, got the todos %v", todos)
+	}
+}
+
+func TestTransactionalUseCaseRollsBackOnPanic(t *testing.T) {
+	txManager := propre.NewInMemoryTxManager()
+	useCase := propre.NewTransactionalUseCase[string, saveTodoOutput](
+		&saveTodoUseCase{repository: &todoRepository{}, panics: true},
+		txManager,
+		saveTodoOutputError,
+		saveTodoErrorOutput,
+	)
+
+	defer func() {
+		if recovered := recover(); recovered != "boom" {
+			t.Fatalf("expected the panic to go on, got %v", recovered)
+		}
+
+		if state := txManager.UnitsOfWork()[0].State(); state != propre.UnitOfWorkRolledBack {
+			t.Fatalf("expected the unit of work to be rolled back, got the state %d", state)
+		}
+	}()
+
+	useCase.Handle(context.Background(), "todo")
+}
+
+// nestedUseCase saves a todo then calls a nested use case, ignoring its error.
+type nestedUseCase struct {
+	repository *todoRepository
+	nested     propre.UseCaseHandler[string, saveTodoOutput]
+	err        error
+}
+
+func (u *nestedUseCase) Handle(ctx context.Context, todo string) saveTodoOutput {
+	u.repository.Save(ctx, todo)
+	u.nested.Handle(ctx, "nested "+todo)
+
+	return saveTodoOutput{Error: u.err}
+}
+
+func TestNestedTransactionalUseCases(t *testing.T) {
+	type testCase struct {
+		savepoints         bool
+		nestedErr          error
+		outerErr           error
+		expectedSavepoints int
+		expectedTodos      []string
+	}
+
+	errUseCase := errors.New("use case error")
+
+	testCases := map[string]testCase{
+		"a nested use case joins the unit of work in progress": {
+			expectedTodos: []string{"todo", "nested todo"},
+		},
+		"the error of a joined use case is left to the outer use case": {
+			nestedErr:     errUseCase,
+			expectedTodos: []string{"todo", "nested todo"},
+		},
+		"a nested use case with savepoints is committed in a savepoint": {
+			savepoints:         true,
+			expectedSavepoints: 1,
+			expectedTodos:      []string{"todo", "nested todo"},
+		},
+		"a nested use case with savepoints is rolled back alone": {
+			savepoints:         true,
+			nestedErr:          errUseCase,
+			expectedSavepoints: 1,
+			expectedTodos:      []string{"todo"},
+		},
+		"the outer use case rolls back the released savepoints": {
+			savepoints:         true,
+			outerErr:           errUseCase,
+			expectedSavepoints: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			txManager := propre.NewInMemoryTxManager()
+			repository := &todoRepository{}
+
+			var opts []propre.TransactionalUseCaseOpts[string, saveTodoOutput]
+			if tc.savepoints {
+				opts = append(opts, propre.WithSavepoints[string, saveTodoOutput]())
+			}
+
+			nested := propre.NewTransactionalUseCase[string, saveTodoOutput](
+				&saveTodoUseCase{repository: repository, err: tc.nestedErr},
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+				opts...,
+			)
+
+			outer := propre.NewTransactionalUseCase[string, saveTodoOutput](
+				&nestedUseCase{repository: repository, nested: nested, err: tc.outerErr},
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+			)
+
+			outer.Handle(context.Background(), "todo")
+
+			unitsOfWork := txManager.UnitsOfWork()
+			if len(unitsOfWork) != 1 {
+				t.Fatalf("expected 1 unit of work, got %d", len(unitsOfWork))
+			}
+
+			if savepoints := unitsOfWork[0].Savepoints(); len(savepoints) != tc.expectedSavepoints {
+				t.Fatalf("expected %d savepoints, got %d", tc.expectedSavepoints, len(savepoints))
+			}
+
+			if todos := repository.Todos(); !reflect.DeepEqual(todos, tc.expectedTodos) {
+				t.Fatalf("expected the todos %v, got %v", tc.expectedTodos, todos)
+			}
+		})
+	}
+}
+
+type noSavepointUnitOfWork struct{}
+
+func (noSavepointUnitOfWork) Commit(ctx context.Context) error   { return nil }
+func (noSavepointUnitOfWork) Rollback(ctx context.Context) error { return nil }
+
+func TestTransactionalUseCaseSavepointUnsupported(t *testing.T) {
+	useCase := propre.NewTransactionalUseCase[string, saveTodoOutput](
+		&saveTodoUseCase{repository: &todoRepository{}},
+		propre.NewInMemoryTxManager(),
+		saveTodoOutputError,
+		saveTodoErrorOutput,
+		propre.WithSavepoints[string, saveTodoOutput](),
+	)
+
+	ctx := propre.ContextWithUnitOfWork(context.Background(), noSavepointUnitOfWork{})
+	if output := useCase.Handle(ctx, "todo"); !errors.Is(output.Error, propre.ErrSavepointUnsupported) {
+		t.Fatalf("expected the error %v, got %v", propre.ErrSavepointUnsupported, output.Error)
+	}
+}
+
+func TestSQLTxManager(t *testing.T) {
+	type testCase struct {
+		useCaseErr         error
+		nestedErr          error
+		expectedStatements []string
+	}
+
+	errUseCase := errors.New("use case error")
+
+	testCases := map[string]testCase{
+		"the transaction is committed": {
+			expectedStatements: []string{
+				"BEGIN", "INSERT todo", "SAVEPOINT propre_1", "INSERT nested todo",
+				"RELEASE SAVEPOINT propre_1", "COMMIT",
+			},
+		},
+		"the savepoint is rolled back": {
+			nestedErr: errUseCase,
+			expectedStatements: []string{
+				"BEGIN", "INSERT todo", "SAVEPOINT propre_1", "INSERT nested todo",
+				"ROLLBACK TO SAVEPOINT propre_1", "COMMIT",
+			},
+		},
+		"the transaction is rolled back": {
+			useCaseErr: errUseCase,
+			expectedStatements: []string{
+				"BEGIN", "INSERT todo", "SAVEPOINT propre_1", "INSERT nested todo",
+				"RELEASE SAVEPOINT propre_1", "ROLLBACK",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			db, recorder := openRecordingDB(t)
+			txManager := propre.NewSQLTxManager(db)
+
+			nested := propre.NewTransactionalUseCase[string, saveTodoOutput](
+				sqlSaveTodoUseCase{db: db, err: tc.nestedErr},
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+				propre.WithSavepoints[string, saveTodoOutput](),
+			)
+
+			outer := propre.NewTransactionalUseCase[string, saveTodoOutput](
+				sqlSaveTodoUseCase{db: db, nested: nested, err: tc.useCaseErr},
+				txManager,
+				saveTodoOutputError,
+				saveTodoErrorOutput,
+			)
+
+			outer.Handle(context.Background(), "todo")
+
+			if statements := recorder.Statements(); !reflect.DeepEqual(statements, tc.expectedStatements) {
+				t.Fatalf("expected the statements %q, got %q", tc.expectedStatements, statements)
+			}
+		})
+	}
+}
+
+func TestSQLQuerierFromContextOutsideOfAUnitOfWork(t *testing.T) {
+	db, _ := openRecordingDB(t)
+	if querier := propre.SQLQuerierFromContext(context.Background(), db); querier != db {
+		t.Fatalf("expected the database, got %v", querier)
+	}
+}
+
+type sqlSaveTodoUseCase struct {
+	db     *sql.DB
+	nested propre.UseCaseHandler[string, saveTodoOutput]
+	err    error
+}
+
+func (u sqlSaveTodoUseCase) Handle(ctx context.Context, todo string) saveTodoOutput {
+	if _, err := propre.SQLQuerierFromContext(ctx, u.db).ExecContext(ctx, "INSERT "+todo); err != nil {
+		return saveTodoOutput{Error: err}
+	}
+
+	if u.nested != nil {
+		u.nested.Handle(ctx, "nested "+todo)
+	}
+
+	return saveTodoOutput{Error: u.err}
+}
+
+// statementRecorder is a database/sql driver recording the statements it runs.
+// The queries return its columns and rows.
+type statementRecorder struct {
+	mu         sync.Mutex
+	statements []string
+	columns    []string
+	rows       [][]driver.Value
+}
+
+func openRecordingDB(t *testing.T) (*sql.DB, *statementRecorder) {
+	recorder := &statementRecorder{}
+	db := sql.OpenDB(recorder)
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	return db, recorder
+}
+
+func (r *statementRecorder) record(statement string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, statement)
+}
+
+func (r *statementRecorder) Statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.statements...)
+}
+
+func (r *statementRecorder) Connect(ctx context.Context) (driver.Conn, error) {
+	return recordingConn{recorder: r}, nil
+}
+
+func (r *statementRecorder) Driver() driver.Driver {
+	return nil
+}
+
+type recordingConn struct {
+	recorder *statementRecorder
+}
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c recordingConn) Close() error {
+	return nil
+}
+
+func (c recordingConn) Begin() (driver.Tx, error) {
+	c.recorder.record("BEGIN")
+	return recordingTx(c), nil
+}
+
+func (c recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.recorder.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.recorder.record(query)
+	return &recordingRows{columns: c.recorder.columns, rows: c.recorder.rows}, nil
+}
+
+type recordingRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *recordingRows) Columns() []string {
+	return r.columns
+}
+
+func (r *recordingRows) Close() error {
+	return nil
+}
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+type recordingTx recordingConn
+
+func (tx recordingTx) Commit() error {
+	tx.recorder.record("COMMIT")
+	return nil
+}
+
+func (tx recordingTx) Rollback() error {
+	tx.recorder.record("ROLLBACK")
+	return nil
+}